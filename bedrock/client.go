@@ -1,25 +1,54 @@
 package bedrock
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
-	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 )
 
+// retryableCodes are the Bedrock error codes of failures that may go away on a retry
+var retryableCodes = map[string]bool{
+	"ThrottlingException":         true,
+	"ServiceUnavailableException": true,
+	"ModelNotReadyException":      true,
+	"ModelTimeoutException":       true,
+	"InternalServerException":     true,
+}
+
 // Client represents an AWS Bedrock client
 type Client struct {
 	bedrockClient *bedrockruntime.Client
 	modelID       string
+	maxTokens     int
+	temperature   *float64
+	timeout       time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for japanese
+	language string
+	// lastUsage holds the tokens of the last request, if it got a response
+	lastUsage *usage.Tokens
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable, Localizable and usage Reporter interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+	_ usage.Reporter     = (*Client)(nil)
+)
 
 // NewClient creates a new AWS Bedrock client
 func NewClient(region, modelID string) (*Client, error) {
@@ -29,12 +58,28 @@ func NewClient(region, modelID string) (*Client, error) {
 		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
 	}
 
+	// Apply provider settings from config
+	appCfg := appconfig.Get()
+	settings := appCfg.Provider("bedrock")
+
 	// Create Bedrock client
-	bedrockClient := bedrockruntime.NewFromConfig(cfg)
+	bedrockClient := bedrockruntime.NewFromConfig(cfg, func(o *bedrockruntime.Options) {
+		if settings.BaseURL != "" {
+			o.BaseEndpoint = aws.String(settings.BaseURL)
+		}
+	})
+
+	maxTokens := 10000
+	if settings.MaxTokens > 0 {
+		maxTokens = settings.MaxTokens
+	}
 
 	return &Client{
 		bedrockClient: bedrockClient,
 		modelID:       modelID,
+		maxTokens:     maxTokens,
+		temperature:   settings.Temperature,
+		timeout:       appCfg.Timeout(0),
 	}, nil
 }
 
@@ -44,11 +89,25 @@ type AnthropicMessage struct {
 	Content string `json:"content"`
 }
 
+// AnthropicCacheControl marks the end of a prompt prefix that Bedrock caches
+type AnthropicCacheControl struct {
+	Type string `json:"type"`
+}
+
+// AnthropicTextBlock represents a text block of the system prompt
+type AnthropicTextBlock struct {
+	Type         string                 `json:"type"`
+	Text         string                 `json:"text"`
+	CacheControl *AnthropicCacheControl `json:"cache_control,omitempty"`
+}
+
 // AnthropicRequest represents a request to the Anthropic API
 type AnthropicRequest struct {
-	AnthropicVersion string             `json:"anthropic_version"`
-	Messages         []AnthropicMessage `json:"messages"`
-	MaxTokens        int                `json:"max_tokens"`
+	AnthropicVersion string               `json:"anthropic_version"`
+	System           []AnthropicTextBlock `json:"system,omitempty"`
+	Messages         []AnthropicMessage   `json:"messages"`
+	MaxTokens        int                  `json:"max_tokens"`
+	Temperature      *float64             `json:"temperature,omitempty"`
 }
 
 // AnthropicContent represents content in the Anthropic API response
@@ -83,16 +142,49 @@ type AnthropicUsage struct {
 	OutputTokens             int `json:"output_tokens"`
 }
 
-// GenerateCommitMessage generates a commit message based on the provided diff
-func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
-	return c.generateWithAnthropic(diff, branch)
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
 }
 
-// generateWithAnthropic generates a commit message using an Anthropic model
-func (c *Client) generateWithAnthropic(diff string, branch string) (string, error) {
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
+// GenerateCommitMessage generates a commit message based on the provided diff
+func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
 	// Get config and build prompt
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPrompt("japanese", branch, diff)
+	instructions, input := cfg.BuildPromptParts(cfg.PromptLanguage(cmp.Or(c.language, "japanese")), branch, diff)
+	if input == "" {
+		return c.Complete(instructions)
+	}
+	return c.completeWithAnthropic(instructions, input)
+}
+
+// Complete sends a prompt to the model and returns its response
+func (c *Client) Complete(prompt string) (string, error) {
+	return c.completeWithAnthropic("", prompt)
+}
+
+// LastUsage returns the tokens of the last request
+func (c *Client) LastUsage() (usage.Tokens, bool) {
+	if c.lastUsage == nil {
+		return usage.Tokens{}, false
+	}
+	return *c.lastUsage, true
+}
+
+// completeWithAnthropic sends a prompt to an Anthropic model. The instructions, if any, go in
+// the system prompt marked for Bedrock prompt caching.
+func (c *Client) completeWithAnthropic(instructions, prompt string) (string, error) {
+	c.lastUsage = nil
 
 	// Create the request
 	request := AnthropicRequest{
@@ -103,7 +195,11 @@ func (c *Client) generateWithAnthropic(diff string, branch string) (string, erro
 				Content: prompt,
 			},
 		},
-		MaxTokens: 10000,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+	}
+	if instructions != "" {
+		request.System = []AnthropicTextBlock{{Type: "text", Text: instructions, CacheControl: &AnthropicCacheControl{Type: "ephemeral"}}}
 	}
 
 	// Marshal the request to JSON
@@ -119,10 +215,16 @@ func (c *Client) generateWithAnthropic(diff string, branch string) (string, erro
 		Body:        requestBytes,
 	}
 
-	// Invoke the model
-	resp, err := c.bedrockClient.InvokeModel(context.TODO(), invokeInput)
+	// Invoke the model, bounded by the configured timeout if any
+	ctx := client.OrBackground(c.ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	resp, err := c.bedrockClient.InvokeModel(ctx, invokeInput)
 	if err != nil {
-		return "", fmt.Errorf("failed to invoke model: %w", err)
+		return "", classify(fmt.Errorf("failed to invoke model: %w", err))
 	}
 
 	// Parse the response
@@ -130,11 +232,30 @@ func (c *Client) generateWithAnthropic(diff string, branch string) (string, erro
 	if err := json.Unmarshal(resp.Body, &response); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	c.lastUsage = &usage.Tokens{
+		Input:         response.Usage.InputTokens,
+		Output:        response.Usage.OutputTokens,
+		CacheCreation: response.Usage.CacheCreationInputTokens,
+		CacheRead:     response.Usage.CacheReadInputTokens,
+	}
 
-	// Extract the commit message
+	// Extract the response text
 	if len(response.Content) > 0 && len(response.Content[0].Text) > 0 {
 		return response.Content[0].Text, nil
 	}
 
 	return "", fmt.Errorf("no content in response")
 }
+
+// classify marks throttling, unavailability, transient HTTP statuses and timeouts as retryable
+// and everything else (access denied, validation errors, unknown models) as fatal
+func classify(err error) error {
+	var apiErr smithy.APIError
+	var respErr *smithyhttp.ResponseError
+	if errors.As(err, &apiErr) && retryableCodes[apiErr.ErrorCode()] ||
+		errors.As(err, &respErr) && client.IsRetryableStatus(respErr.HTTPStatusCode()) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return client.RetryableError("bedrock", err, 0)
+	}
+	return client.FatalError("bedrock", err)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// runBranch proposes a branch name from a task description or the staged changes and
+// optionally switches to a new branch of that name
+func runBranch(args []string) {
+	branchFlags := flag.NewFlagSet("branch", flag.ExitOnError)
+	mf := addModelFlags(branchFlags)
+	ticket := branchFlags.String("ticket", "", "Ticket ID for {ticket} (default: found in the description by the ticket patterns)")
+	switchBranch := branchFlags.Bool("switch", false, "Create the branch and switch to it with git switch -c")
+	branchFlags.BoolVar(switchBranch, "s", false, "Shorthand for --switch")
+	prompt := branchFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	branchFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	branchFlags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: generate-auto-commit-message branch [options] [description]")
+		fmt.Fprintln(os.Stderr, "  Without a description the branch is named after the staged changes")
+		branchFlags.PrintDefaults()
+	}
+	branchFlags.Parse(args)
+
+	cfg, aiClient := mf.load()
+	opts := message.BranchOptions{
+		Description: strings.Join(branchFlags.Args(), " "),
+		Ticket:      *ticket,
+		ExtraPrompt: *prompt,
+		TokenBudget: cfg.Context.TokenBudget,
+	}
+
+	if opts.Description == "" {
+		diff, err := git.GetStagedDiff()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting staged diff: %v\n", err)
+			os.Exit(1)
+		}
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintln(os.Stderr, "No description given and no staged changes found")
+			os.Exit(1)
+		}
+		files, err := git.GetStagedFilesWithStatus()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting staged files: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Diff, opts.Files = diff, files
+	} else if opts.Ticket == "" {
+		// The ticket patterns that find the ticket in branch names find it in the description too
+		found, err := message.ExtractTicket(opts.Description, cfg.Ticket)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if found != nil {
+			opts.Ticket = found.ID
+		}
+	}
+
+	if cfg.Defaults.Verbose {
+		fmt.Fprintln(os.Stderr, "=== Debug Information ===")
+		fmt.Fprintf(os.Stderr, "Provider: %s\n", mf.Provider)
+		fmt.Fprintf(os.Stderr, "Model ID: %s\n", mf.Model)
+		pattern := cfg.Branch.Pattern
+		if pattern == "" {
+			pattern = message.DefaultBranchPattern
+		}
+		fmt.Fprintf(os.Stderr, "Pattern: %s\n", pattern)
+		if opts.Ticket != "" {
+			fmt.Fprintf(os.Stderr, "Ticket: %s\n", opts.Ticket)
+		}
+		fmt.Fprintln(os.Stderr, "========================")
+	}
+
+	name, err := message.GenerateBranchName(aiClient, cfg.Branch, cfg.SemanticReleasePrefixes, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	printUsage(cfg)
+	if name, err = git.CheckBranchName(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v (check branch.pattern)\n", err)
+		os.Exit(1)
+	}
+
+	if !*switchBranch {
+		fmt.Println(name)
+		return
+	}
+	if err := git.SwitchCreate(name); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Switched to a new branch %s\n", name)
+}
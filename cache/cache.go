@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// entrySuffix is the file extension of cache entries
+const entrySuffix = ".json"
+
+// Entry is a cached model response
+type Entry struct {
+	CreatedAt time.Time `json:"created_at"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Response  string    `json:"response"`
+}
+
+// Store is a directory of cached responses. Entries are written to a temporary file and
+// renamed into place, so concurrent processes never see a partial entry.
+type Store struct {
+	dir string
+	ttl time.Duration
+}
+
+// Stats describes the entries of a store
+type Stats struct {
+	Dir     string
+	Entries int
+	Expired int
+	Bytes   int64
+	Oldest  time.Time
+	Newest  time.Time
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/gcm, or ~/.cache/gcm when XDG_CACHE_HOME is not set
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gcm"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find the cache directory: %w", err)
+	}
+	return filepath.Join(home, ".cache", "gcm"), nil
+}
+
+// Open returns the store configured by cc; a leading "~/" in the directory is the home directory
+func Open(cc config.CacheConfig) (*Store, error) {
+	ttl, err := config.ParseTimeout(cc.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cache.ttl %q", cc.TTL)
+	}
+
+	dir := cc.Dir
+	if strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand %s: %w", dir, err)
+		}
+		dir = filepath.Join(home, dir[2:])
+	}
+	if dir == "" {
+		if dir, err = DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{dir: filepath.Join(dir, "responses"), ttl: ttl}, nil
+}
+
+// Key hashes the parts of a request into a cache key; parts are length-prefixed so that
+// moving text from one part to the next changes the key
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(strconv.Itoa(len(part)) + ":" + part))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// path returns the file of an entry; entries are spread over subdirectories by key prefix
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key+entrySuffix)
+}
+
+// expired reports whether an entry is older than the TTL
+func (s *Store) expired(e Entry, now time.Time) bool {
+	return s.ttl > 0 && now.Sub(e.CreatedAt) > s.ttl
+}
+
+// Get returns the entry of key if it exists and has not expired
+func (s *Store) Get(key string) (Entry, bool) {
+	var e Entry
+	data, err := os.ReadFile(s.path(key))
+	if err != nil || json.Unmarshal(data, &e) != nil {
+		return Entry{}, false
+	}
+	if s.expired(e, time.Now()) {
+		os.Remove(s.path(key))
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Put stores the entry of key
+func (s *Store) Put(key string, e Entry) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// walk calls fn for every entry file of the store
+func (s *Store) walk(fn func(path string, info fs.FileInfo) error) error {
+	err := filepath.Walk(s.dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(path, entrySuffix) {
+			return nil
+		}
+		return fn(path, info)
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Clear removes all entries, or only the expired ones, and returns how many were removed
+func (s *Store) Clear(expiredOnly bool) (int, error) {
+	removed := 0
+	now := time.Now()
+	err := s.walk(func(path string, info fs.FileInfo) error {
+		if expiredOnly {
+			var e Entry
+			data, err := os.ReadFile(path)
+			if err == nil && json.Unmarshal(data, &e) == nil && !s.expired(e, now) {
+				return nil
+			}
+		}
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		removed++
+		return nil
+	})
+	return removed, err
+}
+
+// Stats counts the entries of the store
+func (s *Store) Stats() (Stats, error) {
+	stats := Stats{Dir: s.dir}
+	now := time.Now()
+	err := s.walk(func(path string, info fs.FileInfo) error {
+		var e Entry
+		data, err := os.ReadFile(path)
+		if err != nil || json.Unmarshal(data, &e) != nil {
+			// Another process may have removed it meanwhile
+			return nil
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if s.expired(e, now) {
+			stats.Expired++
+		}
+		if stats.Oldest.IsZero() || e.CreatedAt.Before(stats.Oldest) {
+			stats.Oldest = e.CreatedAt
+		}
+		if e.CreatedAt.After(stats.Newest) {
+			stats.Newest = e.CreatedAt
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,170 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// fakeClient counts the requests that reach the provider
+type fakeClient struct {
+	calls int
+}
+
+func (f *fakeClient) GenerateCommitMessage(diff string, branch string) (string, error) {
+	f.calls++
+	return "feat: " + branch, nil
+}
+
+func (f *fakeClient) Complete(prompt string) (string, error) {
+	f.calls++
+	return "response to " + prompt, nil
+}
+
+// fallbackClient is answered by a fallback provider, like a provider.Chain
+type fallbackClient struct {
+	fakeClient
+}
+
+func (f *fallbackClient) Answered() (string, string, bool) {
+	return "geminicli", "gemini-2.5-pro", true
+}
+
+func TestOpenDefaultsToXDGCacheHome(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	store, err := Open(config.CacheConfig{TTL: "1h"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if want := filepath.Join(dir, "gcm", "responses"); store.dir != want {
+		t.Errorf("dir = %q, want %q", store.dir, want)
+	}
+	if store.ttl != time.Hour {
+		t.Errorf("ttl = %v, want 1h", store.ttl)
+	}
+	if _, err := Open(config.CacheConfig{TTL: "soon"}); err == nil {
+		t.Error("expected an error for an invalid TTL")
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("expected moving text between parts to change the key")
+	}
+	if Key("a", "b") != Key("a", "b") {
+		t.Error("expected the same parts to give the same key")
+	}
+}
+
+func TestStoreExpiresEntries(t *testing.T) {
+	store := &Store{dir: t.TempDir(), ttl: time.Hour}
+	key := Key("prompt")
+
+	if _, ok := store.Get(key); ok {
+		t.Fatal("expected a miss on an empty store")
+	}
+	if err := store.Put(key, Entry{CreatedAt: time.Now(), Response: "fresh"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if e, ok := store.Get(key); !ok || e.Response != "fresh" {
+		t.Errorf("Get = %+v, %v", e, ok)
+	}
+
+	old := Key("old")
+	if err := store.Put(old, Entry{CreatedAt: time.Now().Add(-2 * time.Hour), Response: "stale"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	stats, err := store.Stats()
+	if err != nil || stats.Entries != 2 || stats.Expired != 1 {
+		t.Errorf("Stats = %+v, %v", stats, err)
+	}
+	if removed, err := store.Clear(true); err != nil || removed != 1 {
+		t.Errorf("Clear(expired) = %d, %v", removed, err)
+	}
+	if removed, err := store.Clear(false); err != nil || removed != 1 {
+		t.Errorf("Clear() = %d, %v", removed, err)
+	}
+	if _, ok := store.Get(key); ok {
+		t.Error("expected a miss after clearing")
+	}
+}
+
+func TestStoreConcurrentAccess(t *testing.T) {
+	store := &Store{dir: t.TempDir()}
+	key := Key("shared")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := store.Put(key, Entry{CreatedAt: time.Now(), Response: fmt.Sprintf("response %d", i)}); err != nil {
+				t.Errorf("Put failed: %v", err)
+			}
+			if _, ok := store.Get(key); !ok {
+				t.Error("expected a complete entry")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if stats, err := store.Stats(); err != nil || stats.Entries != 1 {
+		t.Errorf("Stats = %+v, %v; temporary files must not be left behind", stats, err)
+	}
+}
+
+func TestClientReusesResponses(t *testing.T) {
+	inner := &fakeClient{}
+	c := NewClient(inner, &Store{dir: t.TempDir()}, "claude", "model-a", "")
+
+	for i := 0; i < 2; i++ {
+		if got, err := c.GenerateCommitMessage("diff", "main"); err != nil || got != "feat: main" {
+			t.Fatalf("GenerateCommitMessage = %q, %v", got, err)
+		}
+		if got, err := c.Complete("hello"); err != nil || got != "response to hello" {
+			t.Fatalf("Complete = %q, %v", got, err)
+		}
+	}
+	if inner.calls != 2 || c.Hits() != 2 {
+		t.Errorf("calls = %d, hits = %d; want 2 and 2", inner.calls, c.Hits())
+	}
+
+	// Another model must not reuse the responses
+	other := NewClient(inner, c.store, "claude", "model-b", "")
+	if _, err := other.Complete("hello"); err != nil || other.Hits() != 0 {
+		t.Errorf("expected a miss for another model, hits = %d, err = %v", other.Hits(), err)
+	}
+}
+
+func TestClientRecordsAnsweringProvider(t *testing.T) {
+	inner := &fallbackClient{}
+	c := NewClient(inner, &Store{dir: t.TempDir()}, "claude", "model-a", "")
+
+	if _, err := c.Complete("hello"); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	// A copy made by WithContext answers from the cache and counts the hit on the original
+	copied := c.WithContext(context.Background()).(*Client)
+	if _, err := copied.Complete("hello"); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	if inner.calls != 1 || c.Hits() != 1 {
+		t.Errorf("calls = %d, hits = %d; want 1 and 1", inner.calls, c.Hits())
+	}
+	if name, model, ok := copied.Answered(); !ok || name != "geminicli" || model != "gemini-2.5-pro" {
+		t.Errorf("Answered = %s, %s, %v; want the fallback that answered", name, model, ok)
+	}
+
+	// A race must not reuse the response of a single provider
+	race := NewClient(inner, c.store, "claude", "model-a", "race")
+	if _, err := race.Complete("hello"); err != nil || race.Hits() != 0 {
+		t.Errorf("expected a miss for a race, hits = %d, err = %v", race.Hits(), err)
+	}
+}
@@ -0,0 +1,129 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// Client answers requests from the store and passes the others on to the provider's client,
+// storing their responses
+type Client struct {
+	inner    client.AIClient
+	store    *Store
+	provider string
+	model    string
+	// mode tells apart the ways a request is answered, such as racing providers, whose
+	// responses differ for the same prompt
+	mode string
+	// hits is shared with the copies made by WithContext
+	hits *atomic.Int64
+	// hit is the entry that answered the last request, if it came from the cache
+	hit *Entry
+}
+
+// Ensure Client implements the AIClient and Cancelable interfaces
+var (
+	_ client.AIClient   = (*Client)(nil)
+	_ client.Cancelable = (*Client)(nil)
+)
+
+// NewClient wraps the client of a provider and model with the store; mode is part of the key
+// of every request (e.g. "race"), so that responses are only reused by the same mode
+func NewClient(inner client.AIClient, store *Store, provider, model, mode string) *Client {
+	return &Client{inner: inner, store: store, provider: provider, model: model, mode: mode, hits: &atomic.Int64{}}
+}
+
+// Hits returns how many responses were served from the cache
+func (c *Client) Hits() int {
+	return int(c.hits.Load())
+}
+
+// Answered returns the provider and model that answered the last request: the ones stored
+// with a cached response, or those reported by the wrapped client
+func (c *Client) Answered() (name, model string, ok bool) {
+	if c.hit != nil {
+		return c.hit.Provider, c.hit.Model, true
+	}
+	if answerer, ok := c.inner.(interface {
+		Answered() (string, string, bool)
+	}); ok {
+		return answerer.Answered()
+	}
+	return "", "", false
+}
+
+// Unwrap returns the wrapped client
+func (c *Client) Unwrap() client.AIClient {
+	return c.inner
+}
+
+// WithContext returns a copy of the client whose requests to the provider are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.inner = client.WithContext(ctx, c.inner)
+	return &copied
+}
+
+// settings returns the config that shapes the response besides the prompt: the provider
+// block (temperature, max tokens, extra arguments, ...) and, for commit messages, everything
+// the provider's prompt is built from
+func (c *Client) settings(withPrompt bool) string {
+	cfg := config.Get()
+	relevant := map[string]interface{}{"provider": cfg.Provider(c.provider)}
+	if withPrompt {
+		relevant["prompt_templates"] = cfg.PromptTemplates
+		relevant["semantic_release_prefixes"] = cfg.SemanticReleasePrefixes
+		relevant["language"] = cfg.Defaults.Language
+		relevant["examples"] = cfg.Examples
+	}
+	data, _ := json.Marshal(relevant)
+	return string(data)
+}
+
+// cached returns the stored response of key, or calls the provider and stores its response
+func (c *Client) cached(key string, call func() (string, error)) (string, error) {
+	c.hit = nil
+	if e, ok := c.store.Get(key); ok {
+		c.hits.Add(1)
+		c.hit = &e
+		return e.Response, nil
+	}
+
+	response, err := call()
+	if err != nil {
+		return "", err
+	}
+	// A fallback or another entrant of a race may have answered instead of the provider asked
+	provider, model, ok := c.Answered()
+	if !ok {
+		provider, model = c.provider, c.model
+	}
+	// A cache that cannot be written only costs the next call
+	if err := c.store.Put(key, Entry{CreatedAt: time.Now(), Provider: provider, Model: model, Response: response}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return response, nil
+}
+
+// GenerateCommitMessage generates a commit message, reusing the response to an identical request
+func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
+	key := Key("commit", c.provider, c.model, c.mode, c.settings(true), branch, diff)
+	return c.cached(key, func() (string, error) {
+		return c.inner.GenerateCommitMessage(diff, branch)
+	})
+}
+
+// Complete sends a prompt to the model, reusing the response to an identical request
+func (c *Client) Complete(prompt string) (string, error) {
+	key := Key("complete", c.provider, c.model, c.mode, c.settings(false), prompt)
+	return c.cached(key, func() (string, error) {
+		return c.inner.Complete(prompt)
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/cache"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// runCache dispatches the cache subcommands
+func runCache(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: missing cache subcommand (available: stats, clear)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "stats":
+		runCacheStats(args[1:])
+	case "clear":
+		runCacheClear(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown cache subcommand '%s' (available: stats, clear)\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// openCache loads the config and opens the configured response cache
+func openCache(fs *flag.FlagSet, configPath, profile *string, args []string) *cache.Store {
+	fs.Parse(args)
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: *configPath, Profile: *profile}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		os.Exit(1)
+	}
+	store, err := cache.Open(config.Get().Cache)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return store
+}
+
+// runCacheStats prints the number and size of the cached responses
+func runCacheStats(args []string) {
+	statsFlags := flag.NewFlagSet("cache stats", flag.ExitOnError)
+	configPath := statsFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := statsFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	store := openCache(statsFlags, configPath, profile, args)
+
+	stats, err := store.Stats()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading cache: %v\n", err)
+		os.Exit(1)
+	}
+	cc := config.Get().Cache
+	enabled := "enabled"
+	if !cc.Enabled {
+		enabled = "disabled"
+	}
+	ttl := cc.TTL
+	if ttl == "" || ttl == "0" {
+		ttl = "none"
+	}
+
+	fmt.Printf("Directory: %s (%s, TTL %s)\n", stats.Dir, enabled, ttl)
+	fmt.Printf("Entries:   %d (%d expired)\n", stats.Entries, stats.Expired)
+	fmt.Printf("Size:      %.1f KiB\n", float64(stats.Bytes)/1024)
+	if stats.Entries > 0 {
+		fmt.Printf("Oldest:    %s\n", stats.Oldest.Local().Format(time.DateTime))
+		fmt.Printf("Newest:    %s\n", stats.Newest.Local().Format(time.DateTime))
+	}
+}
+
+// runCacheClear removes the cached responses
+func runCacheClear(args []string) {
+	clearFlags := flag.NewFlagSet("cache clear", flag.ExitOnError)
+	configPath := clearFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := clearFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	expired := clearFlags.Bool("expired", false, "Remove only the responses older than the TTL")
+	store := openCache(clearFlags, configPath, profile, args)
+
+	removed, err := store.Clear(*expired)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error clearing cache: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✓ Removed %d cached responses\n", removed)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// runChangelog groups the commits between two tags by type and prints or writes a
+// Keep a Changelog section, optionally rewritten into release notes by the model
+func runChangelog(args []string) {
+	changelogFlags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	mf := addModelFlags(changelogFlags)
+	from := changelogFlags.String("from", "", "Start of the range, exclusive (default: the previous tag)")
+	to := changelogFlags.String("to", "HEAD", "End of the range")
+	version := changelogFlags.String("version", "", "Version of the section (default: the tag at --to, or Unreleased)")
+	rewrite := changelogFlags.Bool("rewrite", false, "Have the model rewrite the entries into user-facing release notes")
+	output := changelogFlags.String("output", "", "Changelog file to create or update (e.g. CHANGELOG.md)")
+	changelogFlags.StringVar(output, "o", "", "Shorthand for --output")
+	prompt := changelogFlags.String("prompt", "", "Additional instructions for --rewrite")
+	changelogFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	changelogFlags.Parse(args)
+
+	cfg := mf.loadConfig()
+
+	// A tag at the end of the range names the section; the range starts at the tag before it
+	tag, err := git.DescribeTag(*to, true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading tags: %v\n", err)
+		os.Exit(1)
+	}
+	if *version == "" {
+		*version = tag
+	}
+	if *version == "" {
+		*version = message.UnreleasedVersion
+	}
+	if *from == "" {
+		ref := *to
+		if tag != "" {
+			ref += "^"
+		}
+		if *from, err = git.DescribeTag(ref, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading tags: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	revRange := *to
+	if *from != "" {
+		revRange = *from + ".." + *to
+	}
+
+	commits, err := git.GetCommits(git.LogOptions{Range: revRange})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(commits) == 0 {
+		fmt.Fprintf(os.Stderr, "No commits in %s\n", revRange)
+		os.Exit(0)
+	}
+	date, err := git.GetCommitDate(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commit date: %v\n", err)
+		os.Exit(1)
+	}
+
+	lang := cfg.PromptLanguage("english")
+	body := message.RenderGroups(message.GroupCommits(commits, cfg.SemanticReleasePrefixes, lang))
+	if *rewrite {
+		aiClient := mf.newClient(cfg)
+		if cfg.Defaults.Verbose {
+			fmt.Fprintf(os.Stderr, "Rewriting %d commits of %s with %s (%s)\n", len(commits), revRange, mf.Provider, mf.Model)
+		}
+		if body, err = message.RewriteReleaseNotes(aiClient, body, lang, *prompt); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		printUsage(cfg)
+	}
+	section := message.ChangelogSection(*version, date, body)
+
+	if *output == "" {
+		fmt.Print(section)
+		return
+	}
+
+	existing, err := os.ReadFile(*output)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	updated := message.UpdateChangelog(string(existing), *version, section)
+	if err := os.WriteFile(*output, []byte(updated), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Updated %s: [%s] with %d commits from %s\n", *output, *version, len(commits), revRange)
+}
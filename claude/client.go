@@ -2,27 +2,45 @@ package claude
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
 )
 
 // Client represents a Claude API client
 type Client struct {
-	apiKey     string
-	model      string
-	httpClient *http.Client
-	baseURL    string
+	apiKey      string
+	model       string
+	httpClient  *http.Client
+	baseURL     string
+	maxTokens   int
+	temperature *float64
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for japanese
+	language string
+	// lastUsage holds the tokens of the last request, if it got a response
+	lastUsage *usage.Tokens
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable, Localizable and usage Reporter interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+	_ usage.Reporter     = (*Client)(nil)
+)
 
 // NewClient creates a new Claude API client
 func NewClient(model string) (*Client, error) {
@@ -31,12 +49,28 @@ func NewClient(model string) (*Client, error) {
 		return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable is not set")
 	}
 
+	// Apply provider settings from config
+	cfg := appconfig.Get()
+	settings := cfg.Provider("claude")
+
+	baseURL := "https://api.anthropic.com"
+	if settings.BaseURL != "" {
+		baseURL = strings.TrimSuffix(settings.BaseURL, "/")
+	}
+
+	maxTokens := 10000
+	if settings.MaxTokens > 0 {
+		maxTokens = settings.MaxTokens
+	}
+
 	return &Client{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: "https://api.anthropic.com",
+		apiKey:      apiKey,
+		model:       model,
+		baseURL:     baseURL,
+		maxTokens:   maxTokens,
+		temperature: settings.Temperature,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: cfg.Timeout(60 * time.Second),
 		},
 	}, nil
 }
@@ -47,11 +81,25 @@ type ClaudeMessage struct {
 	Content string `json:"content"`
 }
 
+// ClaudeCacheControl marks the end of a prompt prefix that the API caches
+type ClaudeCacheControl struct {
+	Type string `json:"type"`
+}
+
+// ClaudeTextBlock represents a text block of the system prompt
+type ClaudeTextBlock struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *ClaudeCacheControl `json:"cache_control,omitempty"`
+}
+
 // ClaudeRequest represents a request to the Claude API
 type ClaudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []ClaudeMessage `json:"messages"`
+	Model       string            `json:"model"`
+	MaxTokens   int               `json:"max_tokens"`
+	Temperature *float64          `json:"temperature,omitempty"`
+	System      []ClaudeTextBlock `json:"system,omitempty"`
+	Messages    []ClaudeMessage   `json:"messages"`
 }
 
 // ClaudeResponseContent represents content in the Claude API response
@@ -62,8 +110,10 @@ type ClaudeResponseContent struct {
 
 // ClaudeUsage represents usage information in the Claude API response
 type ClaudeUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
 }
 
 // ClaudeResponse represents a response from the Claude API
@@ -77,16 +127,55 @@ type ClaudeResponse struct {
 	Usage      ClaudeUsage             `json:"usage"`
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
 	// Get config and build prompt
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPrompt("japanese", branch, diff)
+	instructions, input := cfg.BuildPromptParts(cfg.PromptLanguage(cmp.Or(c.language, "japanese")), branch, diff)
+	if input == "" {
+		return c.Complete(instructions)
+	}
+	return c.complete(instructions, input)
+}
+
+// Complete sends a prompt to the model and returns its response
+func (c *Client) Complete(prompt string) (string, error) {
+	return c.complete("", prompt)
+}
+
+// LastUsage returns the tokens of the last request
+func (c *Client) LastUsage() (usage.Tokens, bool) {
+	if c.lastUsage == nil {
+		return usage.Tokens{}, false
+	}
+	return *c.lastUsage, true
+}
+
+// complete sends a prompt to the model. The instructions, if any, go in the system prompt
+// marked for prompt caching, so that repeated calls only pay for the prompt.
+func (c *Client) complete(instructions, prompt string) (string, error) {
+	c.lastUsage = nil
 
 	// Create the request
 	request := ClaudeRequest{
-		Model:     c.model,
-		MaxTokens: 10000,
+		Model:       c.model,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
 		Messages: []ClaudeMessage{
 			{
 				Role:    "user",
@@ -94,6 +183,9 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 			},
 		},
 	}
+	if instructions != "" {
+		request.System = []ClaudeTextBlock{{Type: "text", Text: instructions, CacheControl: &ClaudeCacheControl{Type: "ephemeral"}}}
+	}
 
 	// Marshal the request to JSON
 	requestBytes, err := json.Marshal(request)
@@ -102,7 +194,7 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	}
 
 	// Create HTTP request
-	req, err := http.NewRequest("POST", c.baseURL+"/v1/messages", bytes.NewBuffer(requestBytes))
+	req, err := http.NewRequestWithContext(client.OrBackground(c.ctx), "POST", c.baseURL+"/v1/messages", bytes.NewBuffer(requestBytes))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -113,9 +205,13 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	req.Header.Set("anthropic-version", "2023-06-01")
 
 	// Send the request
+	// Network errors and timeouts may go away on a retry, a cancelled request does not
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		if errors.Is(err, context.Canceled) {
+			return "", client.FatalError("claude", fmt.Errorf("failed to send request: %w", err))
+		}
+		return "", client.RetryableError("claude", fmt.Errorf("failed to send request: %w", err), 0)
 	}
 	defer resp.Body.Close()
 
@@ -125,9 +221,13 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 		return "", fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// Check for HTTP errors
+	// Check for HTTP errors; rate limits (429), overload (529) and server errors are retryable
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(responseBody))
+		err := fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(responseBody))
+		if client.IsRetryableStatus(resp.StatusCode) {
+			return "", client.RetryableError("claude", err, client.ParseRetryAfter(resp.Header.Get("retry-after"), time.Now()))
+		}
+		return "", client.FatalError("claude", err)
 	}
 
 	// Parse the response
@@ -135,11 +235,17 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	if err := json.Unmarshal(responseBody, &response); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	c.lastUsage = &usage.Tokens{
+		Input:         response.Usage.InputTokens,
+		Output:        response.Usage.OutputTokens,
+		CacheCreation: response.Usage.CacheCreationInputTokens,
+		CacheRead:     response.Usage.CacheReadInputTokens,
+	}
 
-	// Extract the commit message
+	// Extract the response text
 	if len(response.Content) > 0 && len(response.Content[0].Text) > 0 {
 		return response.Content[0].Text, nil
 	}
 
 	return "", fmt.Errorf("no content in response")
-}
\ No newline at end of file
+}
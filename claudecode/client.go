@@ -2,21 +2,61 @@ package claudecode
 
 import (
 	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
 )
 
 // Client represents a Claude Code client
 type Client struct {
-	model string
+	model     string
+	extraArgs []string
+	timeout   time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for english
+	language string
+	// lastUsage holds the tokens of the last request, if claude reported them
+	lastUsage *usage.Tokens
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable, Localizable and usage Reporter interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+	_ usage.Reporter     = (*Client)(nil)
+)
+
+// result is the output of claude -p --output-format json
+type result struct {
+	Type    string `json:"type"`
+	IsError bool   `json:"is_error"`
+	Result  string `json:"result"`
+	Usage   struct {
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+	} `json:"usage"`
+}
+
+// parseResult reads the JSON output of claude; ok is false when the output is plain text,
+// e.g. because extra_args chose another output format
+func parseResult(output string) (r result, ok bool) {
+	if err := json.Unmarshal([]byte(output), &r); err != nil || r.Type != "result" {
+		return result{}, false
+	}
+	return r, true
+}
 
 // NewClient creates a new Claude Code client
 func NewClient(model string) (*Client, error) {
@@ -30,47 +70,42 @@ func NewClient(model string) (*Client, error) {
 		model = "claude-sonnet-4.5"
 	}
 
+	// Apply provider settings from config
+	cfg := appconfig.Get()
+
 	return &Client{
-		model: model,
+		model:     model,
+		extraArgs: cfg.Provider("claudecode").ExtraArgs,
+		timeout:   cfg.Timeout(0),
 	}, nil
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
-	// Get config and build prompt (English version)
+	// Get config and build prompt (English unless another language is configured)
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPromptEnglish(branch, diff)
-
-	// Execute claude command with -p flag for prompt only output
-	cmd := exec.Command("claude", "-p", prompt)
+	prompt := cfg.BuildPrompt(cfg.PromptLanguage(cmp.Or(c.language, "english")), branch, diff)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	response, err := c.Complete(prompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute claude command: %w\nstderr: %s", err, stderr.String())
+		return "", err
 	}
 
-	response := strings.TrimSpace(stdout.String())
-	if response == "" {
-		return "", fmt.Errorf("empty response from claude command")
-	}
-
-	// Remove usage statistics from the end first
-	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
-		response = response[:idx]
-	}
-	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
-		response = response[:idx]
-	}
-
-	// Remove leading bullet point (●) that Claude Code might add
-	response = strings.TrimPrefix(response, "●")
-	response = strings.TrimPrefix(response, "● ")
-	response = strings.TrimSpace(response)
-
 	// Get list of Semantic Release prefixes from config
 	prefixes := cfg.GetPrefixList()
 
@@ -101,3 +136,69 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	// Fallback: return the whole response (without usage stats)
 	return response, nil
 }
+
+// LastUsage returns the tokens of the last request
+func (c *Client) LastUsage() (usage.Tokens, bool) {
+	if c.lastUsage == nil {
+		return usage.Tokens{}, false
+	}
+	return *c.lastUsage, true
+}
+
+// Complete sends a prompt to the model and returns its cleaned-up response
+func (c *Client) Complete(prompt string) (string, error) {
+	c.lastUsage = nil
+
+	// Bound the command by the configured timeout if any
+	ctx := client.OrBackground(c.ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Execute claude command with -p flag for prompt only output, as JSON to learn the tokens used
+	args := append([]string{"-p", prompt, "--output-format", "json"}, c.extraArgs...)
+	cmd := exec.CommandContext(ctx, "claude", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", client.CommandError(ctx, "claudecode", "claude", err, stderr.String())
+	}
+
+	response := strings.TrimSpace(stdout.String())
+	if r, ok := parseResult(response); ok {
+		c.lastUsage = &usage.Tokens{
+			Input:         r.Usage.InputTokens,
+			Output:        r.Usage.OutputTokens,
+			CacheCreation: r.Usage.CacheCreationInputTokens,
+			CacheRead:     r.Usage.CacheReadInputTokens,
+		}
+		if r.IsError {
+			return "", client.ClassifyMessage("claudecode", fmt.Errorf("claude command failed: %s", r.Result), "")
+		}
+		response = strings.TrimSpace(r.Result)
+	}
+	if response == "" {
+		return "", client.RetryableError("claudecode", fmt.Errorf("empty response from claude command"), 0)
+	}
+
+	// Remove usage statistics from the end first
+	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+
+	// Remove leading bullet point (●) that Claude Code might add
+	response = strings.TrimPrefix(response, "●")
+	response = strings.TrimPrefix(response, "● ")
+	response = strings.TrimSpace(response)
+
+	return response, nil
+}
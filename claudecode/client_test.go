@@ -0,0 +1,14 @@
+package claudecode
+
+import "testing"
+
+func TestParseResult(t *testing.T) {
+	output := `{"type":"result","subtype":"success","is_error":false,"result":"feat: add login","usage":{"input_tokens":12,"output_tokens":5,"cache_read_input_tokens":300}}`
+	r, ok := parseResult(output)
+	if !ok || r.Result != "feat: add login" || r.Usage.InputTokens != 12 || r.Usage.OutputTokens != 5 || r.Usage.CacheReadInputTokens != 300 {
+		t.Errorf("unexpected result %+v, %v", r, ok)
+	}
+	if _, ok := parseResult("feat: add login\n\nTotal usage: 12 tokens"); ok {
+		t.Error("expected plain text output not to parse")
+	}
+}
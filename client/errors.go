@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transientPattern matches error output of provider CLIs and SDKs that suggests a retry may succeed.
+// Bare numbers and quotas are not enough: a status must be reported as one (see retryableStatuses)
+// and a quota must be a rate limit, as an exhausted daily or billing quota does not come back on
+// a retry.
+var transientPattern = regexp.MustCompile(`(?i)\b(429|rate.?limit(ed)?|too many requests|per (minute|second)|overloaded|capacity|temporarily|unavailable|bad gateway|timed? ?out|timeout|connection (reset|refused)|try again)\b`)
+
+// statusPattern matches an HTTP status reported in error output, such as "status 503" or "code: 529"
+var statusPattern = regexp.MustCompile(`(?i)\b(?:status|code)(?: code)?["']?\s*[:=]?\s*(\d{3})\b`)
+
+// Error is a failed request to a provider, classified for retries and fallback
+type Error struct {
+	Provider string
+	// Retryable reports whether the same request may succeed later (rate limits, overload, timeouts)
+	Retryable bool
+	// RetryAfter is how long the provider asked to wait before retrying, if it said so
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// RetryableError marks err as a failure that may go away when the request is retried
+func RetryableError(provider string, err error, retryAfter time.Duration) error {
+	return &Error{Provider: provider, Retryable: true, RetryAfter: retryAfter, Err: err}
+}
+
+// FatalError marks err as a failure that retrying the same provider will not fix
+func FatalError(provider string, err error) error {
+	return &Error{Provider: provider, Err: err}
+}
+
+// IsRetryable reports whether err was classified as retryable; unclassified errors are fatal
+func IsRetryable(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Retryable
+}
+
+// RetryAfter returns the delay the provider asked for, or 0
+func RetryAfter(err error) time.Duration {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.RetryAfter
+	}
+	return 0
+}
+
+// IsRetryableStatus reports whether an HTTP status means the request may succeed later:
+// timeouts, rate limits, transient server errors and Anthropic's 529 (overloaded). Server errors
+// such as 501 (not implemented) fail the same way on every retry.
+func IsRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout, 529:
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter reads a retry-after header given in seconds or as an HTTP date
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// ClassifyMessage classifies err by its message (and any error output), for providers that
+// report failures only as text
+func ClassifyMessage(provider string, err error, output string) error {
+	text := err.Error() + "\n" + output
+	if errors.Is(err, context.DeadlineExceeded) || transientPattern.MatchString(text) || hasRetryableStatus(text) {
+		return RetryableError(provider, err, 0)
+	}
+	return FatalError(provider, err)
+}
+
+// hasRetryableStatus reports whether text reports a retryable HTTP status (see IsRetryableStatus)
+func hasRetryableStatus(text string) bool {
+	for _, match := range statusPattern.FindAllStringSubmatch(text, -1) {
+		if status, err := strconv.Atoi(match[1]); err == nil && IsRetryableStatus(status) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommandError wraps the failure of a provider CLI run with ctx. Timeouts and error output
+// that looks transient (rate limits, overload, network errors) are retryable.
+func CommandError(ctx context.Context, provider, command string, err error, stderr string) error {
+	wrapped := fmt.Errorf("failed to execute %s command: %w\nstderr: %s", command, err, stderr)
+	if ctx.Err() != nil {
+		wrapped = fmt.Errorf("failed to execute %s command: %w", command, ctx.Err())
+	}
+	return ClassifyMessage(provider, wrapped, stderr)
+}
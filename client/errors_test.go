@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestIsRetryableStatus(t *testing.T) {
+	for status, want := range map[int]bool{400: false, 401: false, 404: false, 408: true, 429: true, 500: true, 501: false, 502: true, 503: true, 504: true, 505: false, 529: true} {
+		if got := IsRetryableStatus(status); got != want {
+			t.Errorf("IsRetryableStatus(%d) = %v, want %v", status, got, want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := map[string]time.Duration{
+		"":                              0,
+		"12":                            12 * time.Second,
+		"0.5":                           500 * time.Millisecond,
+		"soon":                          0,
+		"Fri, 02 Jan 2026 03:04:35 GMT": 30 * time.Second,
+		"Fri, 02 Jan 2026 03:00:00 GMT": 0,
+	}
+	for value, want := range tests {
+		if got := ParseRetryAfter(value, now); got != want {
+			t.Errorf("ParseRetryAfter(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestClassification(t *testing.T) {
+	err := fmt.Errorf("generating: %w", RetryableError("claude", errors.New("overloaded"), 3*time.Second))
+	if !IsRetryable(err) || RetryAfter(err) != 3*time.Second {
+		t.Errorf("expected a wrapped retryable error with its delay, got %v %v", IsRetryable(err), RetryAfter(err))
+	}
+	if IsRetryable(FatalError("claude", errors.New("invalid x-api-key"))) {
+		t.Error("expected a fatal error not to be retryable")
+	}
+	if IsRetryable(errors.New("unclassified")) {
+		t.Error("expected an unclassified error not to be retryable")
+	}
+}
+
+func TestCommandError(t *testing.T) {
+	ctx := context.Background()
+	if err := CommandError(ctx, "geminicli", "gemini", errors.New("exit status 1"), "Error: 429 Too Many Requests"); !IsRetryable(err) {
+		t.Errorf("expected a rate limit to be retryable: %v", err)
+	}
+	if err := CommandError(ctx, "geminicli", "gemini", errors.New("exit status 1"), "Error: unknown flag --modle"); IsRetryable(err) {
+		t.Errorf("expected a usage error to be fatal: %v", err)
+	}
+
+	transient := []string{
+		"API request failed with status 503",
+		`{"error": {"code": 529, "message": "busy"}}`,
+		"Quota exceeded for quota metric 'Generate Content API requests per minute'",
+	}
+	for _, stderr := range transient {
+		if err := CommandError(ctx, "geminicli", "gemini", errors.New("exit status 1"), stderr); !IsRetryable(err) {
+			t.Errorf("expected %q to be retryable: %v", stderr, err)
+		}
+	}
+	fatal := []string{
+		"Error: read 512 bytes from config.json",
+		"You exceeded your current quota, please check your plan and billing details",
+		"API request failed with status 501",
+	}
+	for _, stderr := range fatal {
+		if err := CommandError(ctx, "geminicli", "gemini", errors.New("exit status 1"), stderr); IsRetryable(err) {
+			t.Errorf("expected %q to be fatal: %v", stderr, err)
+		}
+	}
+
+	timedOut, cancel := context.WithTimeout(ctx, 0)
+	defer cancel()
+	<-timedOut.Done()
+	err := CommandError(timedOut, "codexcli", "codex", errors.New("signal: killed"), "")
+	if !IsRetryable(err) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected a timeout to be retryable: %v", err)
+	}
+}
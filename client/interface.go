@@ -1,7 +1,50 @@
 package client
 
+import "context"
+
 // AIClient represents an interface for AI clients that can generate commit messages
 type AIClient interface {
 	// GenerateCommitMessage generates a commit message based on the provided diff and branch
 	GenerateCommitMessage(diff string, branch string) (string, error)
-}
\ No newline at end of file
+	// Complete sends a prompt as is and returns the model's response
+	Complete(prompt string) (string, error)
+}
+
+// Cancelable is implemented by clients whose requests can be cancelled, e.g. when another
+// provider answered first
+type Cancelable interface {
+	// WithContext returns a copy of the client whose requests are cancelled with ctx
+	WithContext(ctx context.Context) AIClient
+}
+
+// Localizable is implemented by clients that build the commit message prompt themselves, so
+// that every provider of a chain can write in the same language
+type Localizable interface {
+	// WithLanguage returns a copy of the client whose prompts are written in lang unless
+	// defaults.language is set
+	WithLanguage(lang string) AIClient
+}
+
+// WithContext returns aiClient bound to ctx, or aiClient itself if it cannot be cancelled
+func WithContext(ctx context.Context, aiClient AIClient) AIClient {
+	if cancelable, ok := aiClient.(Cancelable); ok {
+		return cancelable.WithContext(ctx)
+	}
+	return aiClient
+}
+
+// OrBackground returns ctx, or the background context if ctx is nil
+func OrBackground(ctx context.Context) context.Context {
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// WithLanguage returns aiClient writing in lang, or aiClient itself if it does not build prompts
+func WithLanguage(lang string, aiClient AIClient) AIClient {
+	if localizable, ok := aiClient.(Localizable); ok {
+		return localizable.WithLanguage(lang)
+	}
+	return aiClient
+}
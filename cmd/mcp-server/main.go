@@ -9,9 +9,11 @@ import (
 )
 
 func main() {
-	provider := flag.String("provider", "", "Default AI provider (bedrock, claude, geminicli, copilotcli, claudecode)")
+	provider := flag.String("provider", "", "Default AI provider (bedrock, claude, geminicli, copilotcli, copilotsdk, claudecode, codexcli)")
 	modelID := flag.String("model", "", "Default model ID")
-	region := flag.String("region", "us-east-1", "AWS region (for bedrock provider)")
+	region := flag.String("region", "", "AWS region (for bedrock provider)")
+	configPath := flag.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := flag.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
 	help := flag.Bool("help", false, "Show help")
 	flag.Parse()
 
@@ -20,7 +22,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	server, err := mcp.NewServer(*provider, *modelID, *region)
+	server, err := mcp.NewServer(*provider, *modelID, *region, *configPath, *profile)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating MCP server: %v\n", err)
 		os.Exit(1)
@@ -40,11 +42,16 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("Options:")
 	fmt.Println("  -provider string")
-	fmt.Println("        Default AI provider (bedrock, claude, geminicli, copilotcli, claudecode)")
+	fmt.Println("        Default AI provider (bedrock, claude, geminicli, copilotcli, copilotsdk, claudecode, codexcli)")
+	fmt.Println("        Falls back to defaults.provider in the config, then auto-detection")
 	fmt.Println("  -model string")
-	fmt.Println("        Default model ID")
+	fmt.Println("        Default model ID (falls back to the config, then the provider default)")
 	fmt.Println("  -region string")
-	fmt.Println("        AWS region (for bedrock provider) (default \"us-east-1\")")
+	fmt.Println("        AWS region (for bedrock provider) (falls back to defaults.region, then \"us-east-1\")")
+	fmt.Println("  -config string")
+	fmt.Println("        Path to config file (merged over discovered config files)")
+	fmt.Println("  -profile string")
+	fmt.Println("        Config profile to apply (auto-selected by remote URL if not specified)")
 	fmt.Println("  -help")
 	fmt.Println("        Show help")
 	fmt.Println()
@@ -2,9 +2,12 @@ package codexcli
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
@@ -12,11 +15,21 @@ import (
 
 // Client represents an OpenAI Codex CLI client
 type Client struct {
-	model string
+	model     string
+	extraArgs []string
+	timeout   time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for english
+	language string
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable and Localizable interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+)
 
 // NewClient creates a new Codex CLI client
 func NewClient(model string) (*Client, error) {
@@ -30,42 +43,40 @@ func NewClient(model string) (*Client, error) {
 		model = "o4-mini"
 	}
 
+	// Apply provider settings from config
+	cfg := appconfig.Get()
+
 	return &Client{
-		model: model,
+		model:     model,
+		extraArgs: cfg.Provider("codexcli").ExtraArgs,
+		timeout:   cfg.Timeout(0),
 	}, nil
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
-	// Get config and build prompt (English version)
+	// Get config and build prompt (English unless another language is configured)
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPromptEnglish(branch, diff)
-
-	// Execute codex exec with stdin piping to handle large prompts
-	// codex exec - reads the prompt from stdin in non-interactive mode
-	cmd := exec.Command("codex", "exec", "--model", c.model, "-")
-	cmd.Stdin = strings.NewReader(prompt)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	prompt := cfg.BuildPrompt(cfg.PromptLanguage(cmp.Or(c.language, "english")), branch, diff)
 
-	err := cmd.Run()
+	response, err := c.Complete(prompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute codex command: %w\nstderr: %s", err, stderr.String())
-	}
-
-	response := strings.TrimSpace(stdout.String())
-	if response == "" {
-		return "", fmt.Errorf("empty response from codex command")
-	}
-
-	// Remove usage statistics from the end first
-	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
-		response = response[:idx]
-	}
-	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
-		response = response[:idx]
+		return "", err
 	}
 
 	// Get list of Semantic Release prefixes from config
@@ -98,3 +109,45 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	// Fallback: return the whole response (without usage stats)
 	return response, nil
 }
+
+// Complete sends a prompt to the model and returns its cleaned-up response
+func (c *Client) Complete(prompt string) (string, error) {
+	// Bound the command by the configured timeout if any
+	ctx := client.OrBackground(c.ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Execute codex exec with stdin piping to handle large prompts
+	// codex exec - reads the prompt from stdin in non-interactive mode
+	args := append([]string{"exec", "--model", c.model}, c.extraArgs...)
+	args = append(args, "-")
+	cmd := exec.CommandContext(ctx, "codex", args...)
+	cmd.Stdin = strings.NewReader(prompt)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", client.CommandError(ctx, "codexcli", "codex", err, stderr.String())
+	}
+
+	response := strings.TrimSpace(stdout.String())
+	if response == "" {
+		return "", client.RetryableError("codexcli", fmt.Errorf("empty response from codex command"), 0)
+	}
+
+	// Remove usage statistics from the end first
+	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+
+	return response, nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed presets/*.yaml
+var presetFS embed.FS
+
+// Presets lists the conventions that `init --preset` can scaffold a config for
+var Presets = []string{"conventional", "gitmoji", "angular", "plain", "jira"}
+
+// InitOptions describes the config file generated by `init`
+type InitOptions struct {
+	// Preset is one of Presets; empty means the full embedded default config
+	Preset string
+	// Language sets defaults.language (e.g. japanese, english)
+	Language string
+	// Provider sets defaults.provider
+	Provider string
+	// TicketPattern is a regular expression matching ticket IDs in branch names
+	TicketPattern string
+}
+
+// PresetData returns the raw YAML of a preset
+func PresetData(name string) ([]byte, error) {
+	for _, preset := range Presets {
+		if preset == name {
+			return presetFS.ReadFile("presets/" + name + ".yaml")
+		}
+	}
+	return nil, fmt.Errorf("unknown preset %q (available: %v)", name, Presets)
+}
+
+// RenderInitConfig renders the config file for the given init options.
+// Comments of the preset are kept; the answers of the questionnaire are added on top.
+func RenderInitConfig(opts InitOptions) ([]byte, error) {
+	data := defaultConfigData
+	if opts.Preset != "" {
+		var err error
+		if data, err = PresetData(opts.Preset); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Language == "" && opts.Provider == "" && opts.TicketPattern == "" {
+		return data, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse preset: %w", err)
+	}
+	root := doc.Content[0]
+
+	if opts.Provider != "" {
+		setMappingValue(ensureMapping(root, "defaults"), "provider", opts.Provider)
+	}
+	if opts.Language != "" {
+		setMappingValue(ensureMapping(root, "defaults"), "language", opts.Language)
+	}
+	if opts.TicketPattern != "" {
+		if _, err := regexp.Compile(opts.TicketPattern); err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern: %w", err)
+		}
+		pattern := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		setMappingValue(pattern, "name", "custom")
+		setMappingValue(pattern, "regex", opts.TicketPattern)
+		setMapping(ensureMapping(root, "ticket"), "patterns", &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{pattern}})
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+
+	if errs := ValidateData(buf.Bytes()); len(errs) > 0 {
+		return nil, fmt.Errorf("generated config is invalid: %v", errs[0])
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteInitConfig renders the config for opts and writes it to path
+func WriteInitConfig(path string, force bool, opts InitOptions) error {
+	// Check if file exists
+	if _, err := os.Stat(path); err == nil {
+		if !force {
+			return fmt.Errorf("file already exists: %s (use --force to overwrite)", path)
+		}
+	}
+
+	data, err := RenderInitConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	return nil
+}
+
+// ensureMapping returns the mapping stored under key, adding an empty one if it does not exist
+func ensureMapping(node *yaml.Node, key string) *yaml.Node {
+	if value := mappingValue(node, key); value != nil && value.Kind == yaml.MappingNode {
+		return value
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setMapping(node, key, value)
+	return value
+}
+
+// setMappingValue sets a string value under key in a mapping node
+func setMappingValue(node *yaml.Node, key, value string) {
+	setMapping(node, key, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value})
+}
+
+// setMapping replaces or appends the value stored under key in a mapping node
+func setMapping(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
@@ -0,0 +1,416 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile is the name of the config file discovered at the repository root
+const RepoConfigFile = ".gcm.yaml"
+
+// gitConfigKeys maps git config keys to the config paths they override
+var gitConfigKeys = []struct {
+	Key  string
+	Path string
+}{
+	{Key: "gcm.provider", Path: "defaults.provider"},
+	{Key: "gcm.model", Path: "defaults.model"},
+}
+
+// LoadOptions controls how the layered configuration is assembled
+type LoadOptions struct {
+	// ConfigPath is an explicit config file given with --config
+	ConfigPath string
+	// Overrides maps dotted config paths (e.g. "defaults.provider") to values set by command line flags
+	Overrides map[string]interface{}
+	// OverrideOrigins maps dotted config paths to the flag that set them, used for origin reporting
+	OverrideOrigins map[string]string
+	// Profile is the profile requested with --profile; when empty it is taken from
+	// git config gcm.profile or matched against the repository's remote URL
+	Profile string
+}
+
+// Layer is a single configuration source taking part in the merge
+type Layer struct {
+	Name string
+	Path string
+	Data map[string]interface{}
+}
+
+// Origin returns a human readable description of where the layer comes from
+func (l Layer) Origin() string {
+	if l.Path == "" {
+		return l.Name
+	}
+	return fmt.Sprintf("%s (%s)", l.Name, l.Path)
+}
+
+// Origins maps a dotted config path to the origin of its effective value
+type Origins map[string]string
+
+// LoadLayered discovers all configuration layers and deep-merges them.
+// Layers are applied in the following order, later layers winning:
+//  1. embedded default config
+//  2. system config (/etc/gcm/config.yaml)
+//  3. user config ($XDG_CONFIG_HOME/gcm/config.yaml)
+//  4. repository config (.gcm.yaml at the repository root)
+//  5. explicit config file (--config)
+//  6. selected profile (--profile, gcm.profile, or matched by remote URL)
+//  7. git config keys (gcm.provider, gcm.model)
+//  8. command line flags
+func LoadLayered(opts LoadOptions) (*Config, Origins, error) {
+	layers, err := fileLayers(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	merged := map[string]interface{}{}
+	origins := Origins{}
+	var applied []string
+	for _, layer := range layers {
+		mergeInto(merged, layer.Data, "", layer.Origin(), origins)
+		applied = append(applied, layer.Origin())
+	}
+
+	// Profiles are defined by the files, so they are selected after the files are merged
+	profileName, err := selectProfile(merged, opts.Profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if profileName != "" {
+		layer := profileLayer(merged, profileName)
+		mergeInto(merged, layer.Data, "", layer.Origin(), origins)
+		applied = append(applied, layer.Origin())
+	}
+
+	for _, layer := range overrideLayers(opts) {
+		mergeInto(merged, layer.Data, "", layer.Origin(), origins)
+		applied = append(applied, layer.Origin())
+	}
+
+	// Round-trip the merged tree through YAML to decode it into the typed config
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal merged config: %w", err)
+	}
+
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal merged config: %w", err)
+	}
+	config.ActiveProfile = profileName
+	config.origins, config.layers = origins, applied
+
+	// Reject configs that would silently produce broken prompts
+	if errs := config.Validate(); len(errs) > 0 {
+		return nil, nil, fmt.Errorf("invalid config: %w (run 'config validate' for details)", errs[0])
+	}
+
+	return &config, origins, nil
+}
+
+// fileLayers returns the config file layers that apply to the current directory
+func fileLayers(opts LoadOptions) ([]Layer, error) {
+	defaultData, err := parseLayerData(defaultConfigData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse embedded config: %w", err)
+	}
+	layers := []Layer{{Name: "default", Data: defaultData}}
+
+	// Optional files are skipped silently when they do not exist
+	for _, candidate := range fileCandidates() {
+		data, err := os.ReadFile(candidate.Path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s config file: %w", candidate.Name, err)
+		}
+		candidate.Data, err = parseConfigFile(candidate.Path, data)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, candidate)
+	}
+
+	// An explicitly requested file must exist
+	if opts.ConfigPath != "" {
+		data, err := os.ReadFile(opts.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		fileData, err := parseConfigFile(opts.ConfigPath, data)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, Layer{Name: "file", Path: opts.ConfigPath, Data: fileData})
+	}
+
+	return layers, nil
+}
+
+// fileCandidates returns the optional config files in discovery order
+func fileCandidates() []Layer {
+	var candidates []Layer
+	if path := systemConfigPath(); path != "" {
+		candidates = append(candidates, Layer{Name: "system", Path: path})
+	}
+	if path := userConfigPath(); path != "" {
+		candidates = append(candidates, Layer{Name: "user", Path: path})
+	}
+	if root, err := git.GetRepoRoot(); err == nil && root != "" {
+		candidates = append(candidates, Layer{Name: "repo", Path: filepath.Join(root, RepoConfigFile)})
+	}
+	return candidates
+}
+
+// DiscoveredFiles returns the config files that exist and would be loaded, in discovery order
+func DiscoveredFiles(configPath string) []string {
+	var files []string
+	for _, candidate := range fileCandidates() {
+		if _, err := os.Stat(candidate.Path); err == nil {
+			files = append(files, candidate.Path)
+		}
+	}
+	if configPath != "" {
+		files = append(files, configPath)
+	}
+	return files
+}
+
+// HasRepoConfig reports whether the repository has its own config file or an explicit one is given
+func HasRepoConfig(configPath string) bool {
+	if configPath != "" {
+		return true
+	}
+	for _, candidate := range fileCandidates() {
+		if candidate.Name != "repo" {
+			continue
+		}
+		if _, err := os.Stat(candidate.Path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// overrideLayers returns the layers set from git config keys and command line flags
+func overrideLayers(opts LoadOptions) []Layer {
+	var layers []Layer
+
+	// Git config keys, e.g. `git config gcm.provider claude`
+	for _, key := range gitConfigKeys {
+		value, err := git.GetConfigValue(key.Key)
+		if err != nil || value == "" {
+			continue
+		}
+		data := map[string]interface{}{}
+		setPath(data, key.Path, value)
+		layers = append(layers, Layer{Name: "git config", Path: key.Key, Data: data})
+	}
+
+	// Command line flags always win
+	paths := make([]string, 0, len(opts.Overrides))
+	for path := range opts.Overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data := map[string]interface{}{}
+		setPath(data, path, opts.Overrides[path])
+		layers = append(layers, Layer{Name: "flag", Path: opts.OverrideOrigins[path], Data: data})
+	}
+
+	return layers
+}
+
+// parseConfigFile strictly validates a config file's keys and decodes it into a generic map
+func parseConfigFile(path string, data []byte) (map[string]interface{}, error) {
+	if err := decodeStrict(data, &Config{}); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	result, err := parseLayerData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return result, nil
+}
+
+// parseLayerData decodes a YAML document into a generic map
+func parseLayerData(data []byte) (map[string]interface{}, error) {
+	result := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// systemConfigPath returns the path of the system-wide config file
+func systemConfigPath() string {
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("ProgramData"); dir != "" {
+			return filepath.Join(dir, "gcm", "config.yaml")
+		}
+		return ""
+	}
+	return "/etc/gcm/config.yaml"
+}
+
+// userConfigPath returns the path of the per-user config file
+func userConfigPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gcm", "config.yaml")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcm", "config.yaml")
+}
+
+// StatePath returns a local state file such as a log: path if configured (a leading "~/" is
+// the home directory), otherwise name in $XDG_STATE_HOME/gcm or ~/.local/state/gcm
+func StatePath(path, name string) (string, error) {
+	if path != "" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	if path == "" && os.Getenv("XDG_STATE_HOME") != "" {
+		return filepath.Join(os.Getenv("XDG_STATE_HOME"), "gcm", name), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return filepath.Join(home, ".local", "state", "gcm", name), nil
+	}
+	return filepath.Join(home, path[2:]), nil
+}
+
+// mergeInto deep-merges src into dst, recording the origin of every value it sets.
+// Maps are merged recursively; scalars and lists replace the existing value.
+func mergeInto(dst, src map[string]interface{}, prefix, origin string, origins Origins) {
+	for key, value := range src {
+		path := joinPath(prefix, key)
+		if srcMap, ok := value.(map[string]interface{}); ok {
+			dstMap, ok := dst[key].(map[string]interface{})
+			if !ok {
+				origins.clear(path)
+				dstMap = map[string]interface{}{}
+				dst[key] = dstMap
+			}
+			mergeInto(dstMap, srcMap, path, origin, origins)
+			continue
+		}
+		origins.clear(path)
+		dst[key] = value
+		origins[path] = origin
+	}
+}
+
+// clear removes the origin of path and of everything below it
+func (o Origins) clear(path string) {
+	delete(o, path)
+	for key := range o {
+		if strings.HasPrefix(key, path+".") {
+			delete(o, key)
+		}
+	}
+}
+
+// setPath sets a value at a dotted path, creating intermediate maps as needed
+func setPath(m map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			m[part] = next
+		}
+		m = next
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// joinPath joins a dotted path prefix and a key
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// OriginEntry describes an effective config value and the layer it came from
+type OriginEntry struct {
+	Path   string
+	Value  string
+	Origin string
+}
+
+// DescribeOrigins lists every effective value of cfg together with its origin, sorted by path
+func DescribeOrigins(cfg *Config, origins Origins) ([]OriginEntry, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	tree, err := parseLayerData(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	paths := make([]string, 0, len(origins))
+	for path := range origins {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	entries := make([]OriginEntry, 0, len(paths))
+	for _, path := range paths {
+		value, ok := lookupPath(tree, path)
+		if !ok {
+			// Keys unknown to Config are dropped when decoding
+			continue
+		}
+		entries = append(entries, OriginEntry{Path: path, Value: summarizeValue(value), Origin: origins[path]})
+	}
+	return entries, nil
+}
+
+// lookupPath returns the value stored at a dotted path
+func lookupPath(m map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var current interface{} = m
+	for _, part := range parts {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = currentMap[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// summarizeValue renders a value on a single line for origin listings
+func summarizeValue(value interface{}) string {
+	switch v := value.(type) {
+	case []interface{}:
+		return fmt.Sprintf("[%d items]", len(v))
+	case string:
+		if idx := strings.Index(v, "\n"); idx >= 0 {
+			return fmt.Sprintf("%q...", v[:idx])
+		}
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMergeIntoRecordsOrigins(t *testing.T) {
+	dst := map[string]interface{}{}
+	origins := Origins{}
+
+	mergeInto(dst, map[string]interface{}{
+		"defaults": map[string]interface{}{"provider": "bedrock", "model": "a"},
+		"list":     []interface{}{"x", "y"},
+	}, "", "default", origins)
+	mergeInto(dst, map[string]interface{}{
+		"defaults": map[string]interface{}{"provider": "claude"},
+		"list":     []interface{}{"z"},
+	}, "", "user", origins)
+
+	defaults := dst["defaults"].(map[string]interface{})
+	if defaults["provider"] != "claude" {
+		t.Errorf("Expected provider to be overridden, got %v", defaults["provider"])
+	}
+	if defaults["model"] != "a" {
+		t.Errorf("Expected model to be kept from the lower layer, got %v", defaults["model"])
+	}
+	if list := dst["list"].([]interface{}); len(list) != 1 {
+		t.Errorf("Expected lists to be replaced, got %v", list)
+	}
+
+	expected := map[string]string{
+		"defaults.provider": "user",
+		"defaults.model":    "default",
+		"list":              "user",
+	}
+	for path, origin := range expected {
+		if origins[path] != origin {
+			t.Errorf("Expected origin of %s to be %s, got %s", path, origin, origins[path])
+		}
+	}
+}
+
+func TestMergeIntoReplacesSubtreeOrigins(t *testing.T) {
+	dst := map[string]interface{}{}
+	origins := Origins{}
+
+	mergeInto(dst, map[string]interface{}{
+		"section": map[string]interface{}{"a": 1, "b": 2},
+	}, "", "default", origins)
+	mergeInto(dst, map[string]interface{}{"section": "scalar"}, "", "repo", origins)
+
+	if _, ok := origins["section.a"]; ok {
+		t.Errorf("Expected nested origins to be cleared: %v", origins)
+	}
+	if origins["section"] != "repo" {
+		t.Errorf("Expected origin of section to be repo, got %s", origins["section"])
+	}
+}
+
+func TestLoadLayeredMergesUserAndExplicitFiles(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	userDir := filepath.Join(configHome, "gcm")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatalf("Failed to create user config dir: %v", err)
+	}
+	userConfig := "defaults:\n  provider: bedrock\n  model: user-model\n"
+	if err := os.WriteFile(filepath.Join(userDir, "config.yaml"), []byte(userConfig), 0644); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+
+	explicitPath := filepath.Join(t.TempDir(), "explicit.yaml")
+	if err := os.WriteFile(explicitPath, []byte("defaults:\n  model: file-model\n"), 0644); err != nil {
+		t.Fatalf("Failed to write explicit config: %v", err)
+	}
+
+	cfg, origins, err := LoadLayered(LoadOptions{
+		ConfigPath:      explicitPath,
+		Overrides:       map[string]interface{}{"defaults.provider": "claude"},
+		OverrideOrigins: map[string]string{"defaults.provider": "--provider"},
+	})
+	if err != nil {
+		t.Fatalf("LoadLayered failed: %v", err)
+	}
+
+	if cfg.Defaults.Provider != "claude" {
+		t.Errorf("Expected flag to win for provider, got %s", cfg.Defaults.Provider)
+	}
+	if cfg.Defaults.Model != "file-model" {
+		t.Errorf("Expected explicit file to win for model, got %s", cfg.Defaults.Model)
+	}
+	if len(cfg.SemanticReleasePrefixes) == 0 {
+		t.Errorf("Expected embedded defaults to be kept")
+	}
+	if origins["defaults.provider"] != "flag (--provider)" {
+		t.Errorf("Unexpected origin for provider: %s", origins["defaults.provider"])
+	}
+	if origins["defaults.model"] != "file ("+explicitPath+")" {
+		t.Errorf("Unexpected origin for model: %s", origins["defaults.model"])
+	}
+}
+
+func TestModelFollowsLayerOrder(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	userDir := filepath.Join(configHome, "gcm")
+	if err := os.MkdirAll(userDir, 0755); err != nil {
+		t.Fatalf("Failed to create user config dir: %v", err)
+	}
+	userConfig := "providers:\n  claude:\n    model: user-block-model\n  bedrock:\n    model: user-bedrock-model\n"
+	if err := os.WriteFile(filepath.Join(userDir, "config.yaml"), []byte(userConfig), 0644); err != nil {
+		t.Fatalf("Failed to write user config: %v", err)
+	}
+	explicitPath := filepath.Join(t.TempDir(), "explicit.yaml")
+	explicitConfig := "defaults:\n  model: file-model\nproviders:\n  bedrock:\n    model: file-bedrock-model\n"
+	if err := os.WriteFile(explicitPath, []byte(explicitConfig), 0644); err != nil {
+		t.Fatalf("Failed to write explicit config: %v", err)
+	}
+
+	cfg, _, err := LoadLayered(LoadOptions{ConfigPath: explicitPath})
+	if err != nil {
+		t.Fatalf("LoadLayered failed: %v", err)
+	}
+	// A later defaults.model beats an earlier provider block, and a provider block beats
+	// defaults.model of the same layer
+	for provider, want := range map[string]string{"claude": "file-model", "bedrock": "file-bedrock-model", "geminicli": "file-model"} {
+		if got := cfg.Model(provider); got != want {
+			t.Errorf("Model(%s) = %q, want %q", provider, got, want)
+		}
+	}
+}
+
+func TestMatchWildcard(t *testing.T) {
+	tests := []struct {
+		pattern string
+		url     string
+		want    bool
+	}{
+		{"*github.com*UNILORN/*", "git@github.com:UNILORN/repo.git", true},
+		{"*github.com*UNILORN/*", "https://github.com/unilorn/repo", true},
+		{"*github.example-corp.com*", "https://github.com/UNILORN/repo", false},
+		{"https://gitlab.com/team/repo", "https://gitlab.com/team/repo", true},
+	}
+
+	for _, tt := range tests {
+		if got := MatchWildcard(tt.pattern, tt.url); got != tt.want {
+			t.Errorf("MatchWildcard(%q, %q) = %v, want %v", tt.pattern, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLayeredAppliesRequestedProfile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	configPath := filepath.Join(t.TempDir(), "profiles.yaml")
+	content := `defaults:
+  provider: claude
+profiles:
+  work:
+    match: ["*corp.example.com*"]
+    defaults:
+      provider: bedrock
+      region: eu-west-1
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	cfg, origins, err := LoadLayered(LoadOptions{ConfigPath: configPath, Profile: "work"})
+	if err != nil {
+		t.Fatalf("LoadLayered failed: %v", err)
+	}
+
+	if cfg.ActiveProfile != "work" {
+		t.Errorf("Expected active profile work, got %q", cfg.ActiveProfile)
+	}
+	if cfg.Defaults.Provider != "bedrock" || cfg.Defaults.Region != "eu-west-1" {
+		t.Errorf("Expected profile values to be applied, got %+v", cfg.Defaults)
+	}
+	if origins["defaults.region"] != "profile (work)" {
+		t.Errorf("Unexpected origin for region: %s", origins["defaults.region"])
+	}
+
+	if _, _, err := LoadLayered(LoadOptions{ConfigPath: configPath, Profile: "missing"}); err == nil {
+		t.Errorf("Expected an error for an undefined profile")
+	}
+}
@@ -5,8 +5,6 @@ import (
 	"fmt"
 	"os"
 	"strings"
-
-	"gopkg.in/yaml.v3"
 )
 
 //go:embed prompt.yaml
@@ -31,7 +29,7 @@ func Load(configPath string) (*Config, error) {
 	}
 
 	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	if err := decodeStrict(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
@@ -60,9 +58,14 @@ func WriteDefaultConfig(path string, force bool) error {
 	return nil
 }
 
-// InitGlobal initializes the global configuration
+// InitGlobal initializes the global configuration from all discovered layers
 func InitGlobal(configPath string) error {
-	config, err := Load(configPath)
+	return InitGlobalWithOptions(LoadOptions{ConfigPath: configPath})
+}
+
+// InitGlobalWithOptions initializes the global configuration with command line overrides
+func InitGlobalWithOptions(opts LoadOptions) error {
+	config, _, err := LoadLayered(opts)
 	if err != nil {
 		return err
 	}
@@ -85,14 +88,22 @@ func Get() *Config {
 
 // BuildPrompt builds a prompt for commit message generation using template replacement
 func (c *Config) BuildPrompt(lang string, branch string, diff string) string {
-	// Normalize language code
-	normalizedLang := normalizeLangCode(lang)
+	instructions, input := c.BuildPromptParts(lang, branch, diff)
+	return instructions + input
+}
 
-	promptTemplate, ok := c.PromptTemplates[normalizedLang]
-	if !ok {
-		// Fallback to Japanese if language not found
-		promptTemplate = c.PromptTemplates["japanese"]
-	}
+// variablePlaceholders are the placeholders whose values change from call to call
+var variablePlaceholders = []string{"{branch}", "{examples}", "{diff}"}
+
+// BuildPromptParts builds the prompt of BuildPrompt split before the first line with a value that
+// changes from call to call (branch, examples or diff): the instructions before it (guidelines
+// and prefix table), which stay the same and can be cached by the provider, and the input from
+// there on. Examples of a template without the placeholder start the input.
+func (c *Config) BuildPromptParts(lang string, branch string, diff string) (instructions, input string) {
+	// Normalize language code
+	normalizedLang := NormalizeLangCode(lang)
+	promptTemplate := c.PromptTemplate(lang)
+	template := promptTemplate.Template
 
 	// Format guidelines
 	guidelinesText := formatGuidelines(promptTemplate.Guidelines)
@@ -100,14 +111,38 @@ func (c *Config) BuildPrompt(lang string, branch string, diff string) string {
 	// Format semantic release prefixes
 	prefixesText := formatSemanticReleasePrefixes(c.SemanticReleasePrefixes, normalizedLang)
 
+	// Format few-shot examples
+	examplesText := formatExamples(c.Examples, normalizedLang)
+
 	// Replace template variables
-	result := promptTemplate.Template
-	result = strings.ReplaceAll(result, "{guidelines}", guidelinesText)
-	result = strings.ReplaceAll(result, "{branch}", branch)
-	result = strings.ReplaceAll(result, "{semantic_release_prefixes}", prefixesText)
-	result = strings.ReplaceAll(result, "{diff}", diff)
+	render := func(text string) string {
+		text = strings.ReplaceAll(text, "{guidelines}", guidelinesText)
+		text = strings.ReplaceAll(text, "{branch}", branch)
+		text = strings.ReplaceAll(text, "{semantic_release_prefixes}", prefixesText)
+		if examplesText == "" {
+			// Drop the blank line that would separate the examples from the rest
+			text = strings.ReplaceAll(text, "{examples}\n\n", "")
+		}
+		return strings.ReplaceAll(text, "{examples}", examplesText)
+	}
+
+	split := len(template)
+	for _, placeholder := range variablePlaceholders {
+		if i := strings.Index(template, placeholder); i >= 0 && i < split {
+			split = strings.LastIndex(template[:i], "\n") + 1
+		}
+	}
+	instructions = render(template[:split])
+	if examplesText != "" && !strings.Contains(template, "{examples}") {
+		input = examplesText + "\n\n"
+	}
 
-	return result
+	// The diff is inserted last so that placeholders in it are left alone
+	before, after, found := strings.Cut(template[split:], "{diff}")
+	if !found {
+		return instructions, input + render(before)
+	}
+	return instructions, input + render(before) + diff + strings.ReplaceAll(render(after), "{diff}", diff)
 }
 
 // formatGuidelines formats guidelines as a bulleted list
@@ -131,19 +166,82 @@ func formatSemanticReleasePrefixes(prefixes []SemanticReleasePrefix, lang string
 		} else {
 			desc = p.DescriptionEN
 		}
+		if p.Emoji == "" {
+			sb.WriteString(fmt.Sprintf("\t- \"%s:\" : %s\n", p.Type, desc))
+			continue
+		}
 		sb.WriteString(fmt.Sprintf("\t- \"%s: %s\" : %s\n", p.Type, p.Emoji, desc))
 	}
 	return strings.TrimSuffix(sb.String(), "\n")
 }
 
+// maxExampleLines limits how much of each example message is shown to the model
+const maxExampleLines = 10
+
+// formatExamples formats commit messages as few-shot examples, or returns "" if there are none
+func formatExamples(examples []string, lang string) string {
+	if len(examples) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	if lang == "ja" {
+		sb.WriteString("以下はこのリポジトリの最近のコミットメッセージです。言語、Prefix、長さなどの書き方を合わせてください：\n")
+	} else {
+		sb.WriteString("Recent commit messages of this repository (match their language, prefix style and length):\n")
+	}
+	for _, example := range examples {
+		lines := strings.Split(strings.TrimSpace(example), "\n")
+		if len(lines) > maxExampleLines {
+			lines = append(lines[:maxExampleLines], "...")
+		}
+		sb.WriteString("---\n")
+		sb.WriteString(strings.Join(lines, "\n"))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("---")
+	return sb.String()
+}
+
 // BuildPromptEnglish builds an English prompt for commit message generation
 // This is a convenience method that calls BuildPrompt with "english" language
 func (c *Config) BuildPromptEnglish(branch string, diff string) string {
 	return c.BuildPrompt("english", branch, diff)
 }
 
-// normalizeLangCode normalizes language codes to a standard format
-func normalizeLangCode(lang string) string {
+// PromptTemplate returns the prompt template BuildPrompt uses for a language
+func (c *Config) PromptTemplate(lang string) PromptTemplate {
+	promptTemplate, ok := c.lookupTemplate(lang, NormalizeLangCode(lang))
+	if !ok {
+		// Fallback to Japanese if language not found
+		promptTemplate = c.PromptTemplates["japanese"]
+	}
+	return promptTemplate
+}
+
+// lookupTemplate finds the prompt template for a language.
+// Templates may be keyed by the name given, the full language name ("english") or the short code ("en").
+func (c *Config) lookupTemplate(lang, normalizedLang string) (PromptTemplate, bool) {
+	for _, key := range []string{lang, templateKey(normalizedLang), normalizedLang} {
+		if promptTemplate, ok := c.PromptTemplates[key]; ok {
+			return promptTemplate, true
+		}
+	}
+	return PromptTemplate{}, false
+}
+
+// templateKey returns the template key used by the default config for a normalized language code
+func templateKey(normalizedLang string) string {
+	switch normalizedLang {
+	case "ja":
+		return "japanese"
+	default:
+		return "english"
+	}
+}
+
+// NormalizeLangCode normalizes a language name or code to "ja" or "en" (the default)
+func NormalizeLangCode(lang string) string {
 	switch strings.ToLower(lang) {
 	case "ja", "japanese", "jp", "jpn":
 		return "ja"
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildPromptIncludesExamples(t *testing.T) {
+	cfg, err := LoadDefault()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+
+	prompt := cfg.BuildPrompt("english", "main", "DIFF")
+	if strings.Contains(prompt, "{examples}") || strings.Contains(prompt, "Recent commit messages") {
+		t.Errorf("Expected no examples section without examples:\n%s", prompt)
+	}
+
+	cfg.Examples = []string{"feat: add login form", "fix: handle timeouts"}
+	prompt = cfg.BuildPrompt("english", "main", "DIFF")
+	if !strings.Contains(prompt, "---\nfeat: add login form\n---\nfix: handle timeouts\n---") {
+		t.Errorf("Expected the examples in the prompt:\n%s", prompt)
+	}
+
+	// Templates without the placeholder get the examples before the diff
+	cfg.PromptTemplates["english"] = PromptTemplate{Template: "{guidelines}\n{diff}", Guidelines: []string{"Be brief"}}
+	prompt = cfg.BuildPrompt("english", "main", "DIFF")
+	if !strings.HasPrefix(prompt, "- Be brief\nRecent commit messages") || !strings.HasSuffix(prompt, "---\n\nDIFF") {
+		t.Errorf("Expected the examples before the diff:\n%s", prompt)
+	}
+}
+
+func TestBuildPromptParts(t *testing.T) {
+	cfg, err := LoadDefault()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+
+	diff := "+ {branch} stays as is"
+	instructions, input := cfg.BuildPromptParts("english", "main", diff)
+	if instructions+input != cfg.BuildPrompt("english", "main", diff) {
+		t.Error("expected the parts to add up to the prompt")
+	}
+	if strings.Contains(instructions, diff) || strings.Contains(instructions, "main") || !strings.Contains(instructions, "Semantic Release Prefixes") {
+		t.Errorf("expected the instructions to end before the branch:\n%s", instructions)
+	}
+	if !strings.HasPrefix(input, "Current branch: main\n") || !strings.Contains(input, diff+"\n") || !strings.Contains(input, "OUTPUT FORMAT") {
+		t.Errorf("expected the input to start with the branch:\n%s", input)
+	}
+
+	// The instructions depend on neither the diff, the branch nor the examples, so the provider
+	// can cache them across commits
+	cfg.Examples = []string{"feat: add login form"}
+	for _, lang := range []string{"english", "japanese"} {
+		base, _ := cfg.BuildPromptParts(lang, "main", diff)
+		if other, _ := cfg.BuildPromptParts(lang, "feature/login", "another diff"); other != base || strings.Contains(other, "feat: add login form") {
+			t.Errorf("expected the same %s instructions for another branch and diff:\n%s", lang, other)
+		}
+	}
+}
+
+func TestPriceOf(t *testing.T) {
+	cfg, err := LoadDefault()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	cfg.Pricing["claude-sonnet-4-6"] = Price{Input: 2, Output: 10}
+
+	if price, ok := cfg.PriceOf("claude-sonnet-4-6"); !ok || price.Input != 2 {
+		t.Errorf("expected the exact key to win, got %+v, %v", price, ok)
+	}
+	if price, ok := cfg.PriceOf("anthropic.claude-sonnet-4-5-20250929-v1:0"); !ok || price.Input != 3 || price.CacheRead != 0.3 {
+		t.Errorf("expected the default sonnet price for a Bedrock model ID, got %+v, %v", price, ok)
+	}
+	if _, ok := cfg.PriceOf("gemini-2.5-pro"); ok {
+		t.Error("expected no price for a model missing from the table")
+	}
+}
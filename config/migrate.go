@@ -0,0 +1,194 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// migration upgrades a config document from schema version From to From+1.
+// Apply edits the root mapping in place and returns a description of each change, and the
+// keys it removed because this release does not know them.
+type migration struct {
+	From  int
+	Apply func(root *yaml.Node) (changes, removed []string)
+}
+
+// migrations must be ordered by From and cover every version below CurrentSchemaVersion
+var migrations = []migration{
+	{From: 1, Apply: migrateV1},
+}
+
+// MigrationResult describes the outcome of migrating a config document
+type MigrationResult struct {
+	FromVersion int
+	ToVersion   int
+	Changes     []string
+	// Removed lists the unknown keys that were dropped; their settings are lost
+	Removed []string
+	Data    []byte
+}
+
+// Changed reports whether the migration modified the document
+func (r *MigrationResult) Changed() bool {
+	return r.FromVersion != r.ToVersion || len(r.Changes) > 0
+}
+
+// MigrateFile upgrades a config file to CurrentSchemaVersion.
+// The original file is kept as <path>.bak unless dryRun is set, in which case nothing is written.
+func MigrateFile(path string, dryRun bool) (*MigrationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	result, err := MigrateData(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if dryRun || !result.Changed() {
+		return result, nil
+	}
+
+	if err := os.WriteFile(path+".bak", data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.WriteFile(path, result.Data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return result, nil
+}
+
+// MigrateData upgrades a config document to CurrentSchemaVersion.
+// Files without schema_version are treated as version 1.
+func MigrateData(data []byte) (*MigrationResult, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return &MigrationResult{FromVersion: CurrentSchemaVersion, ToVersion: CurrentSchemaVersion, Data: data}, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config must be a mapping")
+	}
+
+	version := 1
+	if node := mappingValue(root, "schema_version"); node != nil {
+		v, err := strconv.Atoi(node.Value)
+		if err != nil || v < 1 {
+			return nil, fmt.Errorf("invalid schema_version %q", node.Value)
+		}
+		version = v
+	}
+	if version > CurrentSchemaVersion {
+		return nil, fmt.Errorf("schema_version %d is newer than this release supports (%d)", version, CurrentSchemaVersion)
+	}
+
+	result := &MigrationResult{FromVersion: version, ToVersion: CurrentSchemaVersion, Data: data}
+	if version == CurrentSchemaVersion {
+		return result, nil
+	}
+
+	for _, m := range migrations {
+		if m.From >= version {
+			changes, removed := m.Apply(root)
+			result.Changes = append(result.Changes, changes...)
+			result.Removed = append(result.Removed, removed...)
+		}
+	}
+	setSchemaVersion(root, CurrentSchemaVersion)
+	result.Changes = append(result.Changes, fmt.Sprintf("set schema_version to %d", CurrentSchemaVersion))
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to render config: %w", err)
+	}
+	result.Data = buf.Bytes()
+	return result, nil
+}
+
+// migrateV1 renames prompt template language aliases to their canonical names and
+// removes top-level keys that were silently ignored before strict decoding.
+func migrateV1(root *yaml.Node) (changes, removed []string) {
+	if templates := mappingValue(root, "prompt_templates"); templates != nil && templates.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(templates.Content); i += 2 {
+			key := templates.Content[i]
+			canonical := templateKey(NormalizeLangCode(key.Value))
+			if key.Value == canonical || !isLanguageAlias(key.Value) {
+				continue
+			}
+			if mappingValue(templates, canonical) != nil {
+				changes = append(changes, fmt.Sprintf("kept prompt_templates.%s because prompt_templates.%s already exists", key.Value, canonical))
+				continue
+			}
+			changes = append(changes, fmt.Sprintf("renamed prompt_templates.%s to prompt_templates.%s", key.Value, canonical))
+			key.Value = canonical
+		}
+	}
+
+	known := configKeys()
+	content := root.Content[:0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		if !known[key] {
+			changes = append(changes, fmt.Sprintf("removed unknown key %q", key))
+			removed = append(removed, key)
+			continue
+		}
+		content = append(content, root.Content[i], root.Content[i+1])
+	}
+	root.Content = content
+
+	return changes, removed
+}
+
+// isLanguageAlias reports whether a template key is a short form of a language name
+func isLanguageAlias(key string) bool {
+	switch strings.ToLower(key) {
+	case "ja", "jp", "jpn", "en", "eng":
+		return true
+	}
+	return false
+}
+
+// configKeys returns the top-level keys accepted by Config
+func configKeys() map[string]bool {
+	keys := map[string]bool{}
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// setSchemaVersion stores the schema version as the first key of the document
+func setSchemaVersion(root *yaml.Node, version int) {
+	value := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(version)}
+	if node := mappingValue(root, "schema_version"); node != nil {
+		*node = *value
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "schema_version"}
+	// Keep leading comments (e.g. the yaml-language-server modeline) at the top of the file
+	if len(root.Content) > 0 {
+		key.HeadComment = root.Content[0].HeadComment
+		root.Content[0].HeadComment = ""
+	}
+	root.Content = append([]*yaml.Node{key, value}, root.Content...)
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrateDataUpgradesVersion1(t *testing.T) {
+	data := []byte("# comment\nprompt_templates:\n  en:\n    guidelines: [\"a\"]\nlegacy: true\n")
+
+	result, err := MigrateData(data)
+	if err != nil {
+		t.Fatalf("MigrateData failed: %v", err)
+	}
+	if result.FromVersion != 1 || result.ToVersion != CurrentSchemaVersion {
+		t.Errorf("Unexpected versions: %d -> %d", result.FromVersion, result.ToVersion)
+	}
+	if errs := ValidateData(result.Data); len(errs) > 0 {
+		t.Errorf("Migrated config is invalid: %v\n%s", errs, result.Data)
+	}
+
+	cfg := &Config{}
+	if err := decodeStrict(result.Data, cfg); err != nil {
+		t.Fatalf("Failed to decode migrated config: %v", err)
+	}
+	if _, ok := cfg.PromptTemplates["english"]; !ok {
+		t.Errorf("Expected the en template to be renamed to english: %s", result.Data)
+	}
+	if len(result.Removed) != 1 || result.Removed[0] != "legacy" {
+		t.Errorf("Expected the unknown key legacy to be reported as removed, got %v", result.Removed)
+	}
+	if !strings.HasPrefix(string(result.Data), "# comment\nschema_version: 2\n") {
+		t.Errorf("Expected the leading comment to stay at the top:\n%s", result.Data)
+	}
+
+	again, err := MigrateData(result.Data)
+	if err != nil {
+		t.Fatalf("MigrateData failed on migrated config: %v", err)
+	}
+	if again.Changed() {
+		t.Errorf("Expected no changes for an up-to-date config, got %v", again.Changes)
+	}
+}
+
+func TestMigrateDataRejectsNewerVersion(t *testing.T) {
+	if _, err := MigrateData([]byte("schema_version: 99\n")); err == nil {
+		t.Errorf("Expected an error for a newer schema version")
+	}
+}
+
+func TestRenderInitConfigPresets(t *testing.T) {
+	for _, preset := range append([]string{""}, Presets...) {
+		data, err := RenderInitConfig(InitOptions{Preset: preset, Language: "english", Provider: "claude", TicketPattern: "[A-Z]+-[0-9]+"})
+		if err != nil {
+			t.Fatalf("RenderInitConfig(%q) failed: %v", preset, err)
+		}
+
+		cfg := &Config{}
+		if err := decodeStrict(data, cfg); err != nil {
+			t.Fatalf("Failed to decode preset %q: %v", preset, err)
+		}
+		if cfg.SchemaVersion != CurrentSchemaVersion {
+			t.Errorf("Preset %q has schema version %d", preset, cfg.SchemaVersion)
+		}
+		if cfg.Defaults.Provider != "claude" || cfg.Defaults.Language != "english" {
+			t.Errorf("Preset %q did not record the answers: %+v", preset, cfg.Defaults)
+		}
+		if len(cfg.Ticket.Patterns) != 1 || cfg.Ticket.Patterns[0].Regex != "[A-Z]+-[0-9]+" {
+			t.Errorf("Preset %q did not record the ticket pattern: %+v", preset, cfg.Ticket)
+		}
+	}
+
+	if _, err := RenderInitConfig(InitOptions{Preset: "unknown"}); err == nil {
+		t.Errorf("Expected an error for an unknown preset")
+	}
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// Profile is a named set of config values applied on top of the config files.
+// Any config section may be set in a profile; Match lists remote URL patterns
+// (with * wildcards) that select the profile automatically.
+type Profile struct {
+	Match  []string `yaml:"match,omitempty"`
+	Config `yaml:",inline"`
+}
+
+// ProfileNames returns the names of all defined profiles, sorted
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectProfile decides which profile applies to the merged config.
+// An explicitly requested profile wins, followed by git config gcm.profile and
+// the first profile (by name) whose match patterns fit the origin remote URL.
+func selectProfile(merged map[string]interface{}, requested string) (string, error) {
+	profiles, _ := merged["profiles"].(map[string]interface{})
+
+	if requested == "" {
+		requested, _ = git.GetConfigValue("gcm.profile")
+	}
+	if requested != "" {
+		if _, ok := profiles[requested]; !ok {
+			return "", fmt.Errorf("profile %q is not defined", requested)
+		}
+		return requested, nil
+	}
+
+	if len(profiles) == 0 {
+		return "", nil
+	}
+	remoteURL, err := git.GetRemoteURL("origin")
+	if err != nil || remoteURL == "" {
+		return "", nil
+	}
+
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		profile, _ := profiles[name].(map[string]interface{})
+		patterns, _ := profile["match"].([]interface{})
+		for _, pattern := range patterns {
+			if p, ok := pattern.(string); ok && MatchWildcard(p, remoteURL) {
+				return name, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// profileLayer builds the layer that applies a profile's values
+func profileLayer(merged map[string]interface{}, name string) Layer {
+	profiles, _ := merged["profiles"].(map[string]interface{})
+	profile, _ := profiles[name].(map[string]interface{})
+
+	data := map[string]interface{}{}
+	for key, value := range profile {
+		// Selection patterns and nested profiles are not config values
+		if key == "match" || key == "profiles" {
+			continue
+		}
+		data[key] = value
+	}
+	return Layer{Name: "profile", Path: name, Data: data}
+}
+
+// MatchWildcard reports whether s matches pattern, where * matches any sequence of characters
+func MatchWildcard(pattern, s string) bool {
+	quoted := regexp.QuoteMeta(pattern)
+	re, err := regexp.Compile("(?i)^" + strings.ReplaceAll(quoted, `\*`, ".*") + "$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(s)
+}
@@ -1,9 +1,323 @@
 package config
 
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CurrentSchemaVersion is the config file format version written by this release.
+// Files with an older (or missing) schema_version can be upgraded with `config migrate`.
+const CurrentSchemaVersion = 2
+
 // Config represents the entire configuration
 type Config struct {
-	PromptTemplates        map[string]PromptTemplate `yaml:"prompt_templates"`
-	SemanticReleasePrefixes []SemanticReleasePrefix  `yaml:"semantic_release_prefixes"`
+	SchemaVersion           int                       `yaml:"schema_version,omitempty"`
+	PromptTemplates         map[string]PromptTemplate `yaml:"prompt_templates,omitempty"`
+	SemanticReleasePrefixes []SemanticReleasePrefix   `yaml:"semantic_release_prefixes,omitempty"`
+	Defaults                Defaults                  `yaml:"defaults,omitempty"`
+	Providers               map[string]ProviderConfig `yaml:"providers,omitempty"`
+	Profiles                map[string]Profile        `yaml:"profiles,omitempty"`
+	Ticket                  TicketConfig              `yaml:"ticket,omitempty"`
+	Trailers                TrailersConfig            `yaml:"trailers,omitempty"`
+	Style                   StyleConfig               `yaml:"style,omitempty"`
+	Context                 ContextConfig             `yaml:"context,omitempty"`
+	Scopes                  ScopesConfig              `yaml:"scopes,omitempty"`
+	Breaking                BreakingConfig            `yaml:"breaking,omitempty"`
+	PR                      PRConfig                  `yaml:"pr,omitempty"`
+	Reword                  RewordConfig              `yaml:"reword,omitempty"`
+	Branch                  BranchConfig              `yaml:"branch,omitempty"`
+	Review                  ReviewConfig              `yaml:"review,omitempty"`
+	Cache                   CacheConfig               `yaml:"cache,omitempty"`
+	Retry                   RetryConfig               `yaml:"retry,omitempty"`
+	Usage                   UsageConfig               `yaml:"usage,omitempty"`
+	Pricing                 map[string]Price          `yaml:"pricing,omitempty"`
+	Metrics                 MetricsConfig             `yaml:"metrics,omitempty"`
+
+	// ActiveProfile is the name of the profile applied while loading, if any
+	ActiveProfile string `yaml:"-"`
+	// Examples are recent commit messages of the repository rendered as few-shot examples by BuildPrompt
+	Examples []string `yaml:"-"`
+
+	// origins and layers record which layer set each value, for settings that two keys can set
+	// (see Model)
+	origins Origins
+	layers  []string
+}
+
+// Defaults holds the values used when the corresponding command line flags are not given
+type Defaults struct {
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	Region   string `yaml:"region,omitempty"`
+	Verbose  bool   `yaml:"verbose,omitempty"`
+	Timeout  string `yaml:"timeout,omitempty"`
+	Language string `yaml:"language,omitempty"`
+	// Emoji is one of EmojiStyles and rewrites the emoji after the type prefix of the
+	// summary line; empty keeps whatever the model wrote
+	Emoji string `yaml:"emoji,omitempty"`
+	// Fallback lists the providers tried in order when the provider fails; each uses the
+	// model of its provider block or its built-in default
+	Fallback []string `yaml:"fallback,omitempty"`
+}
+
+// Emoji styles of the summary line
+const (
+	EmojiNone      = "none"
+	EmojiShortcode = "shortcode"
+	EmojiUnicode   = "unicode"
+)
+
+// EmojiStyles lists the valid values of defaults.emoji
+var EmojiStyles = []string{EmojiNone, EmojiShortcode, EmojiUnicode}
+
+// ProviderConfig holds the settings of a single AI provider.
+// Not every provider supports every setting; unsupported ones are ignored.
+type ProviderConfig struct {
+	Model       string   `yaml:"model,omitempty"`
+	BaseURL     string   `yaml:"base_url,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	ExtraArgs   []string `yaml:"extra_args,omitempty"`
+}
+
+// Ticket reference placements
+const (
+	TicketPlacementSubject       = "subject"
+	TicketPlacementSubjectPrefix = "subject_prefix"
+	TicketPlacementFooter        = "footer"
+	TicketPlacementNone          = "none"
+)
+
+// TicketPlacements lists the valid values of ticket.placement
+var TicketPlacements = []string{TicketPlacementSubject, TicketPlacementSubjectPrefix, TicketPlacementFooter, TicketPlacementNone}
+
+// TicketConfig controls how ticket references are extracted from branch names and written into messages
+type TicketConfig struct {
+	// Patterns are tried in order against the branch name; the first match wins
+	Patterns []TicketPattern `yaml:"patterns,omitempty"`
+	// Placement is one of TicketPlacements (default: subject)
+	Placement string `yaml:"placement,omitempty"`
+	// Footer is the footer key used with the footer placement (default: Refs)
+	Footer string `yaml:"footer,omitempty"`
+	// Required makes generation fail when the final message does not contain the reference
+	Required bool `yaml:"required,omitempty"`
+}
+
+// TicketPattern describes one kind of ticket ID (e.g. Jira keys or GitHub issue numbers)
+type TicketPattern struct {
+	Name string `yaml:"name"`
+	// Regex is matched against the branch name; the first capture group (or the whole match) is the ID
+	Regex string `yaml:"regex"`
+	// Reference formats the ID for the message, e.g. "#{id}" (default: "{id}")
+	Reference string `yaml:"reference,omitempty"`
+	// Footer overrides TicketConfig.Footer for this pattern, e.g. "Closes"
+	Footer string `yaml:"footer,omitempty"`
+	// Uppercase converts the ID to upper case (useful for lowercase branch names)
+	Uppercase bool `yaml:"uppercase,omitempty"`
+}
+
+// TrailerIfExists lists the values of trailers.if_exists (see git interpret-trailers --if-exists)
+var TrailerIfExists = []string{"addIfDifferentNeighbor", "addIfDifferent", "add", "replace", "doNothing"}
+
+// TrailersConfig controls the git trailers appended after generation
+type TrailersConfig struct {
+	// Signoff adds Signed-off-by with the git identity
+	Signoff bool `yaml:"signoff,omitempty"`
+	// CoAuthors are always added as Co-authored-by; entries are team file aliases or "Name <email>"
+	CoAuthors []string `yaml:"co_authors,omitempty"`
+	// TeamFile is a .mailmap-style file used to resolve co-author aliases (default: .mailmap at the repository root)
+	TeamFile string `yaml:"team_file,omitempty"`
+	// GeneratedBy is the value of a Generated-by trailer; {provider} and {model} are replaced
+	GeneratedBy string `yaml:"generated_by,omitempty"`
+	// IfExists is passed to git interpret-trailers --if-exists (default: addIfDifferent)
+	IfExists string `yaml:"if_exists,omitempty"`
+}
+
+// StyleConfig controls learning the commit style from the repository history
+type StyleConfig struct {
+	// Examples is the number of recent commit messages shown to the model (0 disables)
+	Examples int `yaml:"examples,omitempty"`
+	// Author restricts the examples to an author; "me" means the git user.email
+	Author string `yaml:"author,omitempty"`
+	// Paths restricts the examples to commits touching these paths
+	Paths []string `yaml:"paths,omitempty"`
+	// StagedPaths restricts the examples to commits touching the staged files
+	StagedPaths bool `yaml:"staged_paths,omitempty"`
+	// Infer derives the language, prefix style, emoji usage and subject length from the
+	// history when the repository has no config file of its own
+	Infer bool `yaml:"infer,omitempty"`
+}
+
+// ContextConfig selects the repository context added to the prompt next to the diff
+type ContextConfig struct {
+	// TokenBudget is the approximate number of tokens the diff and the context may use (0 = unlimited).
+	// Context sections that do not fit are left out.
+	TokenBudget int `yaml:"token_budget,omitempty"`
+	// Stat adds the diffstat (git diff --stat)
+	Stat bool `yaml:"stat,omitempty"`
+	// Functions adds the enclosing function names of each hunk
+	Functions bool `yaml:"functions,omitempty"`
+	// Packages adds the package and module each staged file belongs to
+	Packages bool `yaml:"packages,omitempty"`
+	// RecentCommits adds the subjects of this many recent commits touching the staged files
+	RecentCommits int `yaml:"recent_commits,omitempty"`
+	// Readme adds the summary of the README at the repository root
+	Readme bool `yaml:"readme,omitempty"`
+}
+
+// Multi-scope handling modes
+const (
+	ScopesJoin     = "join"
+	ScopesMajority = "majority"
+	ScopesOmit     = "omit"
+)
+
+// ScopesMultiple lists the valid values of scopes.multiple
+var ScopesMultiple = []string{ScopesJoin, ScopesMajority, ScopesOmit}
+
+// ScopesConfig controls how the Conventional Commits scope is inferred from the changed paths
+type ScopesConfig struct {
+	// Rules map path globs to scopes; the first matching rule of each file wins
+	Rules []ScopeRule `yaml:"rules,omitempty"`
+	// Monorepo uses the directory name of nested modules (go.mod, package.json, ...) for files no rule matches
+	Monorepo bool `yaml:"monorepo,omitempty"`
+	// Multiple is one of ScopesMultiple and decides what happens when files map to several scopes (default: join)
+	Multiple string `yaml:"multiple,omitempty"`
+	// MaxScopes is the number of scopes join accepts before omitting the scope (default: 3)
+	MaxScopes int `yaml:"max_scopes,omitempty"`
+	// Enforce rewrites the scope of the generated summary line to the inferred one
+	Enforce bool `yaml:"enforce,omitempty"`
+}
+
+// ScopeRule maps files matching Glob (supports *, **, ? and {a,b}) to Scope
+type ScopeRule struct {
+	Glob  string `yaml:"glob"`
+	Scope string `yaml:"scope"`
+}
+
+// BreakingConfig controls the detection of breaking changes to the exported Go API
+type BreakingConfig struct {
+	// Detect compares the exported API of the staged Go packages with HEAD and marks the
+	// message with "!" and a BREAKING CHANGE footer when something was removed or changed
+	Detect bool `yaml:"detect,omitempty"`
+	// Ignore lists path globs of Go files that are not public API (e.g. examples/**)
+	Ignore []string `yaml:"ignore,omitempty"`
+}
+
+// PRConfig holds the settings of the pr subcommand
+type PRConfig struct {
+	// Base is the branch the pull request merges into (default: origin/main)
+	Base string `yaml:"base,omitempty"`
+	// Template is the path of the pull request template; by default the locations GitHub
+	// uses (.github/pull_request_template.md, ...) are searched
+	Template string `yaml:"template,omitempty"`
+}
+
+// RewordConfig holds the settings of the reword subcommand
+type RewordConfig struct {
+	// Protected are branch patterns (* is a wildcard) whose history reword refuses to
+	// rewrite without --force
+	Protected []string `yaml:"protected,omitempty"`
+}
+
+// BranchConfig holds the settings of the branch subcommand
+type BranchConfig struct {
+	// Pattern is the branch name with {type}, {ticket} and {slug} placeholders
+	// (default: {type}/{ticket}-{slug}); separators next to an empty placeholder are dropped
+	Pattern string `yaml:"pattern,omitempty"`
+	// MaxLength limits the length of the branch name by shortening the slug (0 = unlimited)
+	MaxLength int `yaml:"max_length,omitempty"`
+}
+
+// Review severities, from the least to the most severe
+const (
+	SeverityInfo    = "info"
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Severities lists the review severities in ascending order
+var Severities = []string{SeverityInfo, SeverityWarning, SeverityError}
+
+// ReviewFailOnNone disables the review gate
+const ReviewFailOnNone = "none"
+
+// ReviewConfig holds the settings of the review subcommand
+type ReviewConfig struct {
+	// FailOn is the lowest severity that makes review exit with status 1 (one of Severities,
+	// or "none" to always succeed)
+	FailOn string `yaml:"fail_on,omitempty"`
+	// Instructions are additional review guidelines of the project
+	Instructions string `yaml:"instructions,omitempty"`
+	// ChunkTokens is the approximate size of the diff sent in one request; larger diffs are
+	// reviewed in several requests, never truncated (0 = one request)
+	ChunkTokens int `yaml:"chunk_tokens,omitempty"`
+}
+
+// CacheConfig controls the on-disk cache of model responses
+type CacheConfig struct {
+	// Enabled reuses the response of an identical request instead of calling the model again
+	Enabled bool `yaml:"enabled,omitempty"`
+	// TTL is how long a response is reused (e.g. 24h; 0 = forever)
+	TTL string `yaml:"ttl,omitempty"`
+	// Dir is the cache directory (default: $XDG_CACHE_HOME/gcm or ~/.cache/gcm)
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// RetryConfig controls retrying a provider after a retryable error (rate limit, overload, timeout)
+type RetryConfig struct {
+	// MaxAttempts is the number of requests sent to a provider before falling back (0 or 1 = no retries)
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// InitialDelay is the wait before the first retry; it doubles on every retry (e.g. 1s)
+	InitialDelay string `yaml:"initial_delay,omitempty"`
+	// MaxDelay caps the wait between retries; a provider asking for a longer wait is skipped (e.g. 30s)
+	MaxDelay string `yaml:"max_delay,omitempty"`
+}
+
+// UsageConfig controls the local log of token usage
+type UsageConfig struct {
+	// Log appends the tokens and cost of every model request to the usage log
+	Log bool `yaml:"log,omitempty"`
+	// Path is the usage log file (default: $XDG_STATE_HOME/gcm/usage.jsonl or ~/.local/state/gcm/usage.jsonl)
+	Path string `yaml:"path,omitempty"`
+}
+
+// MetricsConfig controls the local log of how generated commit messages are used
+type MetricsConfig struct {
+	// Enabled records every generated message and what became of it in the metrics log
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path is the metrics log file (default: $XDG_STATE_HOME/gcm/metrics.jsonl or ~/.local/state/gcm/metrics.jsonl)
+	Path string `yaml:"path,omitempty"`
+}
+
+// Price is the price of a model in USD per million tokens
+type Price struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+	// CacheWrite and CacheRead are the prices of prompt tokens written to and read from the prompt cache
+	CacheWrite float64 `yaml:"cache_write,omitempty"`
+	CacheRead  float64 `yaml:"cache_read,omitempty"`
+}
+
+// PriceOf returns the price of a model. Keys of the pricing table are model IDs in which *
+// matches any characters; an exact key wins, then the longest matching pattern.
+func (c *Config) PriceOf(model string) (Price, bool) {
+	if price, ok := c.Pricing[model]; ok {
+		return price, true
+	}
+	best := ""
+	for pattern := range c.Pricing {
+		if len(pattern) > len(best) && MatchWildcard(pattern, model) {
+			best = pattern
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return c.Pricing[best], true
 }
 
 // PromptTemplate represents a template for generating commit messages
@@ -29,3 +343,62 @@ func (c *Config) GetPrefixList() []string {
 	return prefixes
 }
 
+// Provider returns the settings of the named provider, or zero values if it has no block
+func (c *Config) Provider(name string) ProviderConfig {
+	return c.Providers[name]
+}
+
+// Model returns the configured model of the named provider: the model of its provider block
+// or defaults.model, whichever a later layer set, so that a repository's defaults.model
+// overrides a provider block of the user config. Within one layer the provider block wins.
+func (c *Config) Model(name string) string {
+	model := c.Provider(name).Model
+	if model == "" || c.Defaults.Model != "" && c.layerOf("defaults.model") > c.layerOf("providers."+name+".model") {
+		return c.Defaults.Model
+	}
+	return model
+}
+
+// layerOf returns the position of the layer that set the value at path among the applied
+// layers, or -1 when no layer set it
+func (c *Config) layerOf(path string) int {
+	origin, ok := c.origins[path]
+	if !ok {
+		return -1
+	}
+	return slices.Index(c.layers, origin)
+}
+
+// ParseTimeout parses a timeout value such as "90s" or "2m".
+// A bare number is interpreted as seconds and an empty value as no timeout.
+func ParseTimeout(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	return timeout, nil
+}
+
+// Timeout returns the configured request timeout, or fallback if none (or an invalid one) is configured
+func (c *Config) Timeout(fallback time.Duration) time.Duration {
+	timeout, err := ParseTimeout(c.Defaults.Timeout)
+	if err != nil || timeout <= 0 {
+		return fallback
+	}
+	return timeout
+}
+
+// PromptLanguage returns the configured prompt language, or fallback if none is configured
+func (c *Config) PromptLanguage(fallback string) string {
+	if c.Defaults.Language != "" {
+		return c.Defaults.Language
+	}
+	return fallback
+}
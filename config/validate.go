@@ -0,0 +1,405 @@
+package config
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed schema.json
+var schemaData []byte
+
+// knownPlaceholders lists the variables that BuildPrompt replaces in a template
+var knownPlaceholders = map[string]bool{
+	"guidelines":                true,
+	"branch":                    true,
+	"semantic_release_prefixes": true,
+	"diff":                      true,
+	"examples":                  true,
+}
+
+// nonNegativePaths lists the integer settings that must not be negative
+var nonNegativePaths = []string{"style.examples", "context.token_budget", "context.recent_commits", "scopes.max_scopes", "branch.max_length", "retry.max_attempts", "review.chunk_tokens"}
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+	shortcodePattern   = regexp.MustCompile(`^:[a-z0-9_+\-]+:$`)
+	prefixTypePattern  = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+	scopePattern       = regexp.MustCompile(`^[a-z0-9][a-z0-9_./-]*$`)
+	typeErrorPattern   = regexp.MustCompile(`^line (\d+): (.*)$`)
+)
+
+// Schema returns the JSON Schema describing the config file format
+func Schema() []byte {
+	return schemaData
+}
+
+// ValidationError describes a single problem found in a config file
+type ValidationError struct {
+	Line    int
+	Path    string
+	Message string
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	var sb strings.Builder
+	if e.Line > 0 {
+		sb.WriteString(fmt.Sprintf("line %d: ", e.Line))
+	}
+	if e.Path != "" {
+		sb.WriteString(e.Path)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Message)
+	return sb.String()
+}
+
+// ValidateFile validates a config file on disk
+func ValidateFile(path string) ([]ValidationError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return ValidateData(data), nil
+}
+
+// ValidateData validates a config document: it must decode strictly into Config
+// and pass the semantic checks performed by validateNode.
+func ValidateData(data []byte) []ValidationError {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return yamlErrors(err)
+	}
+	// An empty document is a valid (if useless) config
+	if len(root.Content) == 0 {
+		return nil
+	}
+
+	var errs []ValidationError
+	if err := decodeStrict(data, &Config{}); err != nil {
+		errs = append(errs, yamlErrors(err)...)
+	}
+	errs = append(errs, validateNode(root.Content[0], "")...)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return errs
+}
+
+// Validate performs the semantic checks on an already decoded config (without line numbers)
+func (c *Config) Validate() []ValidationError {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return []ValidationError{{Message: err.Error()}}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+
+	errs := validateNode(root.Content[0], "")
+	for i := range errs {
+		errs[i].Line = 0
+	}
+	return errs
+}
+
+// decodeStrict decodes YAML into out, rejecting keys that do not exist in the target type
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// yamlErrors converts a YAML decoding error into validation errors with line numbers
+func yamlErrors(err error) []ValidationError {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return []ValidationError{{Message: strings.TrimPrefix(err.Error(), "yaml: ")}}
+	}
+
+	errs := make([]ValidationError, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		if m := typeErrorPattern.FindStringSubmatch(msg); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			errs = append(errs, ValidationError{Line: line, Message: m[2]})
+			continue
+		}
+		errs = append(errs, ValidationError{Message: msg})
+	}
+	return errs
+}
+
+// validateNode runs the semantic checks on a config mapping node
+func validateNode(node *yaml.Node, prefix string) []ValidationError {
+	if node.Kind != yaml.MappingNode {
+		return []ValidationError{{Line: node.Line, Path: prefix, Message: "expected a mapping"}}
+	}
+
+	var errs []ValidationError
+	if version := mappingValue(node, "schema_version"); version != nil {
+		errs = append(errs, validateSchemaVersion(version, prefix)...)
+	}
+	if templates := mappingValue(node, "prompt_templates"); templates != nil {
+		errs = append(errs, validatePromptTemplates(templates, joinPath(prefix, "prompt_templates"))...)
+	}
+	if prefixes := mappingValue(node, "semantic_release_prefixes"); prefixes != nil {
+		errs = append(errs, validatePrefixes(prefixes, joinPath(prefix, "semantic_release_prefixes"))...)
+	}
+	if timeout := mappingValue(mappingValue(node, "defaults"), "timeout"); timeout != nil {
+		if _, err := ParseTimeout(timeout.Value); err != nil {
+			errs = append(errs, ValidationError{Line: timeout.Line, Path: joinPath(prefix, "defaults.timeout"), Message: err.Error()})
+		}
+	}
+	if emoji := mappingValue(mappingValue(node, "defaults"), "emoji"); emoji != nil && !containsString(EmojiStyles, emoji.Value) {
+		errs = append(errs, ValidationError{Line: emoji.Line, Path: joinPath(prefix, "defaults.emoji"), Message: fmt.Sprintf("invalid value %q (use %s)", emoji.Value, strings.Join(EmojiStyles, ", "))})
+	}
+	if ticket := mappingValue(node, "ticket"); ticket != nil {
+		errs = append(errs, validateTicket(ticket, joinPath(prefix, "ticket"))...)
+	}
+	if ifExists := mappingValue(mappingValue(node, "trailers"), "if_exists"); ifExists != nil && !containsString(TrailerIfExists, ifExists.Value) {
+		errs = append(errs, ValidationError{Line: ifExists.Line, Path: joinPath(prefix, "trailers.if_exists"), Message: fmt.Sprintf("invalid value %q (use %s)", ifExists.Value, strings.Join(TrailerIfExists, ", "))})
+	}
+	for _, path := range nonNegativePaths {
+		section, key, _ := strings.Cut(path, ".")
+		value := mappingValue(mappingValue(node, section), key)
+		if value == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(value.Value); err == nil && n < 0 {
+			errs = append(errs, ValidationError{Line: value.Line, Path: joinPath(prefix, path), Message: "must not be negative"})
+		}
+	}
+	if scopes := mappingValue(node, "scopes"); scopes != nil {
+		errs = append(errs, validateScopes(scopes, joinPath(prefix, "scopes"))...)
+	}
+	if ttl := mappingValue(mappingValue(node, "cache"), "ttl"); ttl != nil {
+		if _, err := ParseTimeout(ttl.Value); err != nil {
+			errs = append(errs, ValidationError{Line: ttl.Line, Path: joinPath(prefix, "cache.ttl"), Message: fmt.Sprintf("invalid duration %q (e.g. 24h, 30m)", ttl.Value)})
+		}
+	}
+	for _, key := range []string{"initial_delay", "max_delay"} {
+		if delay := mappingValue(mappingValue(node, "retry"), key); delay != nil {
+			if _, err := ParseTimeout(delay.Value); err != nil {
+				errs = append(errs, ValidationError{Line: delay.Line, Path: joinPath(prefix, "retry."+key), Message: fmt.Sprintf("invalid duration %q (e.g. 1s, 500ms)", delay.Value)})
+			}
+		}
+	}
+	if pricing := mappingValue(node, "pricing"); pricing != nil {
+		errs = append(errs, validatePricing(pricing, joinPath(prefix, "pricing"))...)
+	}
+	if failOn := mappingValue(mappingValue(node, "review"), "fail_on"); failOn != nil && failOn.Value != ReviewFailOnNone && !containsString(Severities, failOn.Value) {
+		errs = append(errs, ValidationError{Line: failOn.Line, Path: joinPath(prefix, "review.fail_on"), Message: fmt.Sprintf("invalid value %q (use %s, %s)", failOn.Value, ReviewFailOnNone, strings.Join(Severities, ", "))})
+	}
+	if pattern := mappingValue(mappingValue(node, "branch"), "pattern"); pattern != nil && !strings.Contains(pattern.Value, "{slug}") {
+		errs = append(errs, ValidationError{Line: pattern.Line, Path: joinPath(prefix, "branch.pattern"), Message: "must contain {slug}"})
+	}
+	if profiles := mappingValue(node, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := profiles.Content[i].Value
+			errs = append(errs, validateNode(profiles.Content[i+1], joinPath(prefix, "profiles."+name))...)
+		}
+	}
+	return errs
+}
+
+// validateSchemaVersion checks that the schema version is set only at the top level and is supported
+func validateSchemaVersion(node *yaml.Node, prefix string) []ValidationError {
+	path := joinPath(prefix, "schema_version")
+	if prefix != "" {
+		return []ValidationError{{Line: node.Line, Path: path, Message: "schema_version is only allowed at the top level"}}
+	}
+	version, err := strconv.Atoi(node.Value)
+	if err != nil || version < 1 {
+		return []ValidationError{{Line: node.Line, Path: path, Message: fmt.Sprintf("invalid schema version %q", node.Value)}}
+	}
+	if version > CurrentSchemaVersion {
+		return []ValidationError{{Line: node.Line, Path: path, Message: fmt.Sprintf("schema version %d is newer than this release supports (%d)", version, CurrentSchemaVersion)}}
+	}
+	return nil
+}
+
+// validatePromptTemplates checks that every template renders the diff and only uses known placeholders
+func validatePromptTemplates(node *yaml.Node, path string) []ValidationError {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var errs []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		templatePath := path + "." + node.Content[i].Value + ".template"
+		template := mappingValue(node.Content[i+1], "template")
+		if template == nil {
+			continue
+		}
+		if !strings.Contains(template.Value, "{diff}") {
+			errs = append(errs, ValidationError{Line: template.Line, Path: templatePath, Message: "template must contain the {diff} placeholder"})
+		}
+		for _, m := range placeholderPattern.FindAllStringSubmatch(template.Value, -1) {
+			if !knownPlaceholders[m[1]] {
+				errs = append(errs, ValidationError{Line: template.Line, Path: templatePath, Message: fmt.Sprintf("unknown placeholder {%s}", m[1])})
+			}
+		}
+	}
+	return errs
+}
+
+// validatePrefixes checks that prefix types are well-formed and unique and that emojis are valid
+func validatePrefixes(node *yaml.Node, path string) []ValidationError {
+	if node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	var errs []ValidationError
+	seen := map[string]bool{}
+	for i, item := range node.Content {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		typeNode := mappingValue(item, "type")
+		switch {
+		case typeNode == nil || typeNode.Value == "":
+			errs = append(errs, ValidationError{Line: item.Line, Path: itemPath, Message: "type is required"})
+		case !prefixTypePattern.MatchString(typeNode.Value):
+			errs = append(errs, ValidationError{Line: typeNode.Line, Path: itemPath + ".type", Message: fmt.Sprintf("invalid type %q (use lowercase letters, digits, '-' or '_')", typeNode.Value)})
+		default:
+			if seen[typeNode.Value] {
+				errs = append(errs, ValidationError{Line: typeNode.Line, Path: itemPath + ".type", Message: fmt.Sprintf("duplicate type %q", typeNode.Value)})
+			}
+			seen[typeNode.Value] = true
+		}
+
+		if emoji := mappingValue(item, "emoji"); emoji != nil && emoji.Value != "" && !isValidEmoji(emoji.Value) {
+			errs = append(errs, ValidationError{Line: emoji.Line, Path: itemPath + ".emoji", Message: fmt.Sprintf("invalid emoji %q (use a :shortcode: or a single emoji character)", emoji.Value)})
+		}
+	}
+	return errs
+}
+
+// validateTicket checks the ticket placement and that every pattern compiles
+func validateTicket(node *yaml.Node, path string) []ValidationError {
+	var errs []ValidationError
+	if placement := mappingValue(node, "placement"); placement != nil && !containsString(TicketPlacements, placement.Value) {
+		errs = append(errs, ValidationError{Line: placement.Line, Path: path + ".placement", Message: fmt.Sprintf("invalid placement %q (use %s)", placement.Value, strings.Join(TicketPlacements, ", "))})
+	}
+
+	patterns := mappingValue(node, "patterns")
+	if patterns == nil || patterns.Kind != yaml.SequenceNode {
+		return errs
+	}
+	for i, item := range patterns.Content {
+		itemPath := fmt.Sprintf("%s.patterns[%d]", path, i)
+		if name := mappingValue(item, "name"); name == nil || name.Value == "" {
+			errs = append(errs, ValidationError{Line: item.Line, Path: itemPath, Message: "name is required"})
+		}
+		regex := mappingValue(item, "regex")
+		if regex == nil || regex.Value == "" {
+			errs = append(errs, ValidationError{Line: item.Line, Path: itemPath, Message: "regex is required"})
+			continue
+		}
+		if _, err := regexp.Compile(regex.Value); err != nil {
+			errs = append(errs, ValidationError{Line: regex.Line, Path: itemPath + ".regex", Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// validateScopes checks the multi-scope mode and that every rule has a glob and a well-formed scope
+func validateScopes(node *yaml.Node, path string) []ValidationError {
+	var errs []ValidationError
+	if multiple := mappingValue(node, "multiple"); multiple != nil && !containsString(ScopesMultiple, multiple.Value) {
+		errs = append(errs, ValidationError{Line: multiple.Line, Path: path + ".multiple", Message: fmt.Sprintf("invalid value %q (use %s)", multiple.Value, strings.Join(ScopesMultiple, ", "))})
+	}
+
+	rules := mappingValue(node, "rules")
+	if rules == nil || rules.Kind != yaml.SequenceNode {
+		return errs
+	}
+	for i, item := range rules.Content {
+		itemPath := fmt.Sprintf("%s.rules[%d]", path, i)
+		if glob := mappingValue(item, "glob"); glob == nil || glob.Value == "" {
+			errs = append(errs, ValidationError{Line: item.Line, Path: itemPath, Message: "glob is required"})
+		}
+		scope := mappingValue(item, "scope")
+		switch {
+		case scope == nil || scope.Value == "":
+			errs = append(errs, ValidationError{Line: item.Line, Path: itemPath, Message: "scope is required"})
+		case !scopePattern.MatchString(scope.Value):
+			errs = append(errs, ValidationError{Line: scope.Line, Path: itemPath + ".scope", Message: fmt.Sprintf("invalid scope %q (use lowercase letters, digits, '-', '_', '.' or '/')", scope.Value)})
+		}
+	}
+	return errs
+}
+
+// validatePricing checks that every price has input and output prices and none is negative
+func validatePricing(node *yaml.Node, path string) []ValidationError {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	var errs []ValidationError
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		modelPath := fmt.Sprintf("%s.%s", path, node.Content[i].Value)
+		price := node.Content[i+1]
+		for _, key := range []string{"input", "output"} {
+			if mappingValue(price, key) == nil {
+				errs = append(errs, ValidationError{Line: price.Line, Path: modelPath, Message: key + " is required"})
+			}
+		}
+		for _, key := range []string{"input", "output", "cache_write", "cache_read"} {
+			value := mappingValue(price, key)
+			if value == nil {
+				continue
+			}
+			if n, err := strconv.ParseFloat(value.Value, 64); err != nil || n < 0 {
+				errs = append(errs, ValidationError{Line: value.Line, Path: modelPath + "." + key, Message: fmt.Sprintf("invalid price %q (use a non-negative number)", value.Value)})
+			}
+		}
+	}
+	return errs
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidEmoji reports whether value is a :shortcode: or a unicode emoji without ASCII characters
+func isValidEmoji(value string) bool {
+	if shortcodePattern.MatchString(value) {
+		return true
+	}
+	for _, r := range value {
+		if r < 0x80 {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// mappingValue returns the value node stored under key in a mapping node
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDataAcceptsEmbeddedDefault(t *testing.T) {
+	if errs := ValidateData(defaultConfigData); len(errs) > 0 {
+		t.Errorf("Expected the embedded config to be valid, got %v", errs)
+	}
+}
+
+func TestValidateDataReportsProblemsWithLines(t *testing.T) {
+	data := []byte(`prompt_templates:
+  english:
+    template: "Summarize {branch}"
+semantic_release_prefixes:
+  - type: feat
+    emoji: ":sparkles:"
+  - type: feat
+    emoji: "sparkles"
+defaults:
+  providr: claude
+`)
+
+	errs := ValidateData(data)
+
+	expected := []struct {
+		line    int
+		message string
+	}{
+		{3, "{diff}"},
+		{7, "duplicate type"},
+		{8, "invalid emoji"},
+		{10, "field providr not found"},
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, want := range expected {
+		if errs[i].Line != want.line || !strings.Contains(errs[i].Message, want.message) {
+			t.Errorf("Error %d: expected line %d containing %q, got %v", i, want.line, want.message, errs[i])
+		}
+	}
+}
+
+func TestValidateDataChecksProfiles(t *testing.T) {
+	data := []byte(`profiles:
+  oss:
+    prompt_templates:
+      english:
+        template: "{diff} {unknown}"
+`)
+
+	errs := ValidateData(data)
+	if len(errs) != 1 || errs[0].Path != "profiles.oss.prompt_templates.english.template" {
+		t.Errorf("Expected one error for the profile template, got %v", errs)
+	}
+}
+
+func TestValidateDataChecksScopes(t *testing.T) {
+	data := []byte(`scopes:
+  multiple: both
+  max_scopes: -1
+  rules:
+    - glob: "mcp/**"
+      scope: MCP
+    - scope: provider
+`)
+
+	errs := ValidateData(data)
+
+	expected := []string{"max_scopes", "multiple", "rules[0].scope", "rules[1]"}
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for _, want := range expected {
+		found := false
+		for _, err := range errs {
+			if strings.Contains(err.Path, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected an error for %q, got %v", want, errs)
+		}
+	}
+}
+
+func TestValidateDataChecksBranch(t *testing.T) {
+	data := []byte(`branch:
+  pattern: "{type}/{ticket}"
+  max_length: -1
+`)
+
+	errs := ValidateData(data)
+
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Path != "branch.pattern" || errs[1].Path != "branch.max_length" {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
+
+func TestValidateDataChecksReviewFailOn(t *testing.T) {
+	if errs := ValidateData([]byte("review:\n  fail_on: none\n")); len(errs) != 0 {
+		t.Errorf("Expected none to be accepted, got %v", errs)
+	}
+	errs := ValidateData([]byte("review:\n  fail_on: critical\n"))
+	if len(errs) != 1 || errs[0].Path != "review.fail_on" {
+		t.Errorf("Expected an error for review.fail_on, got %v", errs)
+	}
+}
+
+func TestValidateDataChecksRetry(t *testing.T) {
+	errs := ValidateData([]byte("retry:\n  max_attempts: -1\n  initial_delay: soon\n  max_delay: 30s\n"))
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Path != "retry.max_attempts" || errs[1].Path != "retry.initial_delay" {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
+
+func TestValidateDataChecksPricing(t *testing.T) {
+	errs := ValidateData([]byte("pricing:\n  \"*sonnet*\":\n    input: 3\n    output: -15\n  haiku:\n    input: 1\n"))
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %d: %v", len(errs), errs)
+	}
+	if errs[0].Path != "pricing.*sonnet*.output" || errs[1].Path != "pricing.haiku" {
+		t.Errorf("Unexpected errors: %v", errs)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+	"gopkg.in/yaml.v3"
+)
+
+// runConfig dispatches the config subcommands
+func runConfig(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: missing config subcommand (available: show, validate, schema, migrate, infer)")
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "show":
+		runConfigShow(args[1:])
+	case "validate":
+		runConfigValidate(args[1:])
+	case "schema":
+		fmt.Print(string(config.Schema()))
+	case "migrate":
+		runConfigMigrate(args[1:])
+	case "infer":
+		runConfigInfer(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown config subcommand '%s' (available: show, validate, schema, migrate, infer)\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// runConfigShow prints the effective configuration after all layers are merged
+func runConfigShow(args []string) {
+	showFlags := flag.NewFlagSet("config show", flag.ExitOnError)
+	configPath := showFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := showFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	origin := showFlags.Bool("origin", false, "Print where each value came from")
+	showFlags.Parse(args)
+
+	cfg, origins, err := config.LoadLayered(config.LoadOptions{ConfigPath: *configPath, Profile: *profile})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.ActiveProfile != "" {
+		fmt.Printf("# profile: %s\n", cfg.ActiveProfile)
+	}
+
+	if *origin {
+		entries, err := config.DescribeOrigins(cfg, origins)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		for _, entry := range entries {
+			fmt.Printf("%s = %s\t# %s\n", entry.Path, entry.Value, entry.Origin)
+		}
+		return
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to marshal config: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(string(data))
+}
+
+// runConfigValidate validates config files and reports problems with line numbers.
+// Without file arguments, every discovered config file and the merged result are validated.
+func runConfigValidate(args []string) {
+	validateFlags := flag.NewFlagSet("config validate", flag.ExitOnError)
+	configPath := validateFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	validateFlags.Parse(args)
+
+	files := validateFlags.Args()
+	checkMerged := len(files) == 0
+	if checkMerged {
+		files = config.DiscoveredFiles(*configPath)
+	}
+
+	failed := false
+	for _, file := range files {
+		errs, err := config.ValidateFile(file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(errs) == 0 {
+			fmt.Printf("✓ %s is valid\n", file)
+			continue
+		}
+		failed = true
+		for _, e := range errs {
+			location := file
+			if e.Line > 0 {
+				location = fmt.Sprintf("%s:%d", file, e.Line)
+			}
+			if e.Path != "" {
+				fmt.Printf("%s: %s: %s\n", location, e.Path, e.Message)
+			} else {
+				fmt.Printf("%s: %s\n", location, e.Message)
+			}
+		}
+	}
+
+	// The merged config can only be checked when every file decodes
+	if checkMerged && !failed {
+		if _, _, err := config.LoadLayered(config.LoadOptions{ConfigPath: *configPath}); err != nil {
+			fmt.Printf("merged config: %v\n", err)
+			failed = true
+		} else {
+			fmt.Println("✓ merged config is valid")
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// runConfigMigrate upgrades config files to the current schema version.
+// Without file arguments, every discovered config file is migrated.
+func runConfigMigrate(args []string) {
+	migrateFlags := flag.NewFlagSet("config migrate", flag.ExitOnError)
+	configPath := migrateFlags.String("config", "", "Path to config file (migrated along with discovered config files)")
+	dryRun := migrateFlags.Bool("dry-run", false, "Print the changes without writing files")
+	migrateFlags.Parse(args)
+
+	files := migrateFlags.Args()
+	if len(files) == 0 {
+		files = config.DiscoveredFiles(*configPath)
+	}
+	if len(files) == 0 {
+		fmt.Println("No config files found")
+		return
+	}
+
+	for _, file := range files {
+		result, err := config.MigrateFile(file, *dryRun)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !result.Changed() {
+			fmt.Printf("✓ %s is up to date (schema version %d)\n", file, result.ToVersion)
+			continue
+		}
+
+		fmt.Printf("%s: schema version %d -> %d\n", file, result.FromVersion, result.ToVersion)
+		for _, change := range result.Changes {
+			fmt.Printf("  - %s\n", change)
+		}
+		if *dryRun {
+			if len(result.Removed) > 0 {
+				fmt.Fprintf(os.Stderr, "Warning: migrating would drop the settings of unknown keys: %s\n", strings.Join(result.Removed, ", "))
+			}
+			fmt.Println()
+			fmt.Print(string(result.Data))
+			continue
+		}
+		fmt.Printf("✓ %s migrated (backup: %s.bak)\n", file, file)
+		if len(result.Removed) > 0 {
+			fmt.Fprintf(os.Stderr, "Warning: dropped the settings of unknown keys: %s (they are kept in %s.bak)\n", strings.Join(result.Removed, ", "), file)
+		}
+	}
+}
+
+// runConfigInfer prints the commit convention inferred from the repository history
+func runConfigInfer(args []string) {
+	inferFlags := flag.NewFlagSet("config infer", flag.ExitOnError)
+	limit := inferFlags.Int("limit", 50, "Number of recent commits to analyze")
+	author := inferFlags.String("author", "", "Only analyze commits by this author")
+	inferFlags.Parse(args)
+
+	messages, err := git.GetRecentCommitMessages(git.LogOptions{Limit: *limit, Author: *author, Paths: inferFlags.Args()})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commit history: %v\n", err)
+		os.Exit(1)
+	}
+	if len(messages) == 0 {
+		fmt.Println("No commits found")
+		return
+	}
+
+	style := message.InferStyle(messages)
+	fmt.Printf("Analyzed %d commits\n", style.Samples)
+	fmt.Printf("  language:       %s\n", style.Language)
+	fmt.Printf("  type prefixes:  %.0f%% of subjects", style.PrefixShare*100)
+	if len(style.Types) > 0 {
+		fmt.Printf(" (%s)", strings.Join(style.Types, ", "))
+	}
+	fmt.Println()
+	fmt.Printf("  emoji:          %s\n", style.Emoji)
+	fmt.Printf("  summary length: %d characters (90th percentile)\n", style.SubjectLength)
+	fmt.Println()
+	fmt.Printf("Suggested: init --preset=%s --interactive, or set style.infer: true to apply this automatically\n", style.Preset())
+}
@@ -2,9 +2,12 @@ package copilotcli
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
@@ -12,11 +15,21 @@ import (
 
 // Client represents a Copilot CLI client
 type Client struct {
-	model string
+	model     string
+	extraArgs []string
+	timeout   time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for english
+	language string
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable and Localizable interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+)
 
 // NewClient creates a new Copilot CLI client
 func NewClient(model string) (*Client, error) {
@@ -30,47 +43,42 @@ func NewClient(model string) (*Client, error) {
 		model = "claude-sonnet-4.5"
 	}
 
+	// Apply provider settings from config
+	cfg := appconfig.Get()
+
 	return &Client{
-		model: model,
+		model:     model,
+		extraArgs: cfg.Provider("copilotcli").ExtraArgs,
+		timeout:   cfg.Timeout(0),
 	}, nil
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
-	// Get config and build prompt (English version)
+	// Get config and build prompt (English unless another language is configured)
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPromptEnglish(branch, diff)
-
-	// Execute copilot command with -p flag for prompt and --model for model specification
-	cmd := exec.Command("copilot", "-p", prompt, "--model", c.model)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	prompt := cfg.BuildPrompt(cfg.PromptLanguage(cmp.Or(c.language, "english")), branch, diff)
 
-	err := cmd.Run()
+	response, err := c.Complete(prompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute copilot command: %w\nstderr: %s", err, stderr.String())
-	}
-
-	response := strings.TrimSpace(stdout.String())
-	if response == "" {
-		return "", fmt.Errorf("empty response from copilot command")
-	}
-
-	// Remove usage statistics from the end first
-	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
-		response = response[:idx]
-	}
-	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
-		response = response[:idx]
+		return "", err
 	}
 
-	// Remove leading bullet point (●) that Copilot CLI often adds
-	response = strings.TrimPrefix(response, "●")
-	response = strings.TrimPrefix(response, "● ")
-	response = strings.TrimSpace(response)
-
 	// Get list of Semantic Release prefixes from config
 	prefixes := cfg.GetPrefixList()
 
@@ -101,3 +109,47 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	// Fallback: return the whole response (without usage stats)
 	return response, nil
 }
+
+// Complete sends a prompt to the model and returns its cleaned-up response
+func (c *Client) Complete(prompt string) (string, error) {
+	// Bound the command by the configured timeout if any
+	ctx := client.OrBackground(c.ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// Execute copilot command with -p flag for prompt and --model for model specification
+	args := append([]string{"-p", prompt, "--model", c.model}, c.extraArgs...)
+	cmd := exec.CommandContext(ctx, "copilot", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return "", client.CommandError(ctx, "copilotcli", "copilot", err, stderr.String())
+	}
+
+	response := strings.TrimSpace(stdout.String())
+	if response == "" {
+		return "", client.RetryableError("copilotcli", fmt.Errorf("empty response from copilot command"), 0)
+	}
+
+	// Remove usage statistics from the end first
+	if idx := strings.Index(response, "\n\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+	if idx := strings.Index(response, "\nTotal usage"); idx > 0 {
+		response = response[:idx]
+	}
+
+	// Remove leading bullet point (●) that Copilot CLI often adds
+	response = strings.TrimPrefix(response, "●")
+	response = strings.TrimPrefix(response, "● ")
+	response = strings.TrimSpace(response)
+
+	return response, nil
+}
@@ -1,6 +1,8 @@
 package copilotsdk
 
 import (
+	"cmp"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
@@ -16,11 +18,20 @@ import (
 // for programmatic access to Copilot CLI, providing session management
 // and streaming capabilities.
 type Client struct {
-	model string
+	model   string
+	timeout time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for english
+	language string
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable and Localizable interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+)
 
 // NewClient creates a new Copilot SDK client.
 // Unlike copilotcli which executes the CLI directly, copilotsdk uses
@@ -38,49 +49,105 @@ func NewClient(model string) (*Client, error) {
 
 	return &Client{
 		model: model,
+		// Timeout defaults to 120 seconds to allow for complex diffs and model processing time
+		timeout: appconfig.Get().Timeout(120 * time.Second),
 	}, nil
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
 	// Get config and build prompt
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPromptEnglish(branch, diff)
+	prompt := cfg.BuildPrompt(cfg.PromptLanguage(cmp.Or(c.language, "english")), branch, diff)
+
+	responseText, err := c.Complete(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	// Get list of Semantic Release prefixes from config
+	prefixes := cfg.GetPrefixList()
+
+	// Try to find the first line that starts with a Semantic Release prefix
+	lines := strings.Split(responseText, "\n")
+	startIdx := -1
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(trimmed, prefix) {
+				startIdx = i
+				break
+			}
+		}
+		if startIdx >= 0 {
+			break
+		}
+	}
 
+	// If we found a line starting with a prefix, extract from there
+	if startIdx >= 0 {
+		commitLines := lines[startIdx:]
+		commitMessage := strings.Join(commitLines, "\n")
+		return strings.TrimSpace(commitMessage), nil
+	}
+
+	// Fallback: return the whole response
+	return responseText, nil
+}
+
+// Complete sends a prompt to the model and returns its cleaned-up response
+func (c *Client) Complete(prompt string) (string, error) {
 	// Create Copilot client
 	copilotClient := copilot.NewClient(nil)
 
 	// Start the client (this starts the Copilot CLI server)
 	if err := copilotClient.Start(); err != nil {
-		return "", fmt.Errorf("failed to start copilot client: %w", err)
+		return "", client.ClassifyMessage("copilotsdk", fmt.Errorf("failed to start copilot client: %w", err), "")
 	}
 	defer copilotClient.Stop()
+	// The SDK takes no context, so a cancelled request stops the server instead
+	defer context.AfterFunc(client.OrBackground(c.ctx), copilotClient.ForceStop)()
 
 	// Create a session with the specified model
 	session, err := copilotClient.CreateSession(&copilot.SessionConfig{
 		Model: c.model,
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create session: %w", err)
+		return "", client.ClassifyMessage("copilotsdk", fmt.Errorf("failed to create session: %w", err), "")
 	}
 	defer session.Destroy()
 
 	// Send the prompt and wait for the response
-	// Timeout is set to 120 seconds to allow for complex diffs and model processing time
 	response, err := session.SendAndWait(copilot.MessageOptions{
 		Prompt: prompt,
-	}, 120*time.Second)
+	}, c.timeout)
 	if err != nil {
-		return "", fmt.Errorf("failed to send message: %w", err)
+		return "", client.ClassifyMessage("copilotsdk", fmt.Errorf("failed to send message: %w", err), "")
 	}
 
 	if response == nil || response.Data.Content == nil {
-		return "", fmt.Errorf("empty response from copilot SDK")
+		return "", client.RetryableError("copilotsdk", fmt.Errorf("empty response from copilot SDK"), 0)
 	}
 
 	responseText := strings.TrimSpace(*response.Data.Content)
 	if responseText == "" {
-		return "", fmt.Errorf("empty response from copilot SDK")
+		return "", client.RetryableError("copilotsdk", fmt.Errorf("empty response from copilot SDK"), 0)
 	}
 
 	// Remove leading bullet point (●) that Copilot sometimes adds
@@ -88,33 +155,5 @@ func (c *Client) GenerateCommitMessage(diff string, branch string) (string, erro
 	responseText = strings.TrimPrefix(responseText, "● ")
 	responseText = strings.TrimSpace(responseText)
 
-	// Get list of Semantic Release prefixes from config
-	prefixes := cfg.GetPrefixList()
-
-	// Try to find the first line that starts with a Semantic Release prefix
-	lines := strings.Split(responseText, "\n")
-	startIdx := -1
-
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		for _, prefix := range prefixes {
-			if strings.HasPrefix(trimmed, prefix) {
-				startIdx = i
-				break
-			}
-		}
-		if startIdx >= 0 {
-			break
-		}
-	}
-
-	// If we found a line starting with a prefix, extract from there
-	if startIdx >= 0 {
-		commitLines := lines[startIdx:]
-		commitMessage := strings.Join(commitLines, "\n")
-		return strings.TrimSpace(commitMessage), nil
-	}
-
-	// Fallback: return the whole response
 	return responseText, nil
 }
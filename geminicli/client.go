@@ -2,9 +2,12 @@ package geminicli
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	appconfig "github.com/UNILORN/generative-commit-message-for-ai-tool/config"
@@ -12,11 +15,21 @@ import (
 
 // Client represents a Gemini CLI client
 type Client struct {
-	model string
+	model     string
+	extraArgs []string
+	timeout   time.Duration
+	// ctx cancels requests; nil means they are never cancelled
+	ctx context.Context
+	// language is the prompt language when defaults.language is not set; empty for japanese
+	language string
 }
 
-// Ensure Client implements the AIClient interface
-var _ client.AIClient = (*Client)(nil)
+// Ensure Client implements the AIClient, Cancelable and Localizable interfaces
+var (
+	_ client.AIClient    = (*Client)(nil)
+	_ client.Cancelable  = (*Client)(nil)
+	_ client.Localizable = (*Client)(nil)
+)
 
 // NewClient creates a new Gemini CLI client
 func NewClient(model string) (*Client, error) {
@@ -30,33 +43,66 @@ func NewClient(model string) (*Client, error) {
 		model = "gemini-2.5-pro"
 	}
 
+	// Apply provider settings from config
+	cfg := appconfig.Get()
+
 	return &Client{
-		model: model,
+		model:     model,
+		extraArgs: cfg.Provider("geminicli").ExtraArgs,
+		timeout:   cfg.Timeout(0),
 	}, nil
 }
 
+// WithContext returns a copy of the client whose requests are cancelled with ctx
+func (c *Client) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// WithLanguage returns a copy of the client whose prompts are written in lang unless
+// defaults.language is set
+func (c *Client) WithLanguage(lang string) client.AIClient {
+	copied := *c
+	copied.language = lang
+	return &copied
+}
+
 // GenerateCommitMessage generates a commit message based on the provided diff
 func (c *Client) GenerateCommitMessage(diff string, branch string) (string, error) {
 	// Get config and build prompt
 	cfg := appconfig.Get()
-	prompt := cfg.BuildPrompt("japanese", branch, diff)
+	return c.Complete(cfg.BuildPrompt(cfg.PromptLanguage(cmp.Or(c.language, "japanese")), branch, diff))
+}
+
+// Complete sends a prompt to the model and returns its response
+func (c *Client) Complete(prompt string) (string, error) {
+	// Bound the command by the configured timeout if any
+	ctx := client.OrBackground(c.ctx)
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
 
 	// Execute gemini command
-	cmd := exec.Command("gemini", "--model", c.model, "--prompt", prompt)
-	
+	args := append([]string{"--model", c.model}, c.extraArgs...)
+	args = append(args, "--prompt", prompt)
+	cmd := exec.CommandContext(ctx, "gemini", args...)
+
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
 	err := cmd.Run()
 	if err != nil {
-		return "", fmt.Errorf("failed to execute gemini command: %w\nstderr: %s", err, stderr.String())
+		return "", client.CommandError(ctx, "geminicli", "gemini", err, stderr.String())
 	}
 
 	response := strings.TrimSpace(stdout.String())
 	if response == "" {
-		return "", fmt.Errorf("empty response from gemini command")
+		return "", client.RetryableError("geminicli", fmt.Errorf("empty response from gemini command"), 0)
 	}
 
 	return response, nil
-}
\ No newline at end of file
+}
@@ -2,6 +2,7 @@ package git
 
 import (
 	"bytes"
+	"fmt"
 	"os/exec"
 	"strings"
 )
@@ -21,3 +22,18 @@ func GetCurrentBranch() (string, error) {
 	}
 	return strings.TrimSpace(out.String()), nil
 }
+
+// CheckBranchName returns the normalized name if it is a valid branch name
+func CheckBranchName(name string) (string, error) {
+	output, err := runGit("", nil, "check-ref-format", "--branch", name)
+	if err != nil {
+		return "", fmt.Errorf("invalid branch name %q", name)
+	}
+	return output, nil
+}
+
+// SwitchCreate creates a branch at HEAD and switches to it, carrying over local changes
+func SwitchCreate(name string) error {
+	_, err := runGit("", nil, "switch", "--create", name)
+	return err
+}
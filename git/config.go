@@ -0,0 +1,65 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// GetConfigValue returns the value of a git config key.
+// An empty string is returned when the key is not set.
+func GetConfigValue(key string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "config", "--get", key)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		// git config exits with status 1 when the key is not set
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetRepoRoot returns the absolute path of the top-level directory of the current repository
+func GetRepoRoot() (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetRemoteURL returns the URL of the named remote
+func GetRemoteURL(remote string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "remote", "get-url", remote)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
@@ -70,3 +70,20 @@ func GetStagedFilesWithStatus() (string, error) {
 
 	return strings.TrimSpace(out.String()), nil
 }
+
+// GetStagedStat returns the diffstat (git diff --staged --stat) of the staged changes
+func GetStagedStat() (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "diff", "--staged", "--stat")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(out.String(), "\n"), nil
+}
@@ -0,0 +1,125 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// LogOptions filters the commits returned by GetRecentCommitMessages
+type LogOptions struct {
+	// Limit is the maximum number of commits
+	Limit int
+	// Author restricts the commits to an author (git log --author pattern)
+	Author string
+	// Paths restricts the commits to those touching any of the paths
+	Paths []string
+	// Range restricts the commits to a revision range such as base..HEAD
+	Range string
+	// Reverse lists the oldest commit first
+	Reverse bool
+}
+
+// GetRecentCommitMessages returns the full messages of recent non-merge commits, newest first unless opts.Reverse is set
+func GetRecentCommitMessages(opts LogOptions) ([]string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+
+	args := logArgs("%B%x1e", opts)
+
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var messages []string
+	for _, entry := range strings.Split(out.String(), "\x1e") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			messages = append(messages, entry)
+		}
+	}
+	return messages, nil
+}
+
+// Commit is a commit hash and its full message
+type Commit struct {
+	Hash    string
+	Message string
+}
+
+// GetCommits returns the short hashes and full messages of non-merge commits, newest first unless opts.Reverse is set
+func GetCommits(opts LogOptions) ([]Commit, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+
+	args := logArgs("%h%x1f%B%x1e", opts)
+
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, entry := range strings.Split(out.String(), "\x1e") {
+		hash, msg, ok := strings.Cut(strings.TrimSpace(entry), "\x1f")
+		if !ok {
+			continue
+		}
+		commits = append(commits, Commit{Hash: hash, Message: strings.TrimSpace(msg)})
+	}
+	return commits, nil
+}
+
+// GetRecentCommitSubjects returns "<short hash> <subject>" lines of recent non-merge commits, newest first unless opts.Reverse is set
+func GetRecentCommitSubjects(opts LogOptions) ([]string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, err
+	}
+
+	args := logArgs("%h %s", opts)
+
+	cmd := exec.Command("git", args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+
+	output := strings.TrimSpace(out.String())
+	if output == "" {
+		return nil, nil
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// logArgs builds the git log arguments for a format and the filters of opts
+func logArgs(format string, opts LogOptions) []string {
+	args := []string{"log", "--no-merges", "--format=" + format}
+	if opts.Limit > 0 {
+		args = append(args, fmt.Sprintf("--max-count=%d", opts.Limit))
+	}
+	if opts.Reverse {
+		args = append(args, "--reverse")
+	}
+	if opts.Author != "" {
+		args = append(args, "--author="+opts.Author)
+	}
+	if opts.Range != "" {
+		args = append(args, opts.Range)
+	}
+	if len(opts.Paths) > 0 {
+		args = append(args, "--")
+		args = append(args, opts.Paths...)
+	}
+	return args
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// GetMergeBase returns the best common ancestor of base and HEAD
+func GetMergeBase(base string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "merge-base", base, "HEAD")
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("no merge base with %s: %s", base, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetRangeDiff returns the diff between two revisions
+func GetRangeDiff(from, to string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "diff", from, to)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetRangeStat returns the diffstat between two revisions
+func GetRangeStat(from, to string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "diff", "--stat", from, to)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(out.String(), "\n"), nil
+}
+
+// DescribeTag returns the most recent tag reachable from ref, or only a tag pointing at ref
+// itself when exact is set. An empty string is returned when there is no such tag.
+func DescribeTag(ref string, exact bool) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	if exact {
+		args = append(args, "--exact-match")
+	}
+	cmd := exec.Command("git", append(args, ref)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		// git describe exits with status 128 when no tag matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 128 {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetCommitDate returns the committer date of ref as YYYY-MM-DD
+func GetCommitDate(ref string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%cs", ref)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(out.String()), nil
+}
+
+// GetRangeFiles returns the files changed between two revisions
+func GetRangeFiles(from, to string) ([]string, error) {
+	output, err := runGit("", nil, "diff", "--name-only", from, to)
+	if err != nil || output == "" {
+		return []string{}, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// GetRangeFilesWithStatus returns the files changed between two revisions with their status
+func GetRangeFilesWithStatus(from, to string) (string, error) {
+	return runGit("", nil, "diff", "--name-status", from, to)
+}
@@ -0,0 +1,192 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Author is the author of a commit as recorded by git
+type Author struct {
+	Name  string
+	Email string
+	// Date is in git's raw format ("<unix seconds> <zone>")
+	Date string
+}
+
+// runGit runs a git command with optional stdin and extra environment and returns its trimmed output
+func runGit(stdin string, env []string, args ...string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("git", args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// ResolveCommit returns the full hash of the commit rev points to
+func ResolveCommit(rev string) (string, error) {
+	return runGit("", nil, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// RevList returns the full hashes of git rev-list with the given arguments, oldest first
+func RevList(args ...string) ([]string, error) {
+	output, err := runGit("", nil, append([]string{"rev-list", "--reverse"}, args...)...)
+	if err != nil || output == "" {
+		return nil, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// GetCommitMessage returns the full message of a commit
+func GetCommitMessage(hash string) (string, error) {
+	return runGit("", nil, "log", "-1", "--format=%B", hash)
+}
+
+// GetCommitDiff returns the diff a commit introduces
+func GetCommitDiff(hash string) (string, error) {
+	return runGit("", nil, "show", "--format=", "--patch", hash)
+}
+
+// GetCommitFiles returns the files a commit changes
+func GetCommitFiles(hash string) ([]string, error) {
+	output, err := runGit("", nil, "show", "--format=", "--name-only", hash)
+	if err != nil || output == "" {
+		return []string{}, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// GetCommitFilesWithStatus returns the files a commit changes with their status
+func GetCommitFilesWithStatus(hash string) (string, error) {
+	return runGit("", nil, "show", "--format=", "--name-status", hash)
+}
+
+// GetCommitAuthor returns the author of a commit
+func GetCommitAuthor(hash string) (Author, error) {
+	output, err := runGit("", nil, "log", "-1", "--date=raw", "--format=%an%x00%ae%x00%ad", hash)
+	if err != nil {
+		return Author{}, err
+	}
+	parts := strings.SplitN(output, "\x00", 3)
+	if len(parts) != 3 {
+		return Author{}, fmt.Errorf("unexpected author of %s: %q", hash, output)
+	}
+	return Author{Name: parts[0], Email: parts[1], Date: parts[2]}, nil
+}
+
+// RemoteBranchesContaining returns the remote-tracking branches that contain a commit
+func RemoteBranchesContaining(hash string) ([]string, error) {
+	output, err := runGit("", nil, "branch", "--remotes", "--contains", hash, "--format=%(refname:short)")
+	if err != nil || output == "" {
+		return nil, err
+	}
+	return strings.Split(output, "\n"), nil
+}
+
+// UpdateRef points ref at newHash, but only if it still points at oldHash (when given)
+func UpdateRef(ref, newHash, oldHash, reason string) error {
+	args := []string{"update-ref", "-m", reason, ref, newHash}
+	if oldHash != "" {
+		args = append(args, oldHash)
+	}
+	_, err := runGit("", nil, args...)
+	return err
+}
+
+// RewriteMessages recreates the commits after base up to HEAD on top of base, replacing the
+// messages of the commits in messages (keyed by full hash). Trees and authors are kept, so
+// the working tree does not change. The current branch is moved to the new history only if it
+// still points at the old HEAD; the new HEAD is returned.
+func RewriteMessages(base string, messages map[string]string) (string, error) {
+	branch, err := GetCurrentBranch()
+	if err != nil {
+		return "", fmt.Errorf("HEAD is not on a branch: %w", err)
+	}
+	head, err := ResolveCommit("HEAD")
+	if err != nil {
+		return "", err
+	}
+	merges, err := RevList("--min-parents=2", base+"..HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(merges) > 0 {
+		return "", fmt.Errorf("cannot rewrite history containing merge commits (%s)", merges[0][:7])
+	}
+	commits, err := RevList(base + "..HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	parent := base
+	for _, hash := range commits {
+		msg, ok := messages[hash]
+		if !ok {
+			if msg, err = GetCommitMessage(hash); err != nil {
+				return "", err
+			}
+		}
+		author, err := GetCommitAuthor(hash)
+		if err != nil {
+			return "", err
+		}
+		env := []string{
+			"GIT_AUTHOR_NAME=" + author.Name,
+			"GIT_AUTHOR_EMAIL=" + author.Email,
+			"GIT_AUTHOR_DATE=" + author.Date,
+		}
+		if parent, err = runGit(msg+"\n", env, "commit-tree", hash+"^{tree}", "-p", parent, "-F", "-"); err != nil {
+			return "", err
+		}
+	}
+
+	if err := UpdateRef("refs/heads/"+branch, parent, head, "reword: rewrite commit messages"); err != nil {
+		return "", err
+	}
+	return parent, nil
+}
+
+// ResetSoft moves the current branch to rev, keeping the index and the working tree
+func ResetSoft(rev string) error {
+	_, err := runGit("", nil, "reset", "--soft", rev)
+	return err
+}
+
+// CommitIndex commits the index with msg; the repository's commit hooks run as usual
+func CommitIndex(msg string) error {
+	_, err := runGit(msg+"\n", nil, "commit", "--quiet", "--file=-")
+	return err
+}
+
+// GetRangeAuthors returns the distinct authors ("Name <email>") of the commits in a range, oldest first
+func GetRangeAuthors(revRange string) ([]string, error) {
+	output, err := runGit("", nil, "log", "--reverse", "--format=%an <%ae>", revRange)
+	if err != nil || output == "" {
+		return nil, err
+	}
+	var authors []string
+	seen := map[string]bool{}
+	for _, author := range strings.Split(output, "\n") {
+		if !seen[author] {
+			seen[author] = true
+			authors = append(authors, author)
+		}
+	}
+	return authors, nil
+}
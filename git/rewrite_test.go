@@ -0,0 +1,65 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// commitFile creates, stages and commits a file in the current directory
+func commitFile(t *testing.T, filename, content, msg string) {
+	if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	for _, args := range [][]string{{"add", filename}, {"commit", "-q", "-m", msg}} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %v: %s", args, err, out)
+		}
+	}
+}
+
+func TestRewriteMessages(t *testing.T) {
+	repoDir := setupGitRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	defer os.Chdir(currentDir)
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	commitFile(t, "a.txt", "a\n", "initial")
+	commitFile(t, "b.txt", "b\n", "wip")
+	commitFile(t, "c.txt", "c\n", "fix")
+	base, err := ResolveCommit("HEAD~2")
+	if err != nil {
+		t.Fatalf("ResolveCommit failed: %v", err)
+	}
+	commits, err := RevList(base + "..HEAD")
+	if err != nil || len(commits) != 2 {
+		t.Fatalf("RevList = %v, %v", commits, err)
+	}
+	oldTree, _ := runGit("", nil, "rev-parse", "HEAD^{tree}")
+
+	head, err := RewriteMessages(base, map[string]string{commits[0]: "feat: add b"})
+	if err != nil {
+		t.Fatalf("RewriteMessages failed: %v", err)
+	}
+	if current, _ := ResolveCommit("HEAD"); current != head {
+		t.Errorf("HEAD = %s, want %s", current, head)
+	}
+	if tree, _ := runGit("", nil, "rev-parse", "HEAD^{tree}"); tree != oldTree {
+		t.Errorf("tree changed from %s to %s", oldTree, tree)
+	}
+	for rev, want := range map[string]string{"HEAD~1": "feat: add b", "HEAD": "fix", "HEAD~2": "initial"} {
+		if msg, _ := GetCommitMessage(rev); msg != want {
+			t.Errorf("message of %s = %q, want %q", rev, msg, want)
+		}
+	}
+	if author, _ := GetCommitAuthor("HEAD~1"); author.Name != "Test User" {
+		t.Errorf("author = %+v", author)
+	}
+}
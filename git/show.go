@@ -0,0 +1,31 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+)
+
+// GetFileAtRevision returns the content of a file (relative to the repository root) at a revision.
+// An empty revision reads the staged version from the index. The boolean is false when the file
+// does not exist at that revision.
+func GetFileAtRevision(rev, path string) (string, bool, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", false, err
+	}
+
+	cmd := exec.Command("git", "cat-file", "blob", rev+":"+path)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		// git cat-file exits with status 128 when the object does not exist
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 128 {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return out.String(), true, nil
+}
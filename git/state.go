@@ -0,0 +1,109 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Kinds of operations in progress whose commit is not an ordinary change
+const (
+	OperationMerge      = "merge"
+	OperationRevert     = "revert"
+	OperationCherryPick = "cherry-pick"
+)
+
+// operationHeads maps the state files git leaves in the git directory to their operation
+var operationHeads = []struct {
+	file string
+	kind string
+}{
+	{"MERGE_HEAD", OperationMerge},
+	{"REVERT_HEAD", OperationRevert},
+	{"CHERRY_PICK_HEAD", OperationCherryPick},
+}
+
+// mergeBranchPattern captures the branch name of git's default merge message
+var mergeBranchPattern = regexp.MustCompile(`^Merge (?:remote-tracking )?branch(?:es)? '([^']+)'`)
+
+// Operation is a merge, revert or cherry-pick waiting to be committed
+type Operation struct {
+	Kind string
+	// Hash is the full hash of the merged, reverted or cherry-picked commit
+	Hash string
+	// Subject is the summary line of that commit
+	Subject string
+	// Branch is the name of the merged branch, if git's merge message has it
+	Branch string
+	// Conflicts lists the paths whose conflicts were resolved
+	Conflicts []string
+	// Incoming lists the summary lines of the commits a merge brings in, oldest first
+	Incoming []string
+}
+
+// GetOperation returns the merge, revert or cherry-pick in progress, or nil if there is none
+func GetOperation() (*Operation, error) {
+	for _, head := range operationHeads {
+		path, err := runGit("", nil, "rev-parse", "--git-path", head.file)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// MERGE_HEAD lists one commit per merged branch; the first one is described
+		hash, _, _ := strings.Cut(strings.TrimSpace(string(data)), "\n")
+		op := &Operation{Kind: head.kind, Hash: hash}
+		if op.Subject, err = runGit("", nil, "log", "-1", "--format=%s", hash); err != nil {
+			return nil, err
+		}
+
+		msgPath, err := runGit("", nil, "rev-parse", "--git-path", "MERGE_MSG")
+		if err != nil {
+			return nil, err
+		}
+		if msg, err := os.ReadFile(msgPath); err == nil {
+			op.Conflicts = ParseConflicts(string(msg))
+			if m := mergeBranchPattern.FindStringSubmatch(string(msg)); m != nil {
+				op.Branch = m[1]
+			}
+		}
+
+		if op.Kind == OperationMerge {
+			output, err := runGit("", nil, "log", "--reverse", "--no-merges", "--format=%s", "HEAD.."+hash)
+			if err != nil {
+				return nil, err
+			}
+			if output != "" {
+				op.Incoming = strings.Split(output, "\n")
+			}
+		}
+		return op, nil
+	}
+	return nil, nil
+}
+
+// ParseConflicts returns the paths listed under "Conflicts:" in a MERGE_MSG, in the commented
+// form of current git ("# Conflicts:" followed by "#\tpath") and the plain form of older ones
+func ParseConflicts(msg string) []string {
+	var conflicts []string
+	inList := false
+	for _, line := range strings.Split(msg, "\n") {
+		trimmed := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		switch {
+		case trimmed == "Conflicts:":
+			inList = true
+		case inList && (strings.HasPrefix(line, "#\t") || strings.HasPrefix(line, "\t")):
+			conflicts = append(conflicts, trimmed)
+		case inList:
+			inList = false
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		want []string
+	}{
+		{
+			name: "commented",
+			msg:  "Merge branch 'feature'\n\n# Conflicts:\n#\ta.txt\n#\tdir/b.go\n#\n# It looks like you may be committing a merge.\n",
+			want: []string{"a.txt", "dir/b.go"},
+		},
+		{
+			name: "plain",
+			msg:  "Merge branch 'feature'\n\nConflicts:\n\ta.txt\n",
+			want: []string{"a.txt"},
+		},
+		{
+			name: "none",
+			msg:  "Revert \"feat: add login\"\n\nThis reverts commit abc.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseConflicts(tt.msg); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseConflicts() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetOperationMerge(t *testing.T) {
+	repoDir := setupGitRepo(t)
+	defer os.RemoveAll(repoDir)
+
+	currentDir, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current directory: %v", err)
+	}
+	defer os.Chdir(currentDir)
+	if err := os.Chdir(repoDir); err != nil {
+		t.Fatalf("Failed to change directory: %v", err)
+	}
+
+	commitFile(t, "a.txt", "a\n", "initial")
+	if out, err := exec.Command("git", "checkout", "-q", "-b", "feature").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %v: %s", err, out)
+	}
+	commitFile(t, "b.txt", "b\n", "feat: add b")
+	if out, err := exec.Command("git", "checkout", "-q", "-").CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %v: %s", err, out)
+	}
+
+	if op, err := GetOperation(); err != nil || op != nil {
+		t.Fatalf("GetOperation() = %+v, %v before merging", op, err)
+	}
+
+	commitFile(t, "c.txt", "c\n", "chore: add c")
+	if out, err := exec.Command("git", "merge", "--no-commit", "--no-ff", "feature").CombinedOutput(); err != nil {
+		t.Fatalf("git merge failed: %v: %s", err, out)
+	}
+	op, err := GetOperation()
+	if err != nil || op == nil {
+		t.Fatalf("GetOperation() = %+v, %v", op, err)
+	}
+	if op.Kind != OperationMerge || op.Branch != "feature" || op.Subject != "feat: add b" {
+		t.Errorf("unexpected operation %+v", op)
+	}
+	if !reflect.DeepEqual(op.Incoming, []string{"feat: add b"}) {
+		t.Errorf("Incoming = %q", op.Incoming)
+	}
+}
@@ -0,0 +1,68 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// hashFooterPattern matches footers that use '#' as separator, e.g. "Closes #42"
+var hashFooterPattern = regexp.MustCompile(`(?m)^([A-Za-z][A-Za-z0-9-]*) #`)
+
+// InterpretTrailers adds trailers ("Key: value" or "Key #value") to msg with git interpret-trailers.
+// Existing trailers are merged according to ifExists (e.g. addIfDifferent).
+func InterpretTrailers(msg string, trailers []string, ifExists string) (string, error) {
+	// Check if git is installed
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", err
+	}
+
+	// Accept '#' as a separator and keep it for the keys that use it (e.g. "Closes #42"),
+	// otherwise git rewrites them as "Closes: 42"
+	args := []string{"-c", "trailer.separators=:#"}
+	keys := map[string]bool{}
+	for _, m := range hashFooterPattern.FindAllStringSubmatch(msg+"\n"+strings.Join(trailers, "\n"), -1) {
+		key := strings.ToLower(m[1])
+		if !keys[key] {
+			keys[key] = true
+			args = append(args, "-c", fmt.Sprintf("trailer.%s.key=%s #", key, m[1]))
+		}
+	}
+
+	args = append(args, "interpret-trailers", "--no-divider")
+	if ifExists != "" {
+		args = append(args, "--if-exists", ifExists)
+	}
+	for _, trailer := range trailers {
+		args = append(args, "--trailer", trailer)
+	}
+
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(strings.TrimRight(msg, "\n") + "\n")
+	var out, stderr bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git interpret-trailers failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(out.String(), "\n"), nil
+}
+
+// GetUserIdentity returns the git identity as "Name <email>"
+func GetUserIdentity() (string, error) {
+	name, err := GetConfigValue("user.name")
+	if err != nil {
+		return "", err
+	}
+	email, err := GetConfigValue("user.email")
+	if err != nil {
+		return "", err
+	}
+	if name == "" || email == "" {
+		return "", fmt.Errorf("git user.name and user.email must be set")
+	}
+	return fmt.Sprintf("%s <%s>", name, email), nil
+}
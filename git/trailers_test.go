@@ -0,0 +1,41 @@
+package git
+
+import "testing"
+
+func TestInterpretTrailersMergesExistingTrailers(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		trailers []string
+		want     string
+	}{
+		{
+			name:     "new block",
+			msg:      "feat: add login\n\n- detail: with colon\n",
+			trailers: []string{"Signed-off-by: A <a@example.com>"},
+			want:     "feat: add login\n\n- detail: with colon\n\nSigned-off-by: A <a@example.com>",
+		},
+		{
+			name:     "keeps hash footers",
+			msg:      "fix: crash\n\nCloses #42",
+			trailers: []string{"Closes #42", "Signed-off-by: A <a@example.com>"},
+			want:     "fix: crash\n\nCloses #42\nSigned-off-by: A <a@example.com>",
+		},
+		{
+			name:     "no duplicates",
+			msg:      "fix: crash\n\nRefs: ABC-1\nSigned-off-by: A <a@example.com>",
+			trailers: []string{"Refs: ABC-1", "Signed-off-by: A <a@example.com>"},
+			want:     "fix: crash\n\nRefs: ABC-1\nSigned-off-by: A <a@example.com>",
+		},
+	}
+
+	for _, tt := range tests {
+		got, err := InterpretTrailers(tt.msg, tt.trailers, "addIfDifferent")
+		if err != nil {
+			t.Fatalf("%s: InterpretTrailers failed: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
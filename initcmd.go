@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/provider"
+)
+
+func runInit(args []string) {
+	initFlags := flag.NewFlagSet("init", flag.ExitOnError)
+	fileShort := initFlags.String("f", "./prompt.yaml", "Output file path")
+	fileLong := initFlags.String("file", "", "Output file path")
+	force := initFlags.Bool("force", false, "Overwrite existing file")
+	preset := initFlags.String("preset", "", "Commit convention preset: "+strings.Join(config.Presets, ", "))
+	interactive := initFlags.Bool("interactive", false, "Ask for preset, language, provider and ticket pattern")
+	initFlags.BoolVar(interactive, "i", false, "Shorthand for --interactive")
+	initFlags.Parse(args)
+
+	// Use --file if specified, otherwise use -f
+	outputPath := *fileShort
+	if *fileLong != "" {
+		outputPath = *fileLong
+	}
+
+	opts := config.InitOptions{Preset: *preset}
+	if *interactive {
+		var err error
+		if opts, err = askInitOptions(os.Stdin, os.Stdout, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Write the config for the chosen preset and answers
+	if err := config.WriteInitConfig(outputPath, *force, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Config file created: %s\n", outputPath)
+	fmt.Println()
+	fmt.Println("You can now:")
+	fmt.Println("  1. Edit the config file to customize prompts")
+	fmt.Println("  2. Use it with: generate-auto-commit-message --config=" + outputPath)
+	fmt.Println("  3. Or save it as " + config.RepoConfigFile + " at the repository root to load it automatically")
+}
+
+// askInitOptions runs the init questionnaire; an empty answer keeps the suggested value
+func askInitOptions(in io.Reader, out io.Writer, opts config.InitOptions) (config.InitOptions, error) {
+	reader := bufio.NewReader(in)
+	ask := func(question, suggestion string) (string, error) {
+		if suggestion != "" {
+			fmt.Fprintf(out, "%s [%s]: ", question, suggestion)
+		} else {
+			fmt.Fprintf(out, "%s: ", question)
+		}
+		answer, err := reader.ReadString('\n')
+		// A last answer without a newline still counts; nothing at all means there is no one to ask
+		if err == io.EOF && answer == "" {
+			return "", fmt.Errorf("no answer to %q: reached the end of input", question)
+		}
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if answer = strings.TrimSpace(answer); answer == "" {
+			return suggestion, nil
+		}
+		return answer, nil
+	}
+
+	presetSuggestion := opts.Preset
+	if presetSuggestion == "" {
+		presetSuggestion = "conventional"
+	}
+	for {
+		answer, err := ask("Commit convention ("+strings.Join(config.Presets, ", ")+")", presetSuggestion)
+		if err != nil {
+			return opts, err
+		}
+		if _, err := config.PresetData(answer); err != nil {
+			fmt.Fprintf(out, "  %v\n", err)
+			continue
+		}
+		opts.Preset = answer
+		break
+	}
+
+	language, err := ask("Language of the commit messages (japanese, english; empty for the provider default)", "")
+	if err != nil {
+		return opts, err
+	}
+	opts.Language = language
+
+	for {
+		answer, err := ask("AI provider ("+strings.Join(provider.Names, ", ")+")", provider.Detect())
+		if err != nil {
+			return opts, err
+		}
+		if !provider.IsValid(strings.ToLower(answer)) {
+			fmt.Fprintf(out, "  unknown provider %q\n", answer)
+			continue
+		}
+		opts.Provider = strings.ToLower(answer)
+		break
+	}
+
+	ticket, err := ask("Ticket ID pattern in branch names (regular expression, empty for the preset default)", "")
+	if err != nil {
+		return opts, err
+	}
+	opts.TicketPattern = ticket
+
+	fmt.Fprintln(out)
+	return opts, nil
+}
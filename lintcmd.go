@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// scissorsLine marks the start of the diff git adds below the message with commit --verbose
+const scissorsLine = "# ------------------------ >8 ------------------------"
+
+// runLint checks a commit message (a file such as .git/COMMIT_EDITMSG, or stdin) against
+// the config, so it can be used as a commit-msg hook
+func runLint(args []string) {
+	lintFlags := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := lintFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := lintFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	lintFlags.Parse(args)
+
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: *configPath, Profile: *profile}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := "stdin"
+	var data []byte
+	var err error
+	if lintFlags.NArg() > 0 {
+		name = lintFlags.Arg(0)
+		data, err = os.ReadFile(name)
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commit message: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems := message.LintEmoji(stripCommitComments(string(data)), config.Get().SemanticReleasePrefixes)
+	for _, problem := range problems {
+		fmt.Printf("%s: %s\n", name, problem)
+	}
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
+}
+
+// stripCommitComments removes the comment lines and the verbose diff git adds to a commit message file
+func stripCommitComments(msg string) string {
+	var lines []string
+	for _, line := range strings.Split(msg, "\n") {
+		if line == scissorsLine {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
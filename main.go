@@ -5,22 +5,49 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
 
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/bedrock"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/claude"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/claudecode"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/cache"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/codexcli"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/copilotcli"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/copilotsdk"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/geminicli"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/metrics"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/provider"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
 )
 
+// contextFlagPaths maps the names accepted by --context to the config paths they enable
+var contextFlagPaths = map[string]string{
+	"stat":      "context.stat",
+	"functions": "context.functions",
+	"packages":  "context.packages",
+	"readme":    "context.readme",
+}
+
+// flagConfigPaths maps generate flags to the config paths they override
+var flagConfigPaths = map[string]string{
+	"provider":       "defaults.provider",
+	"model":          "defaults.model",
+	"region":         "defaults.region",
+	"verbose":        "defaults.verbose",
+	"timeout":        "defaults.timeout",
+	"language":       "defaults.language",
+	"signoff":        "trailers.signoff",
+	"examples":       "style.examples",
+	"infer-style":    "style.infer",
+	"recent-commits": "context.recent_commits",
+	"token-budget":   "context.token_budget",
+	"breaking":       "breaking.detect",
+	"emoji":          "defaults.emoji",
+}
+
+// negatedFlagConfigPaths maps boolean flags that turn a setting off to the config paths they override
+var negatedFlagConfigPaths = map[string]string{
+	"no-cache": "cache.enabled",
+}
+
 func main() {
 	// Check for subcommands
 	if len(os.Args) > 1 {
@@ -28,6 +55,39 @@ func main() {
 		case "init":
 			runInit(os.Args[2:])
 			return
+		case "config":
+			runConfig(os.Args[2:])
+			return
+		case "lint":
+			runLint(os.Args[2:])
+			return
+		case "pr":
+			runPR(os.Args[2:])
+			return
+		case "changelog":
+			runChangelog(os.Args[2:])
+			return
+		case "reword":
+			runReword(os.Args[2:])
+			return
+		case "squash":
+			runSquash(os.Args[2:])
+			return
+		case "branch":
+			runBranch(os.Args[2:])
+			return
+		case "review":
+			runReview(os.Args[2:])
+			return
+		case "cache":
+			runCache(os.Args[2:])
+			return
+		case "usage":
+			runUsage(os.Args[2:])
+			return
+		case "stats":
+			runStats(os.Args[2:])
+			return
 		case "version", "--version", "-v":
 			printVersion()
 			return
@@ -46,23 +106,157 @@ func printHelp() {
 	fmt.Println("\nUsage:")
 	fmt.Println("  generate-auto-commit-message [options]          Generate commit message")
 	fmt.Println("  generate-auto-commit-message init [options]     Initialize config file")
+	fmt.Println("  generate-auto-commit-message config show        Show the effective config")
+	fmt.Println("  generate-auto-commit-message config validate    Validate config files")
+	fmt.Println("  generate-auto-commit-message config schema      Print the config JSON Schema")
+	fmt.Println("  generate-auto-commit-message config migrate     Upgrade config files to the current schema")
+	fmt.Println("  generate-auto-commit-message config infer       Infer the commit convention from the history")
+	fmt.Println("  generate-auto-commit-message lint [file]        Check a commit message against the config")
+	fmt.Println("  generate-auto-commit-message pr [options]       Generate a pull request title and body")
+	fmt.Println("  generate-auto-commit-message changelog [options] Generate a changelog section from a commit range")
+	fmt.Println("  generate-auto-commit-message reword <range>     Regenerate the messages of existing commits")
+	fmt.Println("  generate-auto-commit-message squash [range]     Write one message for the commits of a branch")
+	fmt.Println("  generate-auto-commit-message branch [text]      Propose a branch name from a description or the staged changes")
+	fmt.Println("  generate-auto-commit-message review [options]   Review the staged changes")
+	fmt.Println("  generate-auto-commit-message cache stats        Show the number and size of cached responses")
+	fmt.Println("  generate-auto-commit-message cache clear        Remove cached responses (--expired for only the expired ones)")
+	fmt.Println("  generate-auto-commit-message usage [options]    Summarize the tokens and cost of the usage log")
+	fmt.Println("  generate-auto-commit-message stats [options]    Compare how often generated messages are committed")
+	fmt.Println("  generate-auto-commit-message stats post-commit  Record whether HEAD used the generated message (post-commit hook)")
 	fmt.Println("  generate-auto-commit-message version            Show version")
 	fmt.Println("  generate-auto-commit-message help               Show this help")
 	fmt.Println("\nGenerate Options:")
 	generateFlags := flag.NewFlagSet("generate", flag.ExitOnError)
 	generateFlags.String("model", "", "Model ID (default depends on provider)")
-	generateFlags.String("region", "us-east-1", "AWS region (for bedrock provider)")
+	generateFlags.String("region", "", "AWS region (for bedrock provider, default \"us-east-1\")")
 	generateFlags.String("provider", "", "AI provider: 'bedrock', 'claude', 'geminicli', 'copilotcli', 'copilotsdk', 'claudecode', or 'codexcli' (auto-detected if not specified)")
-	generateFlags.String("config", "", "Path to config file (uses embedded default if not specified)")
+	generateFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	generateFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
 	generateFlags.Bool("verbose", false, "Enable verbose output")
+	generateFlags.String("timeout", "", "Timeout for the AI request (e.g. 90s, 2m)")
+	generateFlags.String("language", "", "Prompt template language (e.g. japanese, english)")
+	generateFlags.String("emoji", "", "Emoji after the type prefix: none, shortcode or unicode (default: as generated)")
+	generateFlags.Bool("signoff", false, "Add a Signed-off-by trailer with your git identity")
+	generateFlags.String("pair", "", "Comma-separated co-authors (team file aliases or \"Name <email>\")")
+	generateFlags.Int("examples", 0, "Number of recent commit messages to show the model as examples")
+	generateFlags.Bool("infer-style", false, "Infer the commit convention from the history when the repository has no config file")
+	generateFlags.String("context", "", "Comma-separated repository context to add: stat, functions, packages, readme")
+	generateFlags.Int("recent-commits", 0, "Number of recent commits touching the staged files to add as context")
+	generateFlags.Int("token-budget", 0, "Approximate token budget for the diff and context (0 = unlimited)")
+	generateFlags.Bool("breaking", false, "Detect breaking changes to the exported Go API (--breaking=false to disable)")
+	generateFlags.Bool("no-cache", false, "Call the model even if a cached response exists")
+	generateFlags.Bool("race", false, "Query the provider and the defaults.fallback providers at once and take the first valid message")
+	generateFlags.Bool("best-of", false, "Query the provider and the defaults.fallback providers at once and take the best-scored message")
 	generateFlags.String("prompt", "", "Additional instructions to inject into the prompt (e.g. ticket number, context)")
 	generateFlags.String("p", "", "Additional instructions to inject into the prompt (shorthand for --prompt)")
 	generateFlags.PrintDefaults()
+	fmt.Println("\nConfig Show Options:")
+	showFlags := flag.NewFlagSet("config show", flag.ExitOnError)
+	showFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	showFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	showFlags.Bool("origin", false, "Print where each value came from")
+	showFlags.PrintDefaults()
+	fmt.Println("\nConfig Validate Options:")
+	validateFlags := flag.NewFlagSet("config validate", flag.ExitOnError)
+	validateFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	validateFlags.PrintDefaults()
+	fmt.Println("  Pass file paths as arguments to validate only those files")
+	fmt.Println("\nConfig Migrate Options:")
+	migrateFlags := flag.NewFlagSet("config migrate", flag.ExitOnError)
+	migrateFlags.String("config", "", "Path to config file (migrated along with discovered config files)")
+	migrateFlags.Bool("dry-run", false, "Print the changes without writing files")
+	migrateFlags.PrintDefaults()
+	fmt.Println("  Pass file paths as arguments to migrate only those files; originals are kept as <file>.bak")
+	fmt.Println("\nConfig Discovery (later entries win):")
+	fmt.Println("  1. Embedded default config")
+	fmt.Println("  2. /etc/gcm/config.yaml")
+	fmt.Println("  3. $XDG_CONFIG_HOME/gcm/config.yaml (~/.config/gcm/config.yaml)")
+	fmt.Println("  4. .gcm.yaml at the repository root")
+	fmt.Println("  5. --config file")
+	fmt.Println("  6. Profile from --profile, git config gcm.profile, or matched by remote URL")
+	fmt.Println("  7. git config keys gcm.provider and gcm.model")
+	fmt.Println("  8. Command line flags")
+	fmt.Println("\nLint Options:")
+	lintFlags := flag.NewFlagSet("lint", flag.ExitOnError)
+	lintFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	lintFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	lintFlags.PrintDefaults()
+	fmt.Println("  Reads the message from stdin when no file is given; comment lines are ignored")
+	fmt.Println("\nPR Options:")
+	prFlags := flag.NewFlagSet("pr", flag.ExitOnError)
+	addModelFlags(prFlags)
+	prFlags.String("base", "", "Branch the pull request merges into (default \""+defaultPRBase+"\")")
+	prFlags.String("template", "", "Pull request template (default: .github/pull_request_template.md, ...)")
+	prFlags.String("format", "text", "Output format: text or json")
+	prFlags.String("output", "", "Write the body to a file (for gh pr create --body-file) and print only the title")
+	prFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	prFlags.PrintDefaults()
+	fmt.Println("\nChangelog Options:")
+	changelogFlags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	addModelFlags(changelogFlags)
+	changelogFlags.String("from", "", "Start of the range, exclusive (default: the previous tag)")
+	changelogFlags.String("to", "HEAD", "End of the range")
+	changelogFlags.String("version", "", "Version of the section (default: the tag at --to, or Unreleased)")
+	changelogFlags.Bool("rewrite", false, "Have the model rewrite the entries into user-facing release notes")
+	changelogFlags.String("output", "", "Changelog file to create or update (e.g. CHANGELOG.md)")
+	changelogFlags.String("prompt", "", "Additional instructions for --rewrite")
+	changelogFlags.PrintDefaults()
+	fmt.Println("\nReword Options:")
+	rewordFlags := flag.NewFlagSet("reword", flag.ExitOnError)
+	addModelFlags(rewordFlags)
+	rewordFlags.Bool("force", false, "Rewrite protected branches and commits already pushed")
+	rewordFlags.Bool("yes", false, "Rewrite without asking for confirmation")
+	rewordFlags.Bool("dry-run", false, "Show the new messages without rewriting history")
+	rewordFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	rewordFlags.PrintDefaults()
+	fmt.Println("\nSquash Options:")
+	squashFlags := flag.NewFlagSet("squash", flag.ExitOnError)
+	addModelFlags(squashFlags)
+	squashFlags.String("base", "", "Branch whose merge base starts the range when none is given (default \""+defaultPRBase+"\")")
+	squashFlags.Bool("apply", false, "Squash the commits into one with git reset --soft and git commit")
+	squashFlags.Bool("yes", false, "Squash without asking for confirmation")
+	squashFlags.Bool("force", false, "Squash on protected branches, across merge commits and commits already pushed")
+	squashFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	squashFlags.PrintDefaults()
+	fmt.Println("\nBranch Options:")
+	branchFlags := flag.NewFlagSet("branch", flag.ExitOnError)
+	addModelFlags(branchFlags)
+	branchFlags.String("ticket", "", "Ticket ID for {ticket} (default: found in the description by the ticket patterns)")
+	branchFlags.Bool("switch", false, "Create the branch and switch to it with git switch -c")
+	branchFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	branchFlags.PrintDefaults()
+	fmt.Println("\nReview Options:")
+	reviewFlags := flag.NewFlagSet("review", flag.ExitOnError)
+	addModelFlags(reviewFlags)
+	reviewFlags.String("format", "text", "Output format: text, json or sarif")
+	reviewFlags.String("fail-on", "", "Lowest severity that fails the review: "+strings.Join(config.Severities, ", ")+" or none (default \"error\")")
+	reviewFlags.String("output", "", "Write the findings to a file instead of stdout")
+	reviewFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	reviewFlags.PrintDefaults()
+	fmt.Println("\nUsage Options:")
+	usageFlags := flag.NewFlagSet("usage", flag.ExitOnError)
+	usageFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	usageFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	usageFlags.String("by", "day", "Comma-separated grouping: "+strings.Join(usage.Keys, ", "))
+	usageFlags.String("since", "", "Only count requests since a number of days, a duration or a date (e.g. 7d, 12h, 2026-01-31)")
+	usageFlags.String("format", "text", "Output format: text or json")
+	usageFlags.PrintDefaults()
+	fmt.Println("\nStats Options:")
+	statsFlags := flag.NewFlagSet("stats", flag.ExitOnError)
+	statsFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	statsFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	statsFlags.String("by", "provider", "Comma-separated grouping: "+strings.Join(metrics.Keys, ", "))
+	statsFlags.String("since", "", "Only count messages generated since a number of days, a duration or a date (e.g. 7d, 12h, 2026-01-31)")
+	statsFlags.String("format", "text", "Output format: text or json")
+	statsFlags.PrintDefaults()
 	fmt.Println("\nInit Options:")
 	initFlags := flag.NewFlagSet("init", flag.ExitOnError)
 	initFlags.String("f", "./prompt.yaml", "Output file path (short form)")
 	initFlags.String("file", "./prompt.yaml", "Output file path (long form)")
 	initFlags.Bool("force", false, "Overwrite existing file")
+	initFlags.String("preset", "", "Commit convention preset: "+strings.Join(config.Presets, ", "))
+	initFlags.Bool("interactive", false, "Ask for preset, language, provider and ticket pattern")
+	initFlags.Bool("i", false, "Shorthand for --interactive")
 	initFlags.PrintDefaults()
 	fmt.Println("\nProviders:")
 	fmt.Println("  bedrock    - AWS Bedrock (requires AWS credentials)")
@@ -87,6 +281,48 @@ func printHelp() {
 	fmt.Println("  # Generate with additional instructions")
 	fmt.Println("  generate-auto-commit-message --prompt=\"relates to JIRA-123, fix login bug\"")
 	fmt.Println()
+	fmt.Println("  # Sign off and credit a pair programmer from the team file")
+	fmt.Println("  generate-auto-commit-message --signoff --pair=alice")
+	fmt.Println()
+	fmt.Println("  # Show the model the last 10 commit messages as examples")
+	fmt.Println("  generate-auto-commit-message --examples=10")
+	fmt.Println()
+	fmt.Println("  # Add the diffstat and changed function names to the prompt")
+	fmt.Println("  generate-auto-commit-message --context=stat,functions")
+	fmt.Println()
+	fmt.Println("  # Render the gitmoji of each type as unicode emoji")
+	fmt.Println("  generate-auto-commit-message --emoji=unicode")
+	fmt.Println()
+	fmt.Println("  # Check commit messages in a commit-msg hook")
+	fmt.Println("  generate-auto-commit-message lint \"$1\"")
+	fmt.Println()
+	fmt.Println("  # Open a pull request with a generated title and body")
+	fmt.Println("  gh pr create --title \"$(generate-auto-commit-message pr -o pr.md)\" --body-file pr.md")
+	fmt.Println()
+	fmt.Println("  # Add the release notes of the latest tag to CHANGELOG.md")
+	fmt.Println("  generate-auto-commit-message changelog --to v1.2.0 --rewrite -o CHANGELOG.md")
+	fmt.Println("")
+	fmt.Println("  # Regenerate the messages of the commits on the branch since origin/main")
+	fmt.Println("  generate-auto-commit-message reword origin/main..")
+	fmt.Println("")
+	fmt.Println("  # Squash the commits of the branch into one with a generated message")
+	fmt.Println("  generate-auto-commit-message squash --apply")
+	fmt.Println("")
+	fmt.Println("  # Create a branch named after a task, e.g. feat/ABC-123-add-login-form")
+	fmt.Println("  generate-auto-commit-message branch --switch \"ABC-123 add a login form\"")
+	fmt.Println("")
+	fmt.Println("  # Block commits with errors found in review (in a pre-commit hook)")
+	fmt.Println("  generate-auto-commit-message review --fail-on=error")
+	fmt.Println()
+	fmt.Println("  # Take the first valid message of the provider and the defaults.fallback providers")
+	fmt.Println("  generate-auto-commit-message --race")
+	fmt.Println()
+	fmt.Println("  # Show the tokens and cost of the last 30 days by provider and model")
+	fmt.Println("  generate-auto-commit-message usage --since=30d --by=provider,model")
+	fmt.Println()
+	fmt.Println("  # Compare how often the messages of each provider and prompt are committed")
+	fmt.Println("  generate-auto-commit-message stats --by=provider,prompt")
+	fmt.Println()
 	fmt.Println("  # Short form of --prompt")
 	fmt.Println("  generate-auto-commit-message -p \"WIP: do not merge yet\"")
 	fmt.Println()
@@ -95,42 +331,49 @@ func printHelp() {
 	fmt.Println()
 	fmt.Println("  # Overwrite existing config")
 	fmt.Println("  generate-auto-commit-message init --force")
-}
-
-func runInit(args []string) {
-	initFlags := flag.NewFlagSet("init", flag.ExitOnError)
-	fileShort := initFlags.String("f", "./prompt.yaml", "Output file path")
-	fileLong := initFlags.String("file", "", "Output file path")
-	force := initFlags.Bool("force", false, "Overwrite existing file")
-	initFlags.Parse(args)
-
-	// Use --file if specified, otherwise use -f
-	outputPath := *fileShort
-	if *fileLong != "" {
-		outputPath = *fileLong
-	}
-
-	// Write default config
-	if err := config.WriteDefaultConfig(outputPath, *force); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
-	}
-
-	fmt.Printf("✓ Config file created: %s\n", outputPath)
 	fmt.Println()
-	fmt.Println("You can now:")
-	fmt.Println("  1. Edit the config file to customize prompts")
-	fmt.Println("  2. Use it with: generate-auto-commit-message --config=" + outputPath)
+	fmt.Println("  # Scaffold a gitmoji config for this repository")
+	fmt.Println("  generate-auto-commit-message init --preset=gitmoji -f .gcm.yaml")
+	fmt.Println()
+	fmt.Println("  # Answer a few questions to create a config")
+	fmt.Println("  generate-auto-commit-message init --interactive")
+	fmt.Println()
+	fmt.Println("  # Upgrade an older config file")
+	fmt.Println("  generate-auto-commit-message config migrate prompt.yaml")
+	fmt.Println()
+	fmt.Println("  # Generate with the settings of the 'work' profile")
+	fmt.Println("  generate-auto-commit-message --profile=work")
+	fmt.Println()
+	fmt.Println("  # Show the effective config and where each value came from")
+	fmt.Println("  generate-auto-commit-message config show --origin")
+	fmt.Println()
+	fmt.Println("  # Check a config file for unknown keys and broken templates")
+	fmt.Println("  generate-auto-commit-message config validate .gcm.yaml")
 }
 
 func runGenerate(args []string) {
 	// Parse command line flags
 	generateFlags := flag.NewFlagSet("generate", flag.ExitOnError)
 	modelID := generateFlags.String("model", "", "Model ID (default depends on provider)")
-	region := generateFlags.String("region", "us-east-1", "AWS region (for bedrock provider)")
-	provider := generateFlags.String("provider", "", "AI provider: 'bedrock', 'claude', 'geminicli', 'copilotcli', 'copilotsdk', 'claudecode', or 'codexcli' (auto-detected if not specified)")
-	configPath := generateFlags.String("config", "", "Path to config file (uses embedded default if not specified)")
+	region := generateFlags.String("region", "", "AWS region (for bedrock provider, default \"us-east-1\")")
+	providerName := generateFlags.String("provider", "", "AI provider: 'bedrock', 'claude', 'geminicli', 'copilotcli', 'copilotsdk', 'claudecode', or 'codexcli' (auto-detected if not specified)")
+	configPath := generateFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := generateFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
 	verbose := generateFlags.Bool("verbose", false, "Enable verbose output")
+	generateFlags.String("timeout", "", "Timeout for the AI request (e.g. 90s, 2m)")
+	generateFlags.String("language", "", "Prompt template language (e.g. japanese, english)")
+	generateFlags.String("emoji", "", "Emoji after the type prefix: none, shortcode or unicode (default: as generated)")
+	generateFlags.Bool("signoff", false, "Add a Signed-off-by trailer with your git identity")
+	pair := generateFlags.String("pair", "", "Comma-separated co-authors (team file aliases or \"Name <email>\")")
+	generateFlags.Int("examples", 0, "Number of recent commit messages to show the model as examples")
+	generateFlags.Bool("infer-style", false, "Infer the commit convention from the history when the repository has no config file")
+	contextNames := generateFlags.String("context", "", "Comma-separated repository context to add: stat, functions, packages, readme")
+	generateFlags.Int("recent-commits", 0, "Number of recent commits touching the staged files to add as context")
+	generateFlags.Int("token-budget", 0, "Approximate token budget for the diff and context (0 = unlimited)")
+	generateFlags.Bool("breaking", false, "Detect breaking changes to the exported Go API (--breaking=false to disable)")
+	generateFlags.Bool("no-cache", false, "Call the model even if a cached response exists")
+	race := generateFlags.Bool("race", false, "Query the provider and the defaults.fallback providers at once and take the first valid message")
+	bestOf := generateFlags.Bool("best-of", false, "Query the provider and the defaults.fallback providers at once and take the best-scored message")
 	prompt := generateFlags.String("prompt", "", "Additional instructions to inject into the prompt (e.g. ticket number, context)")
 	generateFlags.StringVar(prompt, "p", "", "Additional instructions to inject into the prompt (shorthand for --prompt)")
 	help := generateFlags.Bool("help", false, "Show help")
@@ -140,55 +383,66 @@ func runGenerate(args []string) {
 		printHelp()
 		os.Exit(0)
 	}
-
-	// Auto-detect provider if not specified
-	if *provider == "" {
-		if os.Getenv("ANTHROPIC_API_KEY") != "" {
-			*provider = "claude"
-		} else if _, err := exec.LookPath("claude"); err == nil {
-			*provider = "claudecode"
-		} else if _, err := exec.LookPath("copilot"); err == nil {
-			*provider = "copilotcli"
-		} else if _, err := exec.LookPath("gemini"); err == nil {
-			*provider = "geminicli"
-		} else if _, err := exec.LookPath("codex"); err == nil {
-			*provider = "codexcli"
-		} else {
-			*provider = "bedrock"
-		}
-	}
-
-	// Validate provider
-	*provider = strings.ToLower(*provider)
-	if *provider != "bedrock" && *provider != "claude" && *provider != "geminicli" && *provider != "copilotcli" && *provider != "copilotsdk" && *provider != "claudecode" && *provider != "codexcli" {
-		fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Must be 'bedrock', 'claude', 'geminicli', 'copilotcli', 'copilotsdk', 'claudecode', or 'codexcli'\n", *provider)
+	if *race && *bestOf {
+		fmt.Fprintln(os.Stderr, "Error: --race and --best-of cannot be used together")
 		os.Exit(1)
 	}
 
-	// Set default model ID based on provider
-	if *modelID == "" {
-		if *provider == "bedrock" {
-			*modelID = "anthropic.claude-sonnet-4-5-20250929-v1:0"
-		} else if *provider == "claude" {
-			*modelID = "claude-sonnet-4-6"
-		} else if *provider == "geminicli" {
-			*modelID = "gemini-2.5-pro"
-		} else if *provider == "copilotcli" {
-			*modelID = "claude-sonnet-4.5"
-		} else if *provider == "copilotsdk" {
-			*modelID = "gpt-4o"
-		} else if *provider == "claudecode" {
-			*modelID = "claude-sonnet-4.5"
-		} else if *provider == "codexcli" {
-			*modelID = "o4-mini"
+	// Flags given explicitly override every config layer
+	overrides := map[string]interface{}{}
+	overrideOrigins := map[string]string{}
+	explicitModel := ""
+	generateFlags.Visit(func(f *flag.Flag) {
+		if f.Name == "model" {
+			explicitModel = *modelID
+		}
+		if path, ok := flagConfigPaths[f.Name]; ok {
+			overrides[path] = f.Value.(flag.Getter).Get()
+			overrideOrigins[path] = "--" + f.Name
+		}
+		if path, ok := negatedFlagConfigPaths[f.Name]; ok {
+			overrides[path] = !f.Value.(flag.Getter).Get().(bool)
+			overrideOrigins[path] = "--" + f.Name
+		}
+	})
+	for _, name := range strings.Split(*contextNames, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
 		}
+		path, ok := contextFlagPaths[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: unknown context '%s' (available: stat, functions, packages, readme)\n", name)
+			os.Exit(1)
+		}
+		overrides[path] = true
+		overrideOrigins[path] = "--context"
 	}
 
 	// Initialize config
-	if err := config.InitGlobal(*configPath); err != nil {
+	if err := config.InitGlobalWithOptions(config.LoadOptions{
+		ConfigPath:      *configPath,
+		Overrides:       overrides,
+		OverrideOrigins: overrideOrigins,
+		Profile:         *profile,
+	}); err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
 		os.Exit(1)
 	}
+	cfg := config.Get()
+	if _, err := config.ParseTimeout(cfg.Defaults.Timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Resolve provider (auto-detected if not configured), model and region
+	*providerName = provider.ResolveName(cfg, "")
+	if !provider.IsValid(*providerName) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Must be one of: %s\n", *providerName, strings.Join(provider.Names, ", "))
+		os.Exit(1)
+	}
+	*modelID = provider.ResolveModel(cfg, *providerName, explicitModel)
+	*region = provider.ResolveRegion(cfg, "")
+	*verbose = cfg.Defaults.Verbose
 
 	// Configure logging
 	if *verbose {
@@ -221,69 +475,158 @@ func runGenerate(args []string) {
 		os.Exit(0)
 	}
 
-	// Initialize AI client based on provider
-	var aiClient client.AIClient
+	// Learn the commit style from the repository history
+	style, err := message.LearnStyle(cfg, !config.HasRepoConfig(*configPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
-	if *provider == "bedrock" {
-		aiClient, err = bedrock.NewClient(*region, *modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing AWS Bedrock client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "claude" {
-		aiClient, err = claude.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Claude API client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "geminicli" {
-		aiClient, err = geminicli.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Gemini CLI client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "copilotcli" {
-		aiClient, err = copilotcli.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Copilot CLI client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "copilotsdk" {
-		aiClient, err = copilotsdk.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Copilot SDK client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "claudecode" {
-		aiClient, err = claudecode.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Claude Code client: %v\n", err)
-			os.Exit(1)
-		}
-	} else if *provider == "codexcli" {
-		aiClient, err = codexcli.NewClient(*modelID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error initializing Codex CLI client: %v\n", err)
-			os.Exit(1)
-		}
+	// Merges, reverts and cherry-picks get a prompt of their own
+	op, err := git.GetOperation()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading the repository state: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize AI client based on provider; racing queries the fallback providers too
+	var aiClient client.AIClient
+	if *race || *bestOf {
+		aiClient, err = provider.NewRace(*providerName, *modelID, *region, *bestOf, func(msg string) (int, bool) {
+			evaluation := message.Evaluate(msg, cfg.SemanticReleasePrefixes)
+			return evaluation.Score, evaluation.Valid()
+		})
+	} else {
+		aiClient, err = provider.New(*providerName, *modelID, *region)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing %s client: %v\n", *providerName, err)
+		os.Exit(1)
 	}
 
 	// Generate commit message
-	commitMsg, err := message.Generate(aiClient, diff, branch, *prompt)
+	started := time.Now()
+	result, err := message.GenerateWithOptions(aiClient, diff, branch, message.Options{
+		ExtraPrompt: *prompt,
+		Context:     cfg.Context,
+		Scopes:      cfg.Scopes,
+		Breaking:    cfg.Breaking,
+		Emoji:       cfg.Defaults.Emoji,
+		Operation:   op,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating commit message: %v\n", err)
 		os.Exit(1)
 	}
+	commitMsg := result.Message
+	// A fallback provider may have answered instead of the configured one
+	answeredProvider, answeredModel := *providerName, *modelID
+	if name, model, ok := provider.Answered(aiClient); ok {
+		answeredProvider, answeredModel = name, model
+	}
+	for _, problem := range message.LintEmoji(commitMsg, cfg.SemanticReleasePrefixes) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+	}
+	if scope, ok := message.MessageScope(commitMsg); ok && result.Scope.Inferred() && scope != result.Scope.Scope {
+		fmt.Fprintf(os.Stderr, "Warning: the commit message uses scope %q but the changed paths suggest %q\n", scope, result.Scope.Scope)
+	}
+
+	// Insert the ticket reference from the branch name
+	commitMsg, ticket, err := message.ReferenceTicket(commitMsg, branch, cfg.Ticket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !message.ContainsTicket(commitMsg, ticket) {
+		fmt.Fprintf(os.Stderr, "Warning: the commit message does not reference ticket %s\n", ticket.Reference)
+	}
+
+	// Append trailers (Co-authored-by, Signed-off-by, Generated-by)
+	commitMsg, err = message.AddTrailers(commitMsg, cfg.Trailers, message.TrailerOptions{
+		Pairs:    strings.Split(*pair, ","),
+		Provider: answeredProvider,
+		Model:    answeredModel,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error adding trailers: %v\n", err)
+		os.Exit(1)
+	}
+	latency := time.Since(started)
+
+	// Record the message so that the post-commit hook can tell whether it was used
+	metrics.RecordGeneration(metrics.Event{
+		Branch:      branch,
+		Provider:    answeredProvider,
+		Model:       answeredModel,
+		Prompt:      metrics.PromptID(cfg, cfg.PromptLanguage(provider.DefaultLanguage(*providerName))),
+		ExtraPrompt: *prompt != "",
+		LatencyMS:   latency.Milliseconds(),
+		Message:     commitMsg,
+	})
 
 	// デバッグ情報の出力
 	if *verbose {
 		fmt.Println("=== Debug Information ===")
-		fmt.Printf("Provider: %s\n", *provider)
+		if cfg.ActiveProfile != "" {
+			fmt.Printf("Profile: %s\n", cfg.ActiveProfile)
+		}
+		fmt.Printf("Provider: %s\n", *providerName)
 		fmt.Printf("Model ID: %s\n", *modelID)
-		if *provider == "bedrock" {
+		if *providerName == "bedrock" {
 			fmt.Printf("Region: %s\n", *region)
 		}
+		if answeredProvider != *providerName {
+			fmt.Printf("Answered by: %s (%s)\n", answeredProvider, answeredModel)
+		}
+		if r, ok := provider.Unwrap(aiClient).(*provider.Race); ok {
+			for _, c := range r.Candidates() {
+				if c.Err != nil {
+					fmt.Printf("Candidate: %s failed: %s\n", c.Provider, strings.SplitN(c.Err.Error(), "\n", 2)[0])
+				} else {
+					fmt.Printf("Candidate: %s (%s) score %d, valid %t\n", c.Provider, c.Model, c.Score, c.Valid)
+				}
+			}
+		}
+		if ticket != nil {
+			fmt.Printf("Ticket: %s (%s)\n", ticket.Reference, ticket.Name)
+		}
+		if op != nil {
+			fmt.Printf("Operation: %s of %s %q\n", op.Kind, op.Hash[:7], op.Subject)
+			if len(op.Conflicts) > 0 {
+				fmt.Printf("Resolved conflicts: %s\n", strings.Join(op.Conflicts, ", "))
+			}
+		}
+		if len(cfg.Examples) > 0 {
+			fmt.Printf("Examples: %d commit messages\n", len(cfg.Examples))
+		}
+		if style != nil {
+			fmt.Printf("Inferred style: %s, %s preset, emoji %s, summary <= %d chars (%d commits)\n", style.Language, style.Preset(), style.Emoji, style.SubjectLength, style.Samples)
+		}
 		fmt.Printf("Diff size: %d bytes\n", len(diff))
+		fmt.Printf("Latency: %s\n", latency.Round(time.Millisecond))
+		if cached, ok := aiClient.(*cache.Client); ok && cached.Hits() > 0 {
+			fmt.Println("Response: from cache (--no-cache to regenerate)")
+		}
+		if run := usage.Run(); len(run) > 0 {
+			total := usage.Total(run)
+			fmt.Printf("Usage: %s\n", total)
+			// The default instructions are shorter than the minimum cacheable prompt of most models
+			if total.CacheRead > 0 || total.CacheCreation > 0 {
+				fmt.Printf("Prompt cache: %s\n", total.CacheString())
+			}
+		}
+		if result.Scope.Inferred() {
+			fmt.Printf("Scope: %q (candidates: %s)\n", result.Scope.Scope, strings.Join(result.Scope.Candidates, ", "))
+		}
+		for _, change := range result.Breaking {
+			fmt.Printf("Breaking change: %s\n", change)
+		}
+		for _, section := range result.Context {
+			fmt.Printf("Context: %s (~%d tokens)\n", section.Title, section.Tokens)
+		}
+		for _, skipped := range result.SkippedContext {
+			fmt.Printf("Context skipped: %s\n", skipped)
+		}
+		fmt.Printf("Input size: ~%d tokens\n", result.InputTokens)
 		fmt.Println("========================")
 	}
 
@@ -5,17 +5,12 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"strings"
 
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/bedrock"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/claude"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/claudecode"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/copilotcli"
-	"github.com/UNILORN/generative-commit-message-for-ai-tool/geminicli"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+	aiprovider "github.com/UNILORN/generative-commit-message-for-ai-tool/provider"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 )
@@ -29,12 +24,18 @@ type Server struct {
 }
 
 // NewServer creates a new MCP server instance
-func NewServer(provider, modelID, region string) (*Server, error) {
+func NewServer(provider, modelID, region, configPath, profile string) (*Server, error) {
 	// Initialize config
-	if err := config.InitGlobal(""); err != nil {
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: configPath, Profile: profile}); err != nil {
 		return nil, fmt.Errorf("failed to initialize config: %w", err)
 	}
 
+	// Learn the commit style from the repository history once for the whole session; like the
+	// CLI, the server works without it. Warnings go to stderr, as stdout carries the protocol.
+	if _, err := message.LearnStyle(config.Get(), !config.HasRepoConfig(configPath)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to learn commit style: %v\n", err)
+	}
+
 	s := &Server{
 		provider: provider,
 		modelID:  modelID,
@@ -78,10 +79,10 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 		mcp.NewTool("generate_commit_message",
 			mcp.WithDescription("Generate a commit message for staged changes using AI"),
 			mcp.WithString("provider",
-				mcp.Description("AI provider to use (bedrock, claude, geminicli, copilotcli, claudecode). If not specified, auto-detected."),
+				mcp.Description("AI provider to use (bedrock, claude, geminicli, copilotcli, copilotsdk, claudecode, codexcli). If not specified, the configured default or an auto-detected one is used."),
 			),
 			mcp.WithString("model",
-				mcp.Description("Model ID to use. If not specified, uses default for the provider."),
+				mcp.Description("Model ID to use. If not specified, uses the configured or built-in default for the provider."),
 			),
 		),
 		s.handleGenerateCommitMessage,
@@ -104,10 +105,10 @@ func (s *Server) registerTools(mcpServer *server.MCPServer) {
 		mcp.NewTool("generate_and_commit",
 			mcp.WithDescription("Generate a commit message using AI and create a commit with it"),
 			mcp.WithString("provider",
-				mcp.Description("AI provider to use (bedrock, claude, geminicli, copilotcli, claudecode). If not specified, auto-detected."),
+				mcp.Description("AI provider to use (bedrock, claude, geminicli, copilotcli, copilotsdk, claudecode, codexcli). If not specified, the configured default or an auto-detected one is used."),
 			),
 			mcp.WithString("model",
-				mcp.Description("Model ID to use. If not specified, uses default for the provider."),
+				mcp.Description("Model ID to use. If not specified, uses the configured or built-in default for the provider."),
 			),
 		),
 		s.handleGenerateAndCommit,
@@ -165,7 +166,7 @@ func (s *Server) handleGenerateCommitMessage(ctx context.Context, request mcp.Ca
 	}
 
 	// Create AI client
-	aiClient, err := s.createAIClient(provider, modelID)
+	aiClient, provider, modelID, err := s.createAIClient(provider, modelID)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to create AI client: %v", err)), nil
 	}
@@ -176,6 +177,21 @@ func (s *Server) handleGenerateCommitMessage(ctx context.Context, request mcp.Ca
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate commit message: %v", err)), nil
 	}
 
+	// Insert the ticket reference from the branch name
+	commitMsg, _, err = message.ReferenceTicket(commitMsg, branch, config.Get().Ticket)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Append the configured trailers, naming the provider that answered
+	if name, model, ok := aiprovider.Answered(aiClient); ok {
+		provider, modelID = name, model
+	}
+	commitMsg, err = message.AddTrailers(commitMsg, config.Get().Trailers, message.TrailerOptions{Provider: provider, Model: modelID})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add trailers: %v", err)), nil
+	}
+
 	return mcp.NewToolResultText(commitMsg), nil
 }
 
@@ -229,7 +245,7 @@ func (s *Server) handleGenerateAndCommit(ctx context.Context, request mcp.CallTo
 	}
 
 	// Create AI client
-	aiClient, err := s.createAIClient(provider, modelID)
+	aiClient, provider, modelID, err := s.createAIClient(provider, modelID)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to create AI client: %v", err)), nil
 	}
@@ -240,6 +256,21 @@ func (s *Server) handleGenerateAndCommit(ctx context.Context, request mcp.CallTo
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to generate commit message: %v", err)), nil
 	}
 
+	// Insert the ticket reference from the branch name
+	commitMsg, _, err = message.ReferenceTicket(commitMsg, branch, config.Get().Ticket)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// Append the configured trailers, naming the provider that answered
+	if name, model, ok := aiprovider.Answered(aiClient); ok {
+		provider, modelID = name, model
+	}
+	commitMsg, err = message.AddTrailers(commitMsg, config.Get().Trailers, message.TrailerOptions{Provider: provider, Model: modelID})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to add trailers: %v", err)), nil
+	}
+
 	// Execute git commit
 	cmd := exec.Command("git", "commit", "-m", commitMsg)
 	output, err := cmd.CombinedOutput()
@@ -269,78 +300,17 @@ func (s *Server) getStringParam(request mcp.CallToolRequest, name, defaultValue
 	return defaultValue
 }
 
-// createAIClient creates an AI client based on the provider
-func (s *Server) createAIClient(provider, modelID string) (client.AIClient, error) {
-	// Auto-detect provider if not specified
-	if provider == "" {
-		provider = s.autoDetectProvider()
-	}
+// createAIClient creates an AI client based on the provider and returns it with the provider
+// and model it resolved
+func (s *Server) createAIClient(provider, modelID string) (client.AIClient, string, string, error) {
+	cfg := config.Get()
 
-	// Set default model ID if not specified
-	if modelID == "" {
-		modelID = s.getDefaultModelID(provider)
-	}
-
-	provider = strings.ToLower(provider)
-
-	switch provider {
-	case "bedrock":
-		region := s.region
-		if region == "" {
-			region = "us-east-1"
-		}
-		return bedrock.NewClient(region, modelID)
-	case "claude":
-		return claude.NewClient(modelID)
-	case "geminicli":
-		return geminicli.NewClient(modelID)
-	case "copilotcli":
-		return copilotcli.NewClient(modelID)
-	case "claudecode":
-		return claudecode.NewClient(modelID)
-	default:
-		return nil, fmt.Errorf("unknown provider: %s", provider)
-	}
-}
-
-// autoDetectProvider detects the best available provider
-func (s *Server) autoDetectProvider() string {
-	if s.provider != "" {
-		return s.provider
-	}
+	// Fall back to the configured defaults, then auto-detection
+	provider = aiprovider.ResolveName(cfg, provider)
+	modelID = aiprovider.ResolveModel(cfg, provider, modelID)
 
-	// Check environment variables first (same logic as main.go)
-	if os.Getenv("ANTHROPIC_API_KEY") != "" {
-		return "claude"
-	}
-
-	// Check available CLI tools
-	if _, err := exec.LookPath("claude"); err == nil {
-		return "claudecode"
-	}
-	if _, err := exec.LookPath("copilot"); err == nil {
-		return "copilotcli"
-	}
-	if _, err := exec.LookPath("gemini"); err == nil {
-		return "geminicli"
-	}
-	return "bedrock"
-}
-
-// getDefaultModelID returns the default model ID for a provider
-func (s *Server) getDefaultModelID(provider string) string {
-	switch strings.ToLower(provider) {
-	case "bedrock":
-		return "anthropic.claude-3-sonnet-20240229-v1:0"
-	case "claude":
-		return "claude-3-5-sonnet-20241022"
-	case "geminicli":
-		return "gemini-2.5-pro"
-	case "copilotcli", "claudecode":
-		return "claude-sonnet-4.5"
-	default:
-		return ""
-	}
+	aiClient, err := aiprovider.New(provider, modelID, aiprovider.ResolveRegion(cfg, s.region))
+	return aiClient, provider, modelID, err
 }
 
 // ServeStdio starts the MCP server using stdio transport
@@ -0,0 +1,166 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// DefaultBranchPattern is the branch name pattern used when none is configured
+const DefaultBranchPattern = "{type}/{ticket}-{slug}"
+
+var (
+	// slugSeparatorPattern matches the runs of characters replaced by "-" in a slug
+	slugSeparatorPattern = regexp.MustCompile(`[^a-z0-9]+`)
+	// leadingPlaceholderPattern matches an empty placeholder that starts the name or a path
+	// segment, with the separators after it
+	leadingPlaceholderPattern = regexp.MustCompile(`(^|/)\x00[-_.]*`)
+	// emptyPlaceholderPattern matches any other empty placeholder with the separators before it
+	emptyPlaceholderPattern = regexp.MustCompile(`[-_.]*\x00`)
+	// branchLabelPattern matches the "type:" and "summary:" lines of the model's response
+	branchLabelPattern = regexp.MustCompile(`(?i)^\W*(type|summary)\W*[:：]\s*(.*)$`)
+)
+
+// BranchOptions holds the inputs of GenerateBranchName
+type BranchOptions struct {
+	// Description is the task description; the diff is used when it is empty
+	Description string
+	// Diff and Files describe the staged changes
+	Diff  string
+	Files string
+	// Ticket is the ticket ID put in the name, if any
+	Ticket string
+	// ExtraPrompt holds additional instructions from the user
+	ExtraPrompt string
+	// TokenBudget limits the prompt size (0 = unlimited); the diff is truncated to fit
+	TokenBudget int
+}
+
+// BuildBranchPrompt builds the prompt that asks for the type and summary of a branch
+func BuildBranchPrompt(opts BranchOptions, prefixes []config.SemanticReleasePrefix) string {
+	var sb strings.Builder
+	if strings.TrimSpace(opts.Description) != "" {
+		sb.WriteString("Propose a git branch name for the task described below.\n\n")
+	} else {
+		sb.WriteString("Propose a git branch name for the staged changes below.\n\n")
+	}
+	sb.WriteString("Rules:\n")
+	sb.WriteString("- Answer with exactly two lines: \"type: <type>\" and \"summary: <summary>\"\n")
+	sb.WriteString("- The summary is 2 to 5 lowercase English words naming the change (translate if needed), without the ticket ID\n")
+	sb.WriteString("- Output nothing else\n")
+	if len(prefixes) > 0 {
+		sb.WriteString("\nTypes:\n")
+		for _, p := range prefixes {
+			if p.DescriptionEN != "" {
+				sb.WriteString(fmt.Sprintf("- %s: %s\n", p.Type, p.DescriptionEN))
+			} else {
+				sb.WriteString(fmt.Sprintf("- %s\n", p.Type))
+			}
+		}
+	}
+	if strings.TrimSpace(opts.ExtraPrompt) != "" {
+		sb.WriteString(fmt.Sprintf("\nAdditional instructions from user:\n%s\n", opts.ExtraPrompt))
+	}
+
+	if strings.TrimSpace(opts.Description) != "" {
+		sb.WriteString("\nTask:\n" + strings.TrimSpace(opts.Description) + "\n")
+		return sb.String()
+	}
+	if opts.Files != "" {
+		sb.WriteString("\nFiles changed:\n" + opts.Files + "\n")
+	}
+	diff := opts.Diff
+	if opts.TokenBudget > 0 {
+		diff = TruncateToTokens(diff, opts.TokenBudget-EstimateTokens(sb.String()))
+	}
+	if diff != "" {
+		sb.WriteString("\nDiff:\n" + diff + "\n")
+	}
+	return sb.String()
+}
+
+// ParseBranchResponse returns the type and summary from the model's response
+func ParseBranchResponse(response string) (string, string) {
+	var branchType, summary string
+	for _, line := range strings.Split(stripCodeFence(response), "\n") {
+		m := branchLabelPattern.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		value := strings.Trim(strings.TrimSpace(m[2]), "\"`*")
+		if strings.EqualFold(m[1], "type") {
+			branchType = value
+		} else {
+			summary = value
+		}
+	}
+	return branchType, summary
+}
+
+// Slugify turns text into lowercase words joined by "-", keeping whole words within limit
+// characters (0 = unlimited)
+func Slugify(text string, limit int) string {
+	slug := strings.Trim(slugSeparatorPattern.ReplaceAllString(strings.ToLower(text), "-"), "-")
+	if limit <= 0 || len(slug) <= limit {
+		return slug
+	}
+	slug = slug[:limit]
+	if idx := strings.LastIndex(slug, "-"); idx > 0 {
+		slug = slug[:idx]
+	}
+	return strings.Trim(slug, "-")
+}
+
+// RenderBranchName fills the {type}, {ticket} and {slug} placeholders of pattern. The separator
+// next to an empty placeholder is dropped, and the slug is shortened to keep the name within
+// maxLength characters (0 = unlimited).
+func RenderBranchName(pattern, branchType, ticket, slug string, maxLength int) string {
+	if pattern == "" {
+		pattern = DefaultBranchPattern
+	}
+	render := func(slug string) string {
+		name := pattern
+		for placeholder, value := range map[string]string{"{type}": branchType, "{ticket}": ticket, "{slug}": slug} {
+			if value == "" {
+				value = "\x00"
+			}
+			name = strings.ReplaceAll(name, placeholder, value)
+		}
+		// An empty placeholder takes the "-", "_" and "." before it with it, or those after it
+		// when nothing comes before it
+		name = leadingPlaceholderPattern.ReplaceAllString(name, "$1")
+		name = emptyPlaceholderPattern.ReplaceAllString(name, "")
+		name = strings.ReplaceAll(name, "//", "/")
+		return strings.Trim(name, "/-_.")
+	}
+
+	name := render(slug)
+	if maxLength > 0 && len(name) > maxLength {
+		room := len(slug) - (len(name) - maxLength)
+		name = render(Slugify(slug, max(room, 1)))
+	}
+	return name
+}
+
+// GenerateBranchName asks the model for the type and summary of the change and renders the
+// branch name with the configured pattern
+func GenerateBranchName(aiClient client.AIClient, bc config.BranchConfig, prefixes []config.SemanticReleasePrefix, opts BranchOptions) (string, error) {
+	if strings.TrimSpace(opts.Description) == "" && strings.TrimSpace(opts.Diff) == "" {
+		return "", fmt.Errorf("no description or staged changes to name the branch after")
+	}
+
+	response, err := aiClient.Complete(BuildBranchPrompt(opts, prefixes))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate branch name: %w", err)
+	}
+	branchType, summary := ParseBranchResponse(response)
+	slug := Slugify(summary, 0)
+	if slug == "" {
+		return "", fmt.Errorf("failed to generate branch name: unexpected response %q", strings.TrimSpace(response))
+	}
+	ticket := strings.TrimPrefix(strings.TrimSpace(opts.Ticket), "#")
+	return RenderBranchName(bc.Pattern, Slugify(branchType, 0), ticket, slug, bc.MaxLength), nil
+}
@@ -0,0 +1,70 @@
+package message
+
+import "testing"
+
+func TestParseBranchResponse(t *testing.T) {
+	tests := []struct {
+		response    string
+		wantType    string
+		wantSummary string
+	}{
+		{"type: feat\nsummary: add login form", "feat", "add login form"},
+		{"```\n**Type**: fix\n**Summary**: \"null pointer in parser\"\n```", "fix", "null pointer in parser"},
+		{"feat/add-login", "", ""},
+	}
+	for _, tt := range tests {
+		gotType, gotSummary := ParseBranchResponse(tt.response)
+		if gotType != tt.wantType || gotSummary != tt.wantSummary {
+			t.Errorf("ParseBranchResponse(%q) = %q, %q, want %q, %q", tt.response, gotType, gotSummary, tt.wantType, tt.wantSummary)
+		}
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  string
+	}{
+		{"Add Login Form!", 0, "add-login-form"},
+		{"  fix: crash in  parser ", 0, "fix-crash-in-parser"},
+		{"add login form", 12, "add-login"},
+		{"internationalization", 8, "internat"},
+		{"ログイン", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.text, tt.limit); got != tt.want {
+			t.Errorf("Slugify(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestRenderBranchName(t *testing.T) {
+	tests := []struct {
+		name      string
+		pattern   string
+		typ       string
+		ticket    string
+		slug      string
+		maxLength int
+		want      string
+	}{
+		{"default", "", "feat", "ABC-123", "add-login-form", 0, "feat/ABC-123-add-login-form"},
+		{"no ticket", "", "feat", "", "add-login-form", 0, "feat/add-login-form"},
+		{"no type", "", "", "ABC-123", "add-login-form", 0, "ABC-123-add-login-form"},
+		{"nested", "{type}/{ticket}/{slug}", "fix", "", "crash", 0, "fix/crash"},
+		{"custom", "alice/{slug}_{ticket}", "feat", "42", "login", 0, "alice/login_42"},
+		{"flat no ticket", "{type}-{ticket}-{slug}", "feat", "", "add-login", 0, "feat-add-login"},
+		{"flat no type", "{type}-{ticket}-{slug}", "", "ABC-1", "add-login", 0, "ABC-1-add-login"},
+		{"flat only slug", "{type}-{ticket}-{slug}", "", "", "add-login", 0, "add-login"},
+		{"custom no ticket", "alice/{slug}_{ticket}", "feat", "", "login", 0, "alice/login"},
+		{"shortened", "", "feat", "ABC-1", "add-a-very-long-login-form", 24, "feat/ABC-1-add-a-very"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderBranchName(tt.pattern, tt.typ, tt.ticket, tt.slug, tt.maxLength); got != tt.want {
+				t.Errorf("RenderBranchName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
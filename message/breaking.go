@@ -0,0 +1,356 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+const (
+	// breakingFooter is the Conventional Commits footer key for breaking changes
+	breakingFooter = "BREAKING CHANGE"
+	// maxBreakingFooter limits the number of changes listed in the footer
+	maxBreakingFooter = 5
+)
+
+// BreakingChange is a change to the exported API of a Go package that likely breaks its users
+type BreakingChange struct {
+	// Package is the directory of the package relative to the repository root
+	Package string
+	// Symbol is the affected identifier, e.g. Config.Load
+	Symbol string
+	// Description explains the change, e.g. "removed exported function Load"
+	Description string
+}
+
+// String returns the change as it appears in the prompt and the footer
+func (b BreakingChange) String() string {
+	return fmt.Sprintf("%s: %s", b.Package, b.Description)
+}
+
+// apiEntry is one exported identifier (or member) of a package and its comparable signature
+type apiEntry struct {
+	Kind      string
+	Symbol    string
+	Signature string
+}
+
+// detectsBreaking reports whether breaking changes are detected for a commit. A merge brings in
+// API changes that were reviewed (and marked) on their own branch, so it is not compared.
+func detectsBreaking(bc config.BreakingConfig, op *git.Operation) bool {
+	return bc.Detect && (op == nil || op.Kind != git.OperationMerge)
+}
+
+// DetectBreakingChanges compares the exported API of the Go packages touched by the staged
+// files between HEAD and the index. Test files, main packages, internal packages and the
+// configured ignore globs are skipped.
+func DetectBreakingChanges(bc config.BreakingConfig, files []string) ([]BreakingChange, error) {
+	packages := map[string][]string{}
+	for _, file := range files {
+		if !isPublicGoFile(file) || matchesAny(bc.Ignore, file) {
+			continue
+		}
+		dir := path.Dir(file)
+		packages[dir] = append(packages[dir], file)
+	}
+
+	dirs := make([]string, 0, len(packages))
+	for dir := range packages {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var changes []BreakingChange
+	for _, dir := range dirs {
+		// Only the changed files are compared; declarations moved between them cancel out
+		oldSources := map[string]string{}
+		newSources := map[string]string{}
+		for _, file := range packages[dir] {
+			oldSrc, ok, err := git.GetFileAtRevision("HEAD", file)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				oldSources[file] = oldSrc
+			}
+			newSrc, ok, err := git.GetFileAtRevision("", file)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				newSources[file] = newSrc
+			}
+		}
+		changes = append(changes, CompareGoAPI(dir, oldSources, newSources)...)
+	}
+	return changes, nil
+}
+
+// CompareGoAPI returns the breaking changes between two versions of a package's files.
+// Files that do not parse or belong to a main package are ignored.
+func CompareGoAPI(pkg string, oldSources, newSources map[string]string) []BreakingChange {
+	oldAPI := exportedAPI(oldSources)
+	newAPI := exportedAPI(newSources)
+
+	var changes []BreakingChange
+	for key, old := range oldAPI {
+		current, ok := newAPI[key]
+		switch {
+		case !ok:
+			changes = append(changes, BreakingChange{Package: pkg, Symbol: old.Symbol, Description: fmt.Sprintf("removed exported %s %s", old.Kind, old.Symbol)})
+		case old.Signature != current.Signature:
+			changes = append(changes, BreakingChange{Package: pkg, Symbol: old.Symbol, Description: fmt.Sprintf("changed %s %s from %s to %s", old.Kind, old.Symbol, old.Signature, current.Signature)})
+		}
+	}
+	for key, current := range newAPI {
+		if current.Kind != "interface method" {
+			continue
+		}
+		// A method added to an existing interface breaks its implementations
+		iface, _, _ := strings.Cut(current.Symbol, ".")
+		if _, existed := oldAPI["type "+iface]; existed {
+			if _, ok := oldAPI[key]; !ok {
+				changes = append(changes, BreakingChange{Package: pkg, Symbol: current.Symbol, Description: fmt.Sprintf("added method %s to interface %s", current.Symbol, iface)})
+			}
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Symbol < changes[j].Symbol
+	})
+	return changes
+}
+
+// exportedAPI collects the exported declarations of a package, keyed by kind and symbol
+func exportedAPI(sources map[string]string) map[string]apiEntry {
+	api := map[string]apiEntry{}
+	add := func(kind, symbol, signature string) {
+		api[kind+" "+symbol] = apiEntry{Kind: kind, Symbol: symbol, Signature: signature}
+	}
+
+	fset := token.NewFileSet()
+	for name, src := range sources {
+		file, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+		if err != nil || file.Name.Name == "main" {
+			continue
+		}
+
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if !d.Name.IsExported() {
+					continue
+				}
+				if d.Recv == nil {
+					add("function", d.Name.Name, typeString(fset, d.Type))
+					continue
+				}
+				recv := receiverName(d.Recv)
+				if ast.IsExported(recv) {
+					add("method", recv+"."+d.Name.Name, typeString(fset, d.Type))
+				}
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						if s.Name.IsExported() {
+							addTypeAPI(fset, add, s)
+						}
+					case *ast.ValueSpec:
+						kind := "variable"
+						if d.Tok == token.CONST {
+							kind = "constant"
+						}
+						for _, n := range s.Names {
+							if n.IsExported() {
+								// Only the existence of values is compared; their types are often inferred
+								add(kind, n.Name, "")
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+	return api
+}
+
+// addTypeAPI adds an exported type and, for structs and interfaces, its exported members
+func addTypeAPI(fset *token.FileSet, add func(kind, symbol, signature string), s *ast.TypeSpec) {
+	name := s.Name.Name
+	switch t := s.Type.(type) {
+	case *ast.StructType:
+		add("type", name, "struct")
+		for _, field := range t.Fields.List {
+			for _, n := range fieldNames(field) {
+				if ast.IsExported(n) {
+					add("field", name+"."+n, typeString(fset, field.Type))
+				}
+			}
+		}
+	case *ast.InterfaceType:
+		add("type", name, "interface")
+		for _, method := range t.Methods.List {
+			for _, n := range fieldNames(method) {
+				add("interface method", name+"."+n, typeString(fset, method.Type))
+			}
+		}
+	default:
+		signature := typeString(fset, s.Type)
+		if s.Assign.IsValid() {
+			signature = "= " + signature
+		}
+		add("type", name, signature)
+	}
+}
+
+// fieldNames returns the names of a field, or the type name of an embedded field
+func fieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, len(field.Names))
+		for i, n := range field.Names {
+			names[i] = n.Name
+		}
+		return names
+	}
+	expr := field.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return []string{e.Name}
+	case *ast.SelectorExpr:
+		return []string{e.Sel.Name}
+	}
+	return nil
+}
+
+// receiverName returns the base type name of a method receiver
+func receiverName(recv *ast.FieldList) string {
+	if len(recv.List) == 0 {
+		return ""
+	}
+	expr := recv.List[0].Type
+	for {
+		switch e := expr.(type) {
+		case *ast.StarExpr:
+			expr = e.X
+		case *ast.IndexExpr:
+			expr = e.X
+		case *ast.IndexListExpr:
+			expr = e.X
+		case *ast.ParenExpr:
+			expr = e.X
+		case *ast.Ident:
+			return e.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// typeString prints a type expression without parameter names, so renaming a parameter is not a change
+func typeString(fset *token.FileSet, expr ast.Expr) string {
+	if fn, ok := expr.(*ast.FuncType); ok {
+		stripped := &ast.FuncType{TypeParams: fn.TypeParams, Params: unnamedFields(fn.Params), Results: unnamedFields(fn.Results)}
+		expr = stripped
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, expr); err != nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(buf.String()), " ")
+}
+
+// unnamedFields repeats each field type once per name and drops the names
+func unnamedFields(fields *ast.FieldList) *ast.FieldList {
+	if fields == nil {
+		return nil
+	}
+	unnamed := &ast.FieldList{}
+	for _, field := range fields.List {
+		for range max(len(field.Names), 1) {
+			unnamed.List = append(unnamed.List, &ast.Field{Type: field.Type})
+		}
+	}
+	return unnamed
+}
+
+// isPublicGoFile reports whether file is non-test Go source outside internal and testdata directories
+func isPublicGoFile(file string) bool {
+	if !strings.HasSuffix(file, ".go") || strings.HasSuffix(file, "_test.go") {
+		return false
+	}
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == "internal" || dir == "testdata" || dir == "vendor" {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether file matches one of the globs
+func matchesAny(globs []string, file string) bool {
+	for _, glob := range globs {
+		if MatchGlob(glob, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakingPromptSection describes the detected breaking changes for the prompt
+func BreakingPromptSection(changes []BreakingChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = "- " + change.String()
+	}
+	return "These changes to the exported Go API likely break users; mark the summary line with \"!\" and describe them in a \"BREAKING CHANGE:\" footer:\n" + strings.Join(lines, "\n")
+}
+
+// ApplyBreaking marks the summary line with "!" (when it has a type prefix) and adds a
+// BREAKING CHANGE footer unless the message already has one
+func ApplyBreaking(msg string, changes []BreakingChange) string {
+	if len(changes) == 0 {
+		return msg
+	}
+
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	if m := scopedPrefixPattern.FindStringSubmatchIndex(subject); m != nil && m[6] == m[7] {
+		subject = subject[:m[6]] + "!" + subject[m[6]:]
+	}
+	msg = subject
+	if hasBody {
+		msg += "\n" + body
+	}
+
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, breakingFooter+": ") || strings.HasPrefix(line, "BREAKING-CHANGE: ") {
+			return msg
+		}
+	}
+
+	descriptions := make([]string, 0, maxBreakingFooter+1)
+	for i, change := range changes {
+		if i == maxBreakingFooter {
+			descriptions = append(descriptions, fmt.Sprintf("and %d more", len(changes)-maxBreakingFooter))
+			break
+		}
+		descriptions = append(descriptions, change.String())
+	}
+	return addFooter(msg, footerLine(breakingFooter, strings.Join(descriptions, "; ")), breakingFooter+": ")
+}
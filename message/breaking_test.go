@@ -0,0 +1,183 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+func TestCompareGoAPI(t *testing.T) {
+	oldSources := map[string]string{
+		"api/api.go": `package api
+
+type Client struct {
+	Endpoint string
+	Timeout  int
+	retries  int
+}
+
+type Store interface {
+	Get(key string) (string, error)
+}
+
+const Version = "1"
+
+func New(endpoint string) *Client { return nil }
+
+func Load(path string) error { return nil }
+
+func (c *Client) Do(req string) error { return nil }
+
+func helper() {}
+`,
+		"api/moved.go": `package api
+
+func Moved() {}
+`,
+	}
+	newSources := map[string]string{
+		"api/api.go": `package api
+
+type Client struct {
+	Endpoint string
+	Retries  int
+}
+
+type Store interface {
+	Get(name string) (string, error)
+	Delete(key string) error
+}
+
+func New(url string) *Client { return nil }
+
+func Load(path string, strict bool) error { return nil }
+
+func (c *Client) Do(req string) error { return nil }
+
+func Moved() {}
+
+func Added() {}
+`,
+	}
+
+	changes := CompareGoAPI("api", oldSources, newSources)
+
+	want := []string{
+		"changed function Load from func(string) error to func(string, bool) error",
+		"removed exported field Client.Timeout",
+		"added method Store.Delete to interface Store",
+		"removed exported constant Version",
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected %d changes, got %d: %v", len(want), len(changes), changes)
+	}
+	for _, w := range want {
+		found := false
+		for _, change := range changes {
+			if change.Description == w && change.Package == "api" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected change %q, got %v", w, changes)
+		}
+	}
+}
+
+func TestCompareGoAPISkipsMainPackages(t *testing.T) {
+	oldSources := map[string]string{"main.go": "package main\n\nfunc Run() {}\n"}
+	if changes := CompareGoAPI(".", oldSources, nil); len(changes) != 0 {
+		t.Errorf("Expected no changes for a main package, got %v", changes)
+	}
+}
+
+func TestIsPublicGoFile(t *testing.T) {
+	tests := map[string]bool{
+		"config/types.go":        true,
+		"config/types_test.go":   false,
+		"internal/cache/lru.go":  false,
+		"config/testdata/x.go":   false,
+		"README.md":              false,
+		"cmd/tool/internal/a.go": false,
+	}
+	for file, want := range tests {
+		if got := isPublicGoFile(file); got != want {
+			t.Errorf("isPublicGoFile(%q) = %v, want %v", file, got, want)
+		}
+	}
+}
+
+func TestDetectsBreaking(t *testing.T) {
+	enabled := config.BreakingConfig{Detect: true}
+	tests := []struct {
+		name string
+		bc   config.BreakingConfig
+		op   *git.Operation
+		want bool
+	}{
+		{"commit", enabled, nil, true},
+		{"disabled", config.BreakingConfig{}, nil, false},
+		{"merge", enabled, &git.Operation{Kind: git.OperationMerge}, false},
+		{"revert", enabled, &git.Operation{Kind: git.OperationRevert}, true},
+	}
+	for _, tt := range tests {
+		if got := detectsBreaking(tt.bc, tt.op); got != tt.want {
+			t.Errorf("%s: detectsBreaking = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyBreaking(t *testing.T) {
+	changes := []BreakingChange{{Package: "config", Symbol: "Load", Description: "removed exported function Load"}}
+
+	tests := []struct {
+		name string
+		msg  string
+		want string
+	}{
+		{
+			name: "marks prefix and adds footer",
+			msg:  "feat(config): replace Load\n\nUse LoadFile instead.",
+			want: "feat(config)!: replace Load\n\nUse LoadFile instead.\n\nBREAKING CHANGE: config: removed exported function Load",
+		},
+		{
+			name: "appends to existing footers",
+			msg:  "feat: replace Load\n\nRefs: ABC-1",
+			want: "feat!: replace Load\n\nRefs: ABC-1\nBREAKING CHANGE: config: removed exported function Load",
+		},
+		{
+			name: "keeps the model's footer",
+			msg:  "feat!: replace Load\n\nBREAKING CHANGE: Load is gone",
+			want: "feat!: replace Load\n\nBREAKING CHANGE: Load is gone",
+		},
+		{
+			name: "plain summary line",
+			msg:  "Replace Load",
+			want: "Replace Load\n\nBREAKING CHANGE: config: removed exported function Load",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyBreaking(tt.msg, changes); got != tt.want {
+				t.Errorf("ApplyBreaking() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := ApplyBreaking("feat: add", nil); got != "feat: add" {
+		t.Errorf("ApplyBreaking() without changes = %q", got)
+	}
+}
+
+func TestApplyBreakingLimitsFooter(t *testing.T) {
+	var changes []BreakingChange
+	for _, name := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		changes = append(changes, BreakingChange{Package: "api", Symbol: name, Description: "removed exported function " + name})
+	}
+	got := ApplyBreaking("feat: remove", changes)
+	if !strings.HasSuffix(got, "; and 2 more") {
+		t.Errorf("Expected the footer to be limited, got %q", got)
+	}
+}
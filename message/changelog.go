@@ -0,0 +1,216 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// UnreleasedVersion is the Keep a Changelog section of changes that are not released yet
+const UnreleasedVersion = "Unreleased"
+
+// changelogHeader starts a new CHANGELOG.md
+const changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// versionHeadingPattern matches a Keep a Changelog version heading and captures the version
+var versionHeadingPattern = regexp.MustCompile(`(?m)^## \[([^\]]+)\]`)
+
+// ChangelogEntry is a single commit in the changelog
+type ChangelogEntry struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+}
+
+// String renders the entry as a Markdown list item
+func (e ChangelogEntry) String() string {
+	var sb strings.Builder
+	sb.WriteString("- ")
+	if e.Scope != "" {
+		sb.WriteString("**" + e.Scope + ":** ")
+	}
+	sb.WriteString(e.Description)
+	if e.Hash != "" {
+		sb.WriteString(" (" + e.Hash + ")")
+	}
+	return sb.String()
+}
+
+// ChangelogGroup is a changelog subsection such as "New feature"
+type ChangelogGroup struct {
+	Title   string
+	Entries []ChangelogEntry
+}
+
+// ParseChangelogEntry parses the summary line and footers of a commit message
+func ParseChangelogEntry(hash, msg string) ChangelogEntry {
+	subject, body, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	entry := ChangelogEntry{Hash: hash, Description: strings.TrimSpace(subject)}
+	if m := scopedPrefixPattern.FindStringSubmatch(subject); m != nil {
+		entry.Type = m[1]
+		entry.Scope = m[2]
+		entry.Breaking = m[3] == "!"
+		_, entry.Description = splitLeadingEmoji(strings.TrimSpace(subject[len(m[0]):]))
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.HasPrefix(line, breakingFooter+": ") || strings.HasPrefix(line, "BREAKING-CHANGE: ") {
+			entry.Breaking = true
+		}
+	}
+	return entry
+}
+
+// GroupCommits groups commits by type in the order of the configured prefixes, titled with
+// their descriptions in the given language. Breaking changes come first and commits of
+// unknown types last.
+func GroupCommits(commits []git.Commit, prefixes []config.SemanticReleasePrefix, lang string) []ChangelogGroup {
+	ja := config.NormalizeLangCode(lang) == "ja"
+	breaking := ChangelogGroup{Title: "Breaking changes"}
+	other := ChangelogGroup{Title: "Other"}
+	if ja {
+		breaking.Title = "破壊的変更"
+		other.Title = "その他"
+	}
+
+	groups := make([]ChangelogGroup, len(prefixes))
+	index := map[string]int{}
+	for i, p := range prefixes {
+		title := p.DescriptionEN
+		if ja && p.DescriptionJA != "" {
+			title = p.DescriptionJA
+		}
+		if title == "" {
+			title = p.Type
+		}
+		groups[i].Title = title
+		index[p.Type] = i
+	}
+
+	for _, commit := range commits {
+		entry := ParseChangelogEntry(commit.Hash, commit.Message)
+		if entry.Breaking {
+			breaking.Entries = append(breaking.Entries, entry)
+		}
+		if i, ok := index[entry.Type]; ok {
+			groups[i].Entries = append(groups[i].Entries, entry)
+		} else {
+			other.Entries = append(other.Entries, entry)
+		}
+	}
+
+	var result []ChangelogGroup
+	for _, group := range append(append([]ChangelogGroup{breaking}, groups...), other) {
+		if len(group.Entries) > 0 {
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// RenderGroups renders the groups as Keep a Changelog subsections
+func RenderGroups(groups []ChangelogGroup) string {
+	var sections []string
+	for _, group := range groups {
+		lines := []string{"### " + group.Title, ""}
+		for _, entry := range group.Entries {
+			lines = append(lines, entry.String())
+		}
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// ChangelogSection renders a version section; the date is omitted for Unreleased
+func ChangelogSection(version, date, body string) string {
+	heading := fmt.Sprintf("## [%s]", version)
+	if version != UnreleasedVersion && date != "" {
+		heading += " - " + date
+	}
+	return heading + "\n\n" + strings.TrimSpace(body) + "\n"
+}
+
+// UpdateChangelog replaces the section of the same version in a CHANGELOG.md, or inserts it
+// above the released versions (below Unreleased). An empty changelog gets the standard header.
+func UpdateChangelog(existing, version, section string) string {
+	if strings.TrimSpace(existing) == "" {
+		return changelogHeader + "\n" + section
+	}
+
+	headings := versionHeadingPattern.FindAllStringSubmatchIndex(existing, -1)
+	for i, h := range headings {
+		if existing[h[2]:h[3]] != version {
+			continue
+		}
+		end := len(existing)
+		if i+1 < len(headings) {
+			end = headings[i+1][0]
+		} else if idx := strings.Index(existing[h[1]:], "\n["); idx >= 0 {
+			// Link reference definitions at the end of the file are kept
+			end = h[1] + idx + 1
+		}
+		return existing[:h[0]] + section + separator(existing[end:]) + existing[end:]
+	}
+
+	for _, h := range headings {
+		if existing[h[2]:h[3]] == UnreleasedVersion && version != UnreleasedVersion {
+			continue
+		}
+		return existing[:h[0]] + section + "\n" + existing[h[0]:]
+	}
+	return strings.TrimRight(existing, "\n") + "\n\n" + section
+}
+
+// separator returns the blank line placed between a section and the rest of the file
+func separator(rest string) string {
+	if rest == "" {
+		return ""
+	}
+	return "\n"
+}
+
+// RewriteReleaseNotes asks the model to turn the grouped commit list into user-facing release notes
+func RewriteReleaseNotes(aiClient client.AIClient, notes string, lang string, extraPrompt string) (string, error) {
+	var sb strings.Builder
+	if config.NormalizeLangCode(lang) == "ja" {
+		sb.WriteString("以下はコミットを種類ごとにまとめた変更履歴です。利用者向けのリリースノートとして日本語で書き直してください。\n\n")
+		sb.WriteString("ルール:\n")
+		sb.WriteString("- \"### \" の見出しはそのまま残し、順序も変えない\n")
+		sb.WriteString("- 各項目は利用者から見た変化を1行で説明する。重複する項目はまとめる\n")
+		sb.WriteString("- テスト、CI、リファクタリングなど利用者に影響しない項目は省き、項目がなくなった見出しは削除する\n")
+		sb.WriteString("- 項目末尾のコミットハッシュは残す\n")
+		sb.WriteString("- 出力は Markdown の見出しと箇条書きのみ。コードブロックで囲まず、説明も付けない\n")
+	} else {
+		sb.WriteString("Below is a changelog of commits grouped by type. Rewrite it into user-facing release notes.\n\n")
+		sb.WriteString("Rules:\n")
+		sb.WriteString("- Keep the \"### \" headings as they are and in the same order\n")
+		sb.WriteString("- Describe each item in one line from the user's point of view; merge duplicate items\n")
+		sb.WriteString("- Leave out items users do not notice (tests, CI, refactoring) and drop headings left without items\n")
+		sb.WriteString("- Keep the commit hashes at the end of the items\n")
+		sb.WriteString("- Output only the Markdown headings and lists, without code fences or explanations\n")
+	}
+	if strings.TrimSpace(extraPrompt) != "" {
+		sb.WriteString(fmt.Sprintf("\nAdditional instructions from user:\n%s\n", extraPrompt))
+	}
+	sb.WriteString("\nChangelog:\n" + notes + "\n")
+
+	response, err := aiClient.Complete(sb.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to rewrite release notes: %w", err)
+	}
+	response = stripCodeFence(response)
+	if response == "" {
+		return "", fmt.Errorf("failed to rewrite release notes: empty response")
+	}
+	return response, nil
+}
@@ -0,0 +1,122 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+func TestParseChangelogEntry(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want ChangelogEntry
+	}{
+		{"feat(api): :sparkles: add search", ChangelogEntry{Hash: "abc", Type: "feat", Scope: "api", Description: "add search"}},
+		{"fix!: drop v1 config", ChangelogEntry{Hash: "abc", Type: "fix", Description: "drop v1 config", Breaking: true}},
+		{"refactor: rename Load\n\nBREAKING CHANGE: Load is now LoadFile", ChangelogEntry{Hash: "abc", Type: "refactor", Description: "rename Load", Breaking: true}},
+		{"Update README", ChangelogEntry{Hash: "abc", Description: "Update README"}},
+	}
+	for _, tt := range tests {
+		if got := ParseChangelogEntry("abc", tt.msg); got != tt.want {
+			t.Errorf("ParseChangelogEntry(%q) = %+v, want %+v", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestGroupCommits(t *testing.T) {
+	prefixes := []config.SemanticReleasePrefix{
+		{Type: "feat", DescriptionEN: "New feature", DescriptionJA: "新機能追加"},
+		{Type: "fix", DescriptionEN: "Bug fix", DescriptionJA: "バグ修正"},
+		{Type: "docs", DescriptionEN: "Documentation only"},
+	}
+	commits := []git.Commit{
+		{Hash: "a1", Message: "fix: handle nil"},
+		{Hash: "b2", Message: "feat(api)!: replace endpoints"},
+		{Hash: "c3", Message: "Update README"},
+		{Hash: "d4", Message: "feat: add search"},
+	}
+
+	got := RenderGroups(GroupCommits(commits, prefixes, "english"))
+	want := `### Breaking changes
+
+- **api:** replace endpoints (b2)
+
+### New feature
+
+- **api:** replace endpoints (b2)
+- add search (d4)
+
+### Bug fix
+
+- handle nil (a1)
+
+### Other
+
+- Update README (c3)`
+	if got != want {
+		t.Errorf("RenderGroups() =\n%s\nwant\n%s", got, want)
+	}
+
+	for _, lang := range []string{"japanese", "ja", "jpn"} {
+		ja := GroupCommits(commits, prefixes, lang)
+		if ja[0].Title != "破壊的変更" || ja[1].Title != "新機能追加" {
+			t.Errorf("GroupCommits() %s titles = %q, %q", lang, ja[0].Title, ja[1].Title)
+		}
+	}
+}
+
+func TestChangelogSection(t *testing.T) {
+	if got := ChangelogSection("1.2.0", "2026-10-18", "### Bug fix\n\n- x\n"); got != "## [1.2.0] - 2026-10-18\n\n### Bug fix\n\n- x\n" {
+		t.Errorf("ChangelogSection() = %q", got)
+	}
+	if got := ChangelogSection(UnreleasedVersion, "2026-10-18", "- x"); !strings.HasPrefix(got, "## [Unreleased]\n") {
+		t.Errorf("ChangelogSection() for Unreleased = %q", got)
+	}
+}
+
+func TestUpdateChangelog(t *testing.T) {
+	existing := `# Changelog
+
+## [Unreleased]
+
+- pending
+
+## [1.0.0] - 2026-01-01
+
+- first
+
+[1.0.0]: https://example.com/v1.0.0
+`
+	t.Run("new file", func(t *testing.T) {
+		got := UpdateChangelog("", "1.0.0", "## [1.0.0] - 2026-01-01\n\n- first\n")
+		if !strings.HasPrefix(got, "# Changelog\n") || !strings.HasSuffix(got, "\n## [1.0.0] - 2026-01-01\n\n- first\n") {
+			t.Errorf("UpdateChangelog() = %q", got)
+		}
+	})
+
+	t.Run("insert below unreleased", func(t *testing.T) {
+		got := UpdateChangelog(existing, "1.1.0", "## [1.1.0] - 2026-02-01\n\n- second\n")
+		want := strings.Replace(existing, "## [1.0.0]", "## [1.1.0] - 2026-02-01\n\n- second\n\n## [1.0.0]", 1)
+		if got != want {
+			t.Errorf("UpdateChangelog() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("replace unreleased", func(t *testing.T) {
+		got := UpdateChangelog(existing, UnreleasedVersion, "## [Unreleased]\n\n- replaced\n")
+		want := strings.Replace(existing, "- pending", "- replaced", 1)
+		if got != want {
+			t.Errorf("UpdateChangelog() =\n%s\nwant\n%s", got, want)
+		}
+	})
+
+	t.Run("replace last section keeps links", func(t *testing.T) {
+		got := UpdateChangelog(existing, "1.0.0", "## [1.0.0] - 2026-01-02\n\n- redone\n")
+		want := strings.Replace(existing, "## [1.0.0] - 2026-01-01\n\n- first\n", "## [1.0.0] - 2026-01-02\n\n- redone\n", 1)
+		if got != want {
+			t.Errorf("UpdateChangelog() =\n%s\nwant\n%s", got, want)
+		}
+	})
+}
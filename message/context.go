@@ -0,0 +1,261 @@
+package message
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+const (
+	// maxReadmeSummary limits the README summary in characters
+	maxReadmeSummary = 500
+	// maxFunctionContext limits the length of a single hunk header
+	maxFunctionContext = 100
+)
+
+// readmeFiles are the README names looked up at the repository root, in order
+var readmeFiles = []string{"README.md", "README.en.md", "README.rst", "README.txt", "README"}
+
+// ContextSection is a piece of repository context added to the prompt next to the diff
+type ContextSection struct {
+	Title  string
+	Body   string
+	Tokens int
+}
+
+// contextProvider builds one kind of context section
+type contextProvider struct {
+	Title   string
+	Enabled func(cc config.ContextConfig) bool
+	Build   func(cc config.ContextConfig, diff string, files []string) (string, error)
+}
+
+// contextProviders are tried in order, so earlier ones win when the token budget is tight
+var contextProviders = []contextProvider{
+	{Title: "Diff stat", Enabled: func(cc config.ContextConfig) bool { return cc.Stat }, Build: statContext},
+	{Title: "Changed functions", Enabled: func(cc config.ContextConfig) bool { return cc.Functions }, Build: functionsContext},
+	{Title: "Packages", Enabled: func(cc config.ContextConfig) bool { return cc.Packages }, Build: packagesContext},
+	{Title: "Recent commits touching these files", Enabled: func(cc config.ContextConfig) bool { return cc.RecentCommits > 0 }, Build: recentCommitsContext},
+	{Title: "Project summary (README)", Enabled: func(cc config.ContextConfig) bool { return cc.Readme }, Build: readmeContext},
+}
+
+// EstimateTokens approximates the number of tokens of s (about four characters per token)
+func EstimateTokens(s string) int {
+	return (utf8.RuneCountInString(s) + 3) / 4
+}
+
+// BuildContext returns the enabled context sections that fit in the remaining token budget
+// (0 = unlimited) and a description of the sections that were left out
+func BuildContext(cc config.ContextConfig, diff string, files []string, remaining int) ([]ContextSection, []string) {
+	var sections []ContextSection
+	var skipped []string
+	for _, provider := range contextProviders {
+		if !provider.Enabled(cc) {
+			continue
+		}
+
+		body, err := provider.Build(cc, diff, files)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", provider.Title, err))
+			continue
+		}
+		if strings.TrimSpace(body) == "" {
+			continue
+		}
+
+		section := ContextSection{Title: provider.Title, Body: body}
+		section.Tokens = EstimateTokens(section.Title + ":\n" + section.Body + "\n\n")
+		if cc.TokenBudget > 0 {
+			if section.Tokens > remaining {
+				skipped = append(skipped, fmt.Sprintf("%s (%d tokens, %d left in budget)", provider.Title, section.Tokens, max(remaining, 0)))
+				continue
+			}
+			remaining -= section.Tokens
+		}
+		sections = append(sections, section)
+	}
+	return sections, skipped
+}
+
+// statContext returns the diffstat of the staged changes
+func statContext(cc config.ContextConfig, diff string, files []string) (string, error) {
+	return git.GetStagedStat()
+}
+
+// functionsContext lists the enclosing function of each hunk, as found by git in the hunk headers
+func functionsContext(cc config.ContextConfig, diff string, files []string) (string, error) {
+	var order []string
+	functions := map[string][]string{}
+	seen := map[string]bool{}
+
+	file := ""
+	// The "---" and "+++" lines are file headers only before the first hunk of a file; in a
+	// hunk they are removed or added lines such as "-- comment"
+	header := false
+	scanner := bufio.NewScanner(strings.NewReader(diff))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file, header = "", true
+		case header && strings.HasPrefix(line, "--- "):
+			file = strings.TrimPrefix(strings.TrimPrefix(line, "--- "), "a/")
+		case header && strings.HasPrefix(line, "+++ "):
+			// Deleted files keep the path of the "---" line
+			if path := strings.TrimPrefix(line, "+++ "); path != "/dev/null" {
+				file = strings.TrimPrefix(path, "b/")
+			}
+		case strings.HasPrefix(line, "@@ ") && file != "":
+			header = false
+			// @@ -10,6 +10,8 @@ func (c *Config) BuildPrompt(...) string {
+			parts := strings.SplitN(line, "@@", 3)
+			if len(parts) < 3 {
+				continue
+			}
+			function := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(parts[2]), "{"))
+			if function == "" || seen[file+"\x00"+function] {
+				continue
+			}
+			if utf8.RuneCountInString(function) > maxFunctionContext {
+				function = string([]rune(function)[:maxFunctionContext]) + "..."
+			}
+			seen[file+"\x00"+function] = true
+			if _, ok := functions[file]; !ok {
+				order = append(order, file)
+			}
+			functions[file] = append(functions[file], function)
+		}
+	}
+
+	var sb strings.Builder
+	for _, f := range order {
+		sb.WriteString(fmt.Sprintf("%s:\n", f))
+		for _, function := range functions[f] {
+			sb.WriteString(fmt.Sprintf("  - %s\n", function))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), scanner.Err()
+}
+
+// packagesContext lists the module (and Go package) of each staged file
+func packagesContext(cc config.ContextConfig, diff string, files []string) (string, error) {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+
+	lines := map[string][]string{}
+	var modules []string
+	for _, file := range files {
+		module := FindModule(root, file)
+		if module == nil {
+			continue
+		}
+		key := fmt.Sprintf("%s module %s (%s)", module.Kind, module.Name, module.Dir)
+		if _, ok := lines[key]; !ok {
+			modules = append(modules, key)
+		}
+		if pkg := GoPackageName(root, file); pkg != "" {
+			file = fmt.Sprintf("%s (package %s)", file, pkg)
+		}
+		lines[key] = append(lines[key], file)
+	}
+	sort.Strings(modules)
+
+	var sb strings.Builder
+	for _, module := range modules {
+		sb.WriteString(module + ":\n")
+		for _, file := range lines[module] {
+			sb.WriteString("  - " + file + "\n")
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n"), nil
+}
+
+// recentCommitsContext lists the subjects of recent commits touching the staged files
+func recentCommitsContext(cc config.ContextConfig, diff string, files []string) (string, error) {
+	if len(files) == 0 {
+		return "", nil
+	}
+	subjects, err := git.GetRecentCommitSubjects(git.LogOptions{Limit: cc.RecentCommits, Paths: files})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(subjects, "\n"), nil
+}
+
+// readmeContext returns the title and first paragraph of the README at the repository root
+func readmeContext(cc config.ContextConfig, diff string, files []string) (string, error) {
+	root, err := git.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	for _, name := range readmeFiles {
+		data, err := os.ReadFile(filepath.Join(root, name))
+		if err == nil {
+			return SummarizeReadme(string(data)), nil
+		}
+	}
+	return "", nil
+}
+
+// SummarizeReadme returns the first heading and the first prose paragraph of a README,
+// skipping badges, images, HTML and code blocks
+func SummarizeReadme(readme string) string {
+	var title string
+	var paragraph []string
+	inCode := false
+	for _, line := range strings.Split(readme, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			continue
+		}
+
+		switch {
+		case trimmed == "":
+			if len(paragraph) > 0 {
+				return joinSummary(title, paragraph)
+			}
+		case strings.HasPrefix(trimmed, "#"):
+			if len(paragraph) > 0 {
+				return joinSummary(title, paragraph)
+			}
+			if title == "" {
+				title = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			}
+		case strings.HasPrefix(trimmed, "!["), strings.HasPrefix(trimmed, "[!["), strings.HasPrefix(trimmed, "<"),
+			strings.HasPrefix(trimmed, "|"), strings.HasPrefix(trimmed, "==="), strings.HasPrefix(trimmed, "---"):
+			continue
+		default:
+			paragraph = append(paragraph, trimmed)
+		}
+	}
+	return joinSummary(title, paragraph)
+}
+
+// joinSummary formats the README title and paragraph, limited to maxReadmeSummary characters
+func joinSummary(title string, paragraph []string) string {
+	summary := strings.Join(paragraph, " ")
+	if utf8.RuneCountInString(summary) > maxReadmeSummary {
+		summary = string([]rune(summary)[:maxReadmeSummary]) + "..."
+	}
+	if title == "" {
+		return summary
+	}
+	if summary == "" {
+		return title
+	}
+	return title + ": " + summary
+}
@@ -0,0 +1,71 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+const contextTestDiff = `diff --git a/config/loader.go b/config/loader.go
+--- a/config/loader.go
++++ b/config/loader.go
+@@ -10,6 +10,8 @@ func Load(configPath string) (*Config, error) {
++	// comment
+@@ -90,3 +92,4 @@ func (c *Config) BuildPrompt(lang string, branch string, diff string) string {
++	x := 1
+@@ -95,3 +98,4 @@ func (c *Config) BuildPrompt(lang string, branch string, diff string) string {
++	y := 2
+diff --git a/README.md b/README.md
+--- a/README.md
++++ b/README.md
+@@ -1,3 +1,4 @@
++new line
+diff --git a/old/legacy.go b/old/legacy.go
+deleted file mode 100644
+--- a/old/legacy.go
++++ /dev/null
+@@ -1,5 +0,0 @@ package old
+-func Legacy() {}
+diff --git a/db/schema.sql b/db/schema.sql
+--- a/db/schema.sql
++++ b/db/schema.sql
+@@ -1,4 +1,3 @@ CREATE TABLE users (
+--- old comment
++++ new comment
+@@ -9,3 +8,3 @@ CREATE TABLE posts (
+-  body text
+`
+
+func TestFunctionsContext(t *testing.T) {
+	got, err := functionsContext(config.ContextConfig{}, contextTestDiff, nil)
+	if err != nil {
+		t.Fatalf("functionsContext failed: %v", err)
+	}
+	want := "config/loader.go:\n  - func Load(configPath string) (*Config, error)\n  - func (c *Config) BuildPrompt(lang string, branch string, diff string) string\nold/legacy.go:\n  - package old\ndb/schema.sql:\n  - CREATE TABLE users (\n  - CREATE TABLE posts ("
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestBuildContextRespectsTokenBudget(t *testing.T) {
+	cc := config.ContextConfig{Functions: true, TokenBudget: 10}
+
+	sections, skipped := BuildContext(cc, contextTestDiff, nil, 10)
+	if len(sections) != 0 || len(skipped) != 1 || !strings.HasPrefix(skipped[0], "Changed functions") {
+		t.Errorf("Expected the section to be skipped, got %v, %v", sections, skipped)
+	}
+
+	cc.TokenBudget = 0
+	sections, skipped = BuildContext(cc, contextTestDiff, nil, 0)
+	if len(sections) != 1 || len(skipped) != 0 {
+		t.Errorf("Expected the section with an unlimited budget, got %v, %v", sections, skipped)
+	}
+}
+
+func TestSummarizeReadme(t *testing.T) {
+	readme := "# gcm\n\n[![CI](https://example.com/badge.svg)](https://example.com)\n\nGenerate commit messages\nwith AI.\n\n## Install\n\ngo install ...\n"
+	if got := SummarizeReadme(readme); got != "gcm: Generate commit messages with AI." {
+		t.Errorf("Unexpected summary %q", got)
+	}
+}
@@ -0,0 +1,209 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// leadingShortcodePattern matches an emoji shortcode at the start of the summary text
+var leadingShortcodePattern = regexp.MustCompile(`^:[a-z0-9_+\-]+:\s*`)
+
+// gitmojis maps the gitmoji shortcodes to their unicode emoji
+var gitmojis = map[string]string{
+	":adhesive_bandage:":          "🩹",
+	":alembic:":                   "⚗️",
+	":alien:":                     "👽️",
+	":ambulance:":                 "🚑️",
+	":arrow_down:":                "⬇️",
+	":arrow_up:":                  "⬆️",
+	":art:":                       "🎨",
+	":beers:":                     "🍻",
+	":bento:":                     "🍱",
+	":bookmark:":                  "🔖",
+	":boom:":                      "💥",
+	":bricks:":                    "🧱",
+	":bug:":                       "🐛",
+	":building_construction:":     "🏗️",
+	":bulb:":                      "💡",
+	":busts_in_silhouette:":       "👥",
+	":camera_flash:":              "📸",
+	":card_file_box:":             "🗃️",
+	":chart_with_upwards_trend:":  "📈",
+	":children_crossing:":         "🚸",
+	":closed_lock_with_key:":      "🔐",
+	":clown_face:":                "🤡",
+	":coffin:":                    "⚰️",
+	":construction:":              "🚧",
+	":construction_worker:":       "👷",
+	":dizzy:":                     "💫",
+	":egg:":                       "🥚",
+	":fire:":                      "🔥",
+	":globe_with_meridians:":      "🌐",
+	":goal_net:":                  "🥅",
+	":green_heart:":               "💚",
+	":hammer:":                    "🔨",
+	":heavy_minus_sign:":          "➖",
+	":heavy_plus_sign:":           "➕",
+	":iphone:":                    "📱",
+	":label:":                     "🏷️",
+	":lipstick:":                  "💄",
+	":lock:":                      "🔒️",
+	":loud_sound:":                "🔊",
+	":mag:":                       "🔍️",
+	":memo:":                      "📝",
+	":money_with_wings:":          "💸",
+	":monocle_face:":              "🧐",
+	":mute:":                      "🔇",
+	":necktie:":                   "👔",
+	":package:":                   "📦️",
+	":page_facing_up:":            "📄",
+	":passport_control:":          "🛂",
+	":pencil2:":                   "✏️",
+	":poop:":                      "💩",
+	":pushpin:":                   "📌",
+	":recycle:":                   "♻️",
+	":rewind:":                    "⏪️",
+	":rocket:":                    "🚀",
+	":rotating_light:":            "🚨",
+	":safety_vest:":               "🦺",
+	":see_no_evil:":               "🙈",
+	":seedling:":                  "🌱",
+	":sparkles:":                  "✨",
+	":speech_balloon:":            "💬",
+	":stethoscope:":               "🩺",
+	":tada:":                      "🎉",
+	":technologist:":              "🧑‍💻",
+	":test_tube:":                 "🧪",
+	":thread:":                    "🧵",
+	":triangular_flag_on_post:":   "🚩",
+	":truck:":                     "🚚",
+	":twisted_rightwards_arrows:": "🔀",
+	":wastebasket:":               "🗑️",
+	":wheelchair:":                "♿️",
+	":white_check_mark:":          "✅",
+	":wrench:":                    "🔧",
+	":zap:":                       "⚡️",
+}
+
+// EmojiFor returns the configured emoji of a type in the given style, or "" if there is none
+func EmojiFor(prefixes []config.SemanticReleasePrefix, typ string, style string) string {
+	for _, p := range prefixes {
+		if p.Type != typ || p.Emoji == "" {
+			continue
+		}
+		switch style {
+		case EmojiShortcode:
+			return toShortcode(p.Emoji)
+		case EmojiUnicode:
+			return toUnicode(p.Emoji)
+		}
+		return ""
+	}
+	return ""
+}
+
+// ApplyEmoji rewrites the emoji after the type prefix of the summary line to the emoji
+// configured for the type, in the given style. An empty style leaves the message unchanged,
+// and so do summary lines without a type prefix.
+func ApplyEmoji(msg string, prefixes []config.SemanticReleasePrefix, style string) string {
+	if style == "" {
+		return msg
+	}
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	m := scopedPrefixPattern.FindStringSubmatchIndex(subject)
+	if m == nil {
+		return msg
+	}
+
+	prefix := subject[:m[1]]
+	_, text := splitLeadingEmoji(subject[m[1]:])
+	if emoji := EmojiFor(prefixes, subject[m[2]:m[3]], style); emoji != "" {
+		text = emoji + " " + text
+	}
+	subject = prefix + text
+
+	if !hasBody {
+		return subject
+	}
+	return subject + "\n" + body
+}
+
+// LintEmoji reports a problem when the emoji after the type prefix of the summary line is
+// not the one configured for the type. Messages without a type prefix or emoji pass.
+func LintEmoji(msg string, prefixes []config.SemanticReleasePrefix) []string {
+	subject, _, _ := strings.Cut(msg, "\n")
+	m := scopedPrefixPattern.FindStringSubmatchIndex(subject)
+	if m == nil {
+		return nil
+	}
+	emoji, _ := splitLeadingEmoji(subject[m[1]:])
+	if emoji == "" {
+		return nil
+	}
+
+	typ := subject[m[2]:m[3]]
+	expected := EmojiFor(prefixes, typ, EmojiShortcode)
+	switch {
+	case expected == "":
+		return []string{fmt.Sprintf("emoji %s is used but type %q has no emoji configured", emoji, typ)}
+	case normalizeEmoji(emoji) != normalizeEmoji(expected):
+		return []string{fmt.Sprintf("emoji %s does not match type %q (expected %s or %s)", emoji, typ, expected, toUnicode(expected))}
+	}
+	return nil
+}
+
+// splitLeadingEmoji splits a shortcode or unicode emoji (and the following spaces) off the start of s
+func splitLeadingEmoji(s string) (string, string) {
+	if loc := leadingShortcodePattern.FindStringIndex(s); loc != nil {
+		return strings.TrimSpace(s[:loc[1]]), s[loc[1]:]
+	}
+
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !isEmojiRune(r) {
+			break
+		}
+		end += size
+	}
+	if end == 0 {
+		return "", s
+	}
+	return s[:end], strings.TrimLeft(s[end:], " ")
+}
+
+// isEmojiRune reports whether r belongs to an emoji, including joiners and variation selectors
+func isEmojiRune(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2300 && r <= 0x23FF) || (r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x2B00 && r <= 0x2BFF) || r == 0x200D || r == 0xFE0F
+}
+
+// toShortcode returns the shortcode of a unicode gitmoji, or emoji itself if it is unknown
+func toShortcode(emoji string) string {
+	if leadingShortcodePattern.MatchString(emoji) {
+		return emoji
+	}
+	for shortcode, unicode := range gitmojis {
+		if normalizeEmoji(unicode) == normalizeEmoji(emoji) {
+			return shortcode
+		}
+	}
+	return emoji
+}
+
+// toUnicode returns the unicode emoji of a gitmoji shortcode, or emoji itself if it is unknown
+func toUnicode(emoji string) string {
+	if unicode, ok := gitmojis[emoji]; ok {
+		return unicode
+	}
+	return emoji
+}
+
+// normalizeEmoji maps an emoji to a comparable form: its unicode without variation selectors
+func normalizeEmoji(emoji string) string {
+	return strings.ReplaceAll(toUnicode(emoji), "\uFE0F", "")
+}
@@ -0,0 +1,61 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+var testPrefixes = []config.SemanticReleasePrefix{
+	{Type: "feat", Emoji: ":sparkles:"},
+	{Type: "fix", Emoji: "🐛"},
+	{Type: "chore"},
+}
+
+func TestApplyEmoji(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		style string
+		want  string
+	}{
+		{"shortcode", "feat: add search\n\n- body", EmojiShortcode, "feat: :sparkles: add search\n\n- body"},
+		{"unicode", "feat(api): add search", EmojiUnicode, "feat(api): ✨ add search"},
+		{"unicode config to shortcode", "fix: handle nil", EmojiShortcode, "fix: :bug: handle nil"},
+		{"replace wrong emoji", "feat!: :bug: drop v1", EmojiShortcode, "feat!: :sparkles: drop v1"},
+		{"replace unicode emoji", "fix: ✨ handle nil", EmojiUnicode, "fix: 🐛 handle nil"},
+		{"none", "feat: :sparkles: add search", EmojiNone, "feat: add search"},
+		{"none unicode", "feat: ⚡️ add search", EmojiNone, "feat: add search"},
+		{"type without emoji", "chore: :wrench: bump", EmojiShortcode, "chore: bump"},
+		{"unset style", "feat: :bug: add search", "", "feat: :bug: add search"},
+		{"no prefix", "Add search", EmojiUnicode, "Add search"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyEmoji(tt.msg, testPrefixes, tt.style); got != tt.want {
+				t.Errorf("ApplyEmoji() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintEmoji(t *testing.T) {
+	tests := []struct {
+		msg      string
+		problems int
+	}{
+		{"feat: :sparkles: add search", 0},
+		{"feat: ✨ add search", 0},
+		{"fix: :bug: handle nil", 0},
+		{"feat: add search", 0},
+		{"Add search", 0},
+		{"feat: :bug: add search", 1},
+		{"fix: ✨ handle nil", 1},
+		{"chore: :wrench: bump", 1},
+	}
+	for _, tt := range tests {
+		if got := LintEmoji(tt.msg, testPrefixes); len(got) != tt.problems {
+			t.Errorf("LintEmoji(%q) = %v, want %d problems", tt.msg, got, tt.problems)
+		}
+	}
+}
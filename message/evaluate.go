@@ -0,0 +1,79 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// Summary line lengths used by Evaluate: longer than MaxSubjectLength is a problem, and every
+// character over PreferredSubjectLength costs a little so that shorter summaries win ties
+const (
+	MaxSubjectLength       = 72
+	PreferredSubjectLength = 50
+)
+
+// Evaluation is the verdict on a generated commit message
+type Evaluation struct {
+	// Score starts at 100 and drops with every problem; higher is better
+	Score    int
+	Problems []string
+}
+
+// Valid reports whether the message has no problems
+func (e Evaluation) Valid() bool {
+	return len(e.Problems) == 0
+}
+
+// Evaluate checks a generated commit message against the configured prefixes and the usual
+// commit message shape, and scores it for choosing among the answers of several providers
+func Evaluate(msg string, prefixes []config.SemanticReleasePrefix) Evaluation {
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return Evaluation{Problems: []string{"the message is empty"}}
+	}
+
+	e := Evaluation{Score: 100}
+	problem := func(penalty int, format string, args ...interface{}) {
+		e.Score -= penalty
+		e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+	}
+
+	// Without configured prefixes (e.g. the plain preset) messages have no type prefix
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	if len(prefixes) > 0 {
+		if m := scopedPrefixPattern.FindStringSubmatch(subject); m == nil {
+			problem(40, "the summary line has no type prefix")
+		} else if !hasPrefixType(prefixes, m[1]) {
+			problem(30, "type %q is not one of the configured prefixes", m[1])
+		}
+	}
+	if length := utf8.RuneCountInString(subject); length > MaxSubjectLength {
+		problem(20, "the summary line is %d characters long (max %d)", length, MaxSubjectLength)
+	} else if length > PreferredSubjectLength {
+		e.Score -= (length - PreferredSubjectLength) / 5
+	}
+	if hasBody && strings.TrimSpace(strings.SplitN(body, "\n", 2)[0]) != "" {
+		problem(10, "the summary line is not followed by a blank line")
+	}
+	if strings.Contains(msg, "```") {
+		problem(30, "the message contains a Markdown code fence")
+	}
+	for _, lint := range LintEmoji(msg, prefixes) {
+		problem(10, "%s", lint)
+	}
+	e.Score = max(e.Score, 0)
+	return e
+}
+
+// hasPrefixType reports whether typ is one of the configured prefixes
+func hasPrefixType(prefixes []config.SemanticReleasePrefix, typ string) bool {
+	for _, p := range prefixes {
+		if p.Type == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package message
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		problems int
+	}{
+		{"valid", "feat(api): add search\n\n- index titles", 0},
+		{"empty", "  \n", 1},
+		{"no prefix", "Add search", 1},
+		{"unknown type", "perf: speed up search", 1},
+		{"long summary", "fix: " + strings.Repeat("x", 80), 1},
+		{"no blank line", "feat: add search\n- index titles", 1},
+		{"code fence", "```\nfeat: add search\n```", 3},
+		{"wrong emoji", "feat: :bug: add search", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Evaluate(tt.msg, testPrefixes)
+			if len(e.Problems) != tt.problems {
+				t.Errorf("Evaluate(%q) problems = %v, want %d", tt.msg, e.Problems, tt.problems)
+			}
+			if e.Valid() != (tt.problems == 0) {
+				t.Errorf("Valid() = %v", e.Valid())
+			}
+		})
+	}
+}
+
+func TestEvaluateWithoutPrefixes(t *testing.T) {
+	if e := Evaluate("Add search to the header", nil); !e.Valid() || e.Score != 100 {
+		t.Errorf("expected a plain summary to be valid without prefixes, got %d %v", e.Score, e.Problems)
+	}
+	if e := Evaluate("Add search\n- index titles", nil); e.Valid() {
+		t.Error("expected a missing blank line to stay a problem without prefixes")
+	}
+}
+
+func TestEvaluatePrefersShorterSummaries(t *testing.T) {
+	short := Evaluate("feat: add search", testPrefixes)
+	long := Evaluate("feat: add a search box to the header of every page of the site", testPrefixes)
+	invalid := Evaluate("Add search", testPrefixes)
+
+	if short.Score != 100 {
+		t.Errorf("short score = %d, want 100", short.Score)
+	}
+	if !(short.Score > long.Score && long.Score > invalid.Score) {
+		t.Errorf("expected short > long > invalid, got %d, %d, %d", short.Score, long.Score, invalid.Score)
+	}
+}
@@ -5,40 +5,163 @@ import (
 	"strings"
 
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
 	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
 )
 
+// Options holds the optional inputs of GenerateWithOptions
+type Options struct {
+	// ExtraPrompt holds additional instructions from the user
+	ExtraPrompt string
+	// Context selects the repository context added next to the diff
+	Context config.ContextConfig
+	// Scopes controls the scope inferred from the changed paths
+	Scopes config.ScopesConfig
+	// Breaking controls the detection of breaking changes to the exported Go API
+	Breaking config.BreakingConfig
+	// Emoji is the emoji style of the summary line (see config.EmojiStyles); empty keeps the model's output
+	Emoji string
+	// Files and FilesWithStatus describe the changed files when the diff is not the staged
+	// changes (e.g. an existing commit); the staged files are used when Files is nil
+	Files           []string
+	FilesWithStatus string
+	// Commits are the messages of the commits being squashed into one, oldest first
+	Commits []string
+	// Operation is the merge, revert or cherry-pick the commit concludes, if any
+	Operation *git.Operation
+}
+
+// Result is the outcome of GenerateWithOptions
+type Result struct {
+	Message string
+	// Context lists the context sections that were sent to the model
+	Context []ContextSection
+	// SkippedContext describes enabled context sections that were left out
+	SkippedContext []string
+	// InputTokens is the estimated size of the diff and context sent to the model
+	InputTokens int
+	// Scope is the scope inferred from the changed paths
+	Scope ScopeInference
+	// Breaking lists the detected breaking changes to the exported Go API
+	Breaking []BreakingChange
+}
+
 // Generate generates a commit message based on the provided diff
 func Generate(aiClient client.AIClient, diff string, branch string, extraPrompt ...string) (string, error) {
+	cfg := config.Get()
+	opts := Options{Context: cfg.Context, Scopes: cfg.Scopes, Breaking: cfg.Breaking, Emoji: cfg.Defaults.Emoji}
+	op, err := git.GetOperation()
+	if err != nil {
+		return "", fmt.Errorf("failed to read the repository state: %w", err)
+	}
+	opts.Operation = op
+	if len(extraPrompt) > 0 {
+		opts.ExtraPrompt = extraPrompt[0]
+	}
+
+	result, err := GenerateWithOptions(aiClient, diff, branch, opts)
+	if err != nil {
+		return "", err
+	}
+	return result.Message, nil
+}
+
+// GenerateWithOptions generates a commit message with repository context and reports what was sent
+func GenerateWithOptions(aiClient client.AIClient, diff string, branch string, opts Options) (*Result, error) {
 	// If diff is empty, try to get more context from staged files
 	if strings.TrimSpace(diff) == "" {
-		return "", fmt.Errorf("no diff provided")
+		return nil, fmt.Errorf("no diff provided")
 	}
 
 	// Get the list of staged files with status for additional context
-	filesWithStatus, err := git.GetStagedFilesWithStatus()
-	if err != nil {
-		return "", fmt.Errorf("failed to get staged files: %w", err)
+	files, filesWithStatus := opts.Files, opts.FilesWithStatus
+	if files == nil {
+		var err error
+		if filesWithStatus, err = git.GetStagedFilesWithStatus(); err != nil {
+			return nil, fmt.Errorf("failed to get staged files: %w", err)
+		}
+		if files, err = git.GetStagedFiles(); err != nil {
+			return nil, fmt.Errorf("failed to get staged files: %w", err)
+		}
+	}
+
+	// Append any extra prompt instructions provided by the user
+	extra := ""
+	if strings.TrimSpace(opts.ExtraPrompt) != "" {
+		extra = fmt.Sprintf("\n\nAdditional instructions from user:\n%s", opts.ExtraPrompt)
+	}
+
+	// Infer the scope from the changed paths; nested modules need the repository root
+	result := &Result{}
+	root := ""
+	if opts.Scopes.Monorepo {
+		root, _ = git.GetRepoRoot()
+	}
+	result.Scope = InferScope(opts.Scopes, root, files)
+	scopeSection := result.Scope.PromptSection()
+
+	// Compare the exported Go API with HEAD
+	if detectsBreaking(opts.Breaking, opts.Operation) {
+		var err error
+		result.Breaking, err = DetectBreakingChanges(opts.Breaking, files)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect breaking changes: %w", err)
+		}
 	}
+	breakingSection := BreakingPromptSection(result.Breaking)
+	commitsSection := CommitsPromptSection(opts.Commits)
+	operationSection := OperationPromptSection(opts.Operation)
+
+	// The diff, the file list, the scope, the breaking changes, the combined commits, the operation
+	// and the user's instructions always go in; context sections fill the rest of the token budget
+	used := EstimateTokens(filesWithStatus + scopeSection + breakingSection + commitsSection + operationSection + diff + extra)
+	result.Context, result.SkippedContext = BuildContext(opts.Context, diff, files, opts.Context.TokenBudget-used)
 
 	// If we have a lot of files, we might want to include a summary
 	// in the prompt to help the AI generate a better commit message
+	var sb strings.Builder
 	if len(filesWithStatus) > 0 {
-		diff = fmt.Sprintf("Files changed:\n%s\n\nDiff:\n%s", filesWithStatus, diff)
+		sb.WriteString(fmt.Sprintf("Files changed:\n%s\n\n", filesWithStatus))
 	}
-
-	// Append any extra prompt instructions provided by the user
-	if len(extraPrompt) > 0 && strings.TrimSpace(extraPrompt[0]) != "" {
-		diff = fmt.Sprintf("%s\n\nAdditional instructions from user:\n%s", diff, extraPrompt[0])
+	if operationSection != "" {
+		sb.WriteString(fmt.Sprintf("Commit kind:\n%s\n\n", operationSection))
 	}
+	if scopeSection != "" {
+		sb.WriteString(fmt.Sprintf("Scope:\n%s\n\n", scopeSection))
+	}
+	if breakingSection != "" {
+		sb.WriteString(fmt.Sprintf("Breaking changes:\n%s\n\n", breakingSection))
+	}
+	if commitsSection != "" {
+		sb.WriteString(fmt.Sprintf("Commits being squashed (write one message for all of them):\n%s\n\n", commitsSection))
+	}
+	for _, section := range result.Context {
+		sb.WriteString(fmt.Sprintf("%s:\n%s\n\n", section.Title, section.Body))
+	}
+	if sb.Len() > 0 {
+		sb.WriteString("Diff:\n")
+	}
+	sb.WriteString(diff)
+	sb.WriteString(extra)
+	input := sb.String()
+	result.InputTokens = EstimateTokens(input)
 
 	// Generate the commit message using the AI client
-	commitMsg, err := aiClient.GenerateCommitMessage(diff, branch)
+	commitMsg, err := aiClient.GenerateCommitMessage(input, branch)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate commit message: %w", err)
+		return nil, fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	// Enforce the inferred scope on the summary line
+	if opts.Scopes.Enforce && result.Scope.Inferred() {
+		commitMsg = ApplyScope(commitMsg, result.Scope.Scope)
 	}
+	commitMsg = ApplyBreaking(commitMsg, result.Breaking)
+	commitMsg = ApplyOperation(commitMsg, opts.Operation)
+	commitMsg = ApplyEmoji(commitMsg, config.Get().SemanticReleasePrefixes, opts.Emoji)
 
-	return commitMsg, nil
+	result.Message = commitMsg
+	return result, nil
 }
 
 // ApplyCommitMessage applies the generated commit message using git commit
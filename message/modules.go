@@ -0,0 +1,91 @@
+package message
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	goModulePattern  = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	goPackagePattern = regexp.MustCompile(`(?m)^package\s+(\w+)`)
+	tomlNamePattern  = regexp.MustCompile(`(?m)^name\s*=\s*"([^"]+)"`)
+	moduleManifests  = []moduleManifest{
+		{File: "go.mod", Kind: "go", Name: func(data []byte) string { return firstSubmatch(goModulePattern, data) }},
+		{File: "package.json", Kind: "npm", Name: packageJSONName},
+		{File: "Cargo.toml", Kind: "rust", Name: func(data []byte) string { return firstSubmatch(tomlNamePattern, data) }},
+		{File: "pyproject.toml", Kind: "python", Name: func(data []byte) string { return firstSubmatch(tomlNamePattern, data) }},
+	}
+)
+
+// moduleManifest describes a file that marks the root of a module
+type moduleManifest struct {
+	File string
+	Kind string
+	Name func(data []byte) string
+}
+
+// Module is a project unit (Go module, npm package, ...) that files belong to
+type Module struct {
+	// Kind is go, npm, rust or python
+	Kind string
+	// Name is the module path or package name from the manifest
+	Name string
+	// Dir is the module directory relative to the repository root ("." for the root)
+	Dir string
+}
+
+// FindModule returns the innermost module containing file (relative to root), or nil if there is none
+func FindModule(root, file string) *Module {
+	dir := filepath.Dir(filepath.FromSlash(file))
+	for {
+		for _, manifest := range moduleManifests {
+			data, err := os.ReadFile(filepath.Join(root, dir, manifest.File))
+			if err != nil {
+				continue
+			}
+			name := manifest.Name(data)
+			if name == "" {
+				name = filepath.Base(filepath.Join(root, dir))
+			}
+			return &Module{Kind: manifest.Kind, Name: name, Dir: filepath.ToSlash(dir)}
+		}
+		if dir == "." || dir == string(filepath.Separator) {
+			return nil
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// GoPackageName returns the package clause of a Go file (relative to root), or "" if it cannot be read
+func GoPackageName(root, file string) string {
+	if !strings.HasSuffix(file, ".go") {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(file)))
+	if err != nil {
+		return ""
+	}
+	return firstSubmatch(goPackagePattern, data)
+}
+
+// packageJSONName returns the "name" field of a package.json
+func packageJSONName(data []byte) string {
+	var pkg struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return ""
+	}
+	return pkg.Name
+}
+
+// firstSubmatch returns the first capture group of the first match of re in data
+func firstSubmatch(re *regexp.Regexp, data []byte) string {
+	if m := re.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package message
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindModule(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                 "module example.com/app\n\ngo 1.23\n",
+		"cmd/tool/main.go":       "// Tool\npackage main\n",
+		"web/package.json":       `{"name": "@example/web", "version": "1.0.0"}`,
+		"web/src/index.ts":       "export {}\n",
+		"services/api/go.mod":    "module example.com/api\n",
+		"services/api/server.go": "package api\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	tests := []struct {
+		file string
+		want Module
+	}{
+		{"cmd/tool/main.go", Module{Kind: "go", Name: "example.com/app", Dir: "."}},
+		{"web/src/index.ts", Module{Kind: "npm", Name: "@example/web", Dir: "web"}},
+		{"services/api/server.go", Module{Kind: "go", Name: "example.com/api", Dir: "services/api"}},
+	}
+	for _, tt := range tests {
+		got := FindModule(root, tt.file)
+		if got == nil || *got != tt.want {
+			t.Errorf("FindModule(%q) = %+v, want %+v", tt.file, got, tt.want)
+		}
+	}
+
+	if pkg := GoPackageName(root, "cmd/tool/main.go"); pkg != "main" {
+		t.Errorf("Expected package main, got %q", pkg)
+	}
+	if FindModule(t.TempDir(), "a/b.txt") != nil {
+		t.Errorf("Expected no module outside of a project")
+	}
+}
@@ -0,0 +1,102 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// maxIncomingCommits limits the incoming commits of a merge listed in the prompt
+const maxIncomingCommits = 30
+
+// OperationPromptSection tells the model that the commit concludes a merge, revert or
+// cherry-pick, whose staged diff alone is misleading
+func OperationPromptSection(op *git.Operation) string {
+	if op == nil {
+		return ""
+	}
+
+	var lines []string
+	switch op.Kind {
+	case git.OperationMerge:
+		source := op.Branch
+		if source == "" {
+			source = shortHash(op.Hash)
+		}
+		lines = append(lines, fmt.Sprintf("This commit merges %s into the current branch. Summarize what the incoming branch brings as a whole instead of describing the diff, and start the summary line with \"Merge %s\".", source, source))
+		if len(op.Incoming) > 0 {
+			lines = append(lines, "Incoming commits:")
+			for i, subject := range op.Incoming {
+				if i == maxIncomingCommits {
+					lines = append(lines, fmt.Sprintf("- and %d more", len(op.Incoming)-maxIncomingCommits))
+					break
+				}
+				lines = append(lines, "- "+subject)
+			}
+		}
+		if len(op.Conflicts) > 0 {
+			lines = append(lines, "Conflicts were resolved in these files; list them in the body with how they were resolved:")
+			for _, path := range op.Conflicts {
+				lines = append(lines, "- "+path)
+			}
+		}
+	case git.OperationRevert:
+		lines = append(lines, fmt.Sprintf("This commit reverts commit %s %q. Use the revert type if the convention has one, repeat the reverted summary line in the summary, and keep the line \"This reverts commit %s.\" in the body.", shortHash(op.Hash), op.Subject, op.Hash))
+	case git.OperationCherryPick:
+		lines = append(lines, fmt.Sprintf("This commit cherry-picks commit %s %q. Describe the change the way the original commit does and keep the line \"(cherry picked from commit %s)\" at the end.", shortHash(op.Hash), op.Subject, op.Hash))
+	}
+	if op.Kind != git.OperationMerge && len(op.Conflicts) > 0 {
+		lines = append(lines, "Conflicts were resolved in: "+strings.Join(op.Conflicts, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ApplyOperation makes sure the message references the operation: the reverted commit,
+// the cherry-picked commit, or the files whose merge conflicts were resolved
+func ApplyOperation(msg string, op *git.Operation) string {
+	if op == nil {
+		return msg
+	}
+
+	switch op.Kind {
+	case git.OperationMerge:
+		var missing []string
+		for _, path := range op.Conflicts {
+			if !strings.Contains(msg, path) {
+				missing = append(missing, "- "+path)
+			}
+		}
+		if len(missing) > 0 {
+			msg = insertParagraph(msg, "Resolved conflicts:\n"+strings.Join(missing, "\n"))
+		}
+	case git.OperationRevert:
+		if !strings.Contains(msg, op.Hash) {
+			msg = insertParagraph(msg, fmt.Sprintf("This reverts commit %s.", op.Hash))
+		}
+	case git.OperationCherryPick:
+		if !strings.Contains(msg, op.Hash) {
+			msg = addFooter(msg, fmt.Sprintf("(cherry picked from commit %s)", op.Hash), op.Hash)
+		}
+	}
+	return msg
+}
+
+// insertParagraph adds a body paragraph to msg, above its footer block if it has one
+func insertParagraph(msg string, paragraph string) string {
+	paragraphs := strings.Split(strings.TrimRight(msg, "\n"), "\n\n")
+	if n := len(paragraphs); n > 1 && isFooterBlock(paragraphs[n-1]) {
+		paragraphs = append(paragraphs[:n-1], paragraph, paragraphs[n-1])
+	} else {
+		paragraphs = append(paragraphs, paragraph)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// shortHash abbreviates a commit hash for display
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
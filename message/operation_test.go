@@ -0,0 +1,80 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+const operationHash = "0123456789abcdef0123456789abcdef01234567"
+
+func TestApplyOperation(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  string
+		op   *git.Operation
+		want string
+	}{
+		{
+			name: "no operation",
+			msg:  "feat: add login",
+			want: "feat: add login",
+		},
+		{
+			name: "revert adds the reverted commit above the footers",
+			msg:  "revert: feat: add login\n\nRefs: ABC-1",
+			op:   &git.Operation{Kind: git.OperationRevert, Hash: operationHash, Subject: "feat: add login"},
+			want: "revert: feat: add login\n\nThis reverts commit " + operationHash + ".\n\nRefs: ABC-1",
+		},
+		{
+			name: "revert already referenced",
+			msg:  "revert: feat: add login\n\nThis reverts commit " + operationHash + ".",
+			op:   &git.Operation{Kind: git.OperationRevert, Hash: operationHash},
+			want: "revert: feat: add login\n\nThis reverts commit " + operationHash + ".",
+		},
+		{
+			name: "cherry-pick",
+			msg:  "fix: crash\n\nSigned-off-by: A <a@example.com>",
+			op:   &git.Operation{Kind: git.OperationCherryPick, Hash: operationHash},
+			want: "fix: crash\n\nSigned-off-by: A <a@example.com>\n(cherry picked from commit " + operationHash + ")",
+		},
+		{
+			name: "merge lists the conflicts the message misses",
+			msg:  "Merge feature\n\nBrings the login form. Kept both versions of a.txt.",
+			op:   &git.Operation{Kind: git.OperationMerge, Hash: operationHash, Conflicts: []string{"a.txt", "b.go"}},
+			want: "Merge feature\n\nBrings the login form. Kept both versions of a.txt.\n\nResolved conflicts:\n- b.go",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyOperation(tt.msg, tt.op); got != tt.want {
+				t.Errorf("ApplyOperation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOperationPromptSection(t *testing.T) {
+	if got := OperationPromptSection(nil); got != "" {
+		t.Errorf("expected no section without an operation, got %q", got)
+	}
+
+	merge := OperationPromptSection(&git.Operation{
+		Kind:      git.OperationMerge,
+		Hash:      operationHash,
+		Branch:    "feature/login",
+		Incoming:  []string{"feat: add login form", "fix: validate password"},
+		Conflicts: []string{"a.txt"},
+	})
+	for _, want := range []string{"merges feature/login", "- feat: add login form", "- a.txt"} {
+		if !strings.Contains(merge, want) {
+			t.Errorf("merge section %q does not contain %q", merge, want)
+		}
+	}
+
+	revert := OperationPromptSection(&git.Operation{Kind: git.OperationRevert, Hash: operationHash, Subject: "feat: add login"})
+	if !strings.Contains(revert, "0123456 \"feat: add login\"") || !strings.Contains(revert, "This reverts commit "+operationHash+".") {
+		t.Errorf("unexpected revert section %q", revert)
+	}
+}
@@ -0,0 +1,219 @@
+package message
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// prTemplatePaths are the pull request template locations GitHub looks up, in order
+var prTemplatePaths = []string{
+	".github/pull_request_template.md",
+	".github/PULL_REQUEST_TEMPLATE.md",
+	"pull_request_template.md",
+	"PULL_REQUEST_TEMPLATE.md",
+	"docs/pull_request_template.md",
+	"docs/PULL_REQUEST_TEMPLATE.md",
+}
+
+var (
+	// templateHeadingPattern matches a Markdown heading of a pull request template
+	templateHeadingPattern = regexp.MustCompile(`(?m)^#{1,6}\s+(.+?)\s*#*\s*$`)
+	// titleLabelPattern matches a "Title:" label the model may put before the title
+	titleLabelPattern = regexp.MustCompile(`(?i)^(\*\*)?(title|タイトル)(\*\*)?\s*[:：]\s*`)
+)
+
+// PullRequest is a generated pull request title and body
+type PullRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	// Base is the branch the pull request merges into
+	Base string `json:"base,omitempty"`
+	// Commits is the number of commits in the pull request
+	Commits int `json:"commits"`
+}
+
+// PROptions holds the inputs of GeneratePullRequest
+type PROptions struct {
+	// Branch is the branch the pull request is opened from
+	Branch string
+	// Base is the branch the pull request merges into
+	Base string
+	// Commits are the commit messages of the branch, oldest first
+	Commits []string
+	// Stat and Diff are the diffstat and diff against the merge base
+	Stat string
+	Diff string
+	// Template is the content of the pull request template, if any
+	Template string
+	// Language is the prompt language (e.g. japanese, ja, english); anything but Japanese is English
+	Language string
+	// ExtraPrompt holds additional instructions from the user
+	ExtraPrompt string
+	// TokenBudget limits the prompt size (0 = unlimited); the diff is truncated to fit
+	TokenBudget int
+}
+
+// FindPRTemplate returns the pull request template at path, or the first one found at the
+// locations GitHub uses under root. An empty string is returned when there is none.
+func FindPRTemplate(root string, path string) (string, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read pull request template: %w", err)
+		}
+		return string(data), nil
+	}
+
+	for _, name := range prTemplatePaths {
+		data, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(name)))
+		if err == nil {
+			return string(data), nil
+		}
+	}
+	return "", nil
+}
+
+// TemplateSections returns the headings of a pull request template, in order
+func TemplateSections(template string) []string {
+	var sections []string
+	for _, m := range templateHeadingPattern.FindAllStringSubmatch(template, -1) {
+		sections = append(sections, m[1])
+	}
+	return sections
+}
+
+// BuildPRPrompt builds the prompt that asks for a pull request title and body
+func BuildPRPrompt(opts PROptions) string {
+	ja := config.NormalizeLangCode(opts.Language) == "ja"
+	var sb strings.Builder
+
+	if ja {
+		sb.WriteString(fmt.Sprintf("ブランチ %s を %s にマージするプルリクエストのタイトルと説明文を日本語で書いてください。\n\n", opts.Branch, opts.Base))
+		sb.WriteString("ルール:\n")
+		sb.WriteString("- 1行目にタイトル（72文字以内）、空行を挟んで Markdown の本文を出力する\n")
+		sb.WriteString("- 変更の目的（なぜ）と内容（何を）を説明し、レビュー時に注意すべき点があれば書く\n")
+		sb.WriteString("- コミットメッセージとdiffから分からないことは推測で書かない\n")
+		sb.WriteString("- 出力はタイトルと本文のみ。コードブロックで囲まず、説明も付けない\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Write the title and description of a pull request that merges %s into %s.\n\n", opts.Branch, opts.Base))
+		sb.WriteString("Rules:\n")
+		sb.WriteString("- Output the title (at most 72 characters) on the first line, then a blank line, then the Markdown body\n")
+		sb.WriteString("- Explain why the change is made and what it does, and point out anything reviewers should look at\n")
+		sb.WriteString("- Do not guess anything the commit messages and the diff do not show\n")
+		sb.WriteString("- Output only the title and the body, without code fences or explanations\n")
+	}
+
+	if strings.TrimSpace(opts.Template) != "" {
+		sections := TemplateSections(opts.Template)
+		if ja {
+			sb.WriteString("- 本文は以下のテンプレートに沿って書く。見出しはそのままの順序で残し、HTML コメントは削除し、該当しないセクションには「なし」と書く\n")
+			if len(sections) > 0 {
+				sb.WriteString(fmt.Sprintf("- セクション: %s\n", strings.Join(sections, ", ")))
+			}
+			sb.WriteString("\nテンプレート:\n")
+		} else {
+			sb.WriteString("- Write the body by filling in the template below. Keep its headings in order, remove HTML comments and write \"N/A\" under sections that do not apply\n")
+			if len(sections) > 0 {
+				sb.WriteString(fmt.Sprintf("- Sections: %s\n", strings.Join(sections, ", ")))
+			}
+			sb.WriteString("\nTemplate:\n")
+		}
+		sb.WriteString(strings.TrimSpace(opts.Template) + "\n")
+	}
+
+	if strings.TrimSpace(opts.ExtraPrompt) != "" {
+		sb.WriteString(fmt.Sprintf("\nAdditional instructions from user:\n%s\n", opts.ExtraPrompt))
+	}
+
+	sb.WriteString("\nCommits:\n")
+	for _, commit := range opts.Commits {
+		sb.WriteString("---\n" + strings.TrimSpace(commit) + "\n")
+	}
+	if opts.Stat != "" {
+		sb.WriteString("\nDiff stat:\n" + opts.Stat + "\n")
+	}
+
+	diff := opts.Diff
+	if opts.TokenBudget > 0 {
+		diff = TruncateToTokens(diff, opts.TokenBudget-EstimateTokens(sb.String()))
+	}
+	if diff != "" {
+		sb.WriteString("\nDiff:\n" + diff + "\n")
+	}
+	return sb.String()
+}
+
+// TruncateToTokens shortens s to about tokens tokens, cutting at a line boundary and noting the truncation
+func TruncateToTokens(s string, tokens int) string {
+	if EstimateTokens(s) <= tokens {
+		return s
+	}
+	if tokens <= 0 {
+		return ""
+	}
+
+	limit := tokens * 4
+	runes := 0
+	cut := len(s)
+	for i := range s {
+		if runes == limit {
+			cut = i
+			break
+		}
+		runes++
+	}
+	if idx := strings.LastIndex(s[:cut], "\n"); idx > 0 {
+		cut = idx
+	}
+	omitted := utf8.RuneCountInString(s[cut:])
+	return s[:cut] + fmt.Sprintf("\n... (%d characters truncated)", omitted)
+}
+
+// ParsePullRequest splits a model response into the pull request title and body
+func ParsePullRequest(response string) PullRequest {
+	title, body, _ := strings.Cut(stripCodeFence(response), "\n")
+	title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(title), "#"))
+	title = titleLabelPattern.ReplaceAllString(title, "")
+	title = strings.Trim(title, "\"`*")
+	return PullRequest{Title: strings.TrimSpace(title), Body: strings.TrimSpace(body)}
+}
+
+// stripCodeFence removes a code fence the model may wrap its whole response in
+func stripCodeFence(response string) string {
+	response = strings.TrimSpace(response)
+	if !strings.HasPrefix(response, "```") {
+		return response
+	}
+	response = strings.TrimPrefix(response, "```")
+	if idx := strings.Index(response, "\n"); idx >= 0 {
+		response = response[idx+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(response), "```"))
+}
+
+// GeneratePullRequest asks the model for a pull request title and body
+func GeneratePullRequest(aiClient client.AIClient, opts PROptions) (*PullRequest, error) {
+	if len(opts.Commits) == 0 && strings.TrimSpace(opts.Diff) == "" {
+		return nil, fmt.Errorf("no changes between %s and %s", opts.Base, opts.Branch)
+	}
+
+	response, err := aiClient.Complete(BuildPRPrompt(opts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pull request: %w", err)
+	}
+
+	pr := ParsePullRequest(response)
+	if pr.Title == "" {
+		return nil, fmt.Errorf("failed to generate pull request: empty response")
+	}
+	pr.Base = opts.Base
+	pr.Commits = len(opts.Commits)
+	return &pr, nil
+}
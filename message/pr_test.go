@@ -0,0 +1,112 @@
+package message
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindPRTemplate(t *testing.T) {
+	root := t.TempDir()
+	if got, err := FindPRTemplate(root, ""); err != nil || got != "" {
+		t.Fatalf("FindPRTemplate() without template = %q, %v", got, err)
+	}
+
+	dir := filepath.Join(root, ".github")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "pull_request_template.md"), []byte("## Summary\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if got, err := FindPRTemplate(root, ""); err != nil || got != "## Summary\n" {
+		t.Errorf("FindPRTemplate() = %q, %v", got, err)
+	}
+
+	if _, err := FindPRTemplate(root, filepath.Join(root, "missing.md")); err == nil {
+		t.Error("FindPRTemplate() with a missing explicit path should fail")
+	}
+}
+
+func TestTemplateSections(t *testing.T) {
+	template := "## Summary\n<!-- what and why -->\n\n## Test plan ##\n\n### Checklist\n- [ ] Tests\n"
+	want := []string{"Summary", "Test plan", "Checklist"}
+	if got := TemplateSections(template); !reflect.DeepEqual(got, want) {
+		t.Errorf("TemplateSections() = %v, want %v", got, want)
+	}
+}
+
+func TestParsePullRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		title    string
+		body     string
+	}{
+		{"plain", "Add scope inference\n\n## Summary\nInfers scopes.", "Add scope inference", "## Summary\nInfers scopes."},
+		{"heading title", "# Add scope inference\n\nBody", "Add scope inference", "Body"},
+		{"labelled title", "**Title:** Add scope inference\n\nBody", "Add scope inference", "Body"},
+		{"code fence", "```markdown\nAdd scope inference\n\nBody\n```", "Add scope inference", "Body"},
+		{"title only", "Add scope inference", "Add scope inference", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParsePullRequest(tt.response)
+			if got.Title != tt.title || got.Body != tt.body {
+				t.Errorf("ParsePullRequest() = %q / %q, want %q / %q", got.Title, got.Body, tt.title, tt.body)
+			}
+		})
+	}
+}
+
+func TestTruncateToTokens(t *testing.T) {
+	s := strings.Repeat("line of diff\n", 100)
+	if got := TruncateToTokens(s, 1000); got != s {
+		t.Error("TruncateToTokens() changed a string within the budget")
+	}
+
+	got := TruncateToTokens(s, 10)
+	if !strings.HasSuffix(got, "characters truncated)") {
+		t.Errorf("TruncateToTokens() = %q, want a truncation note", got)
+	}
+	if kept, _, _ := strings.Cut(got, "\n..."); len(kept) > 40 || strings.HasSuffix(kept, "line of") {
+		t.Errorf("TruncateToTokens() kept %q, want whole lines within 40 characters", kept)
+	}
+	if got := TruncateToTokens(s, 0); got != "" {
+		t.Errorf("TruncateToTokens() with no budget = %q", got)
+	}
+}
+
+func TestBuildPRPromptNormalizesLanguage(t *testing.T) {
+	for _, lang := range []string{"japanese", "ja", "jp", "Japanese"} {
+		if prompt := BuildPRPrompt(PROptions{Branch: "b", Base: "main", Language: lang}); !strings.Contains(prompt, "日本語") {
+			t.Errorf("expected a Japanese prompt for %q", lang)
+		}
+	}
+	if prompt := BuildPRPrompt(PROptions{Branch: "b", Base: "main", Language: "en"}); strings.Contains(prompt, "日本語") {
+		t.Error("expected an English prompt for en")
+	}
+}
+
+func TestBuildPRPrompt(t *testing.T) {
+	prompt := BuildPRPrompt(PROptions{
+		Branch:      "feature/scopes",
+		Base:        "origin/main",
+		Commits:     []string{"feat: add scopes", "fix: handle nil"},
+		Diff:        strings.Repeat("+added line\n", 1000),
+		Template:    "## Summary\n\n## Test plan\n",
+		Language:    "english",
+		TokenBudget: 500,
+	})
+
+	for _, want := range []string{"feature/scopes", "origin/main", "Sections: Summary, Test plan", "feat: add scopes", "characters truncated"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("BuildPRPrompt() does not contain %q", want)
+		}
+	}
+	if EstimateTokens(prompt) > 520 {
+		t.Errorf("BuildPRPrompt() is about %d tokens, want the diff truncated to the budget", EstimateTokens(prompt))
+	}
+}
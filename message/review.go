@@ -0,0 +1,359 @@
+package message
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// Review finding categories
+const (
+	CategoryBug    = "bug"
+	CategoryDebug  = "debug"
+	CategoryTests  = "tests"
+	CategorySecret = "secret"
+	CategoryOther  = "other"
+)
+
+// reviewCategories describes the categories to the model and in SARIF rules
+var reviewCategories = []struct {
+	ID          string
+	Description string
+}{
+	{CategoryBug, "Bugs: logic errors, unhandled errors, nil dereferences, races, resource leaks"},
+	{CategoryDebug, "Leftover debugging: debug prints, commented-out code, TODOs added by the change"},
+	{CategoryTests, "Missing tests for new or changed behavior"},
+	{CategorySecret, "Secrets: API keys, tokens, passwords or private keys"},
+	{CategoryOther, "Anything else worth fixing before the commit"},
+}
+
+// hunkHeaderPattern matches a unified diff hunk header and captures the new start line
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// Finding is an issue found by the review
+type Finding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line,omitempty"`
+	Severity string `json:"severity"`
+	Category string `json:"category"`
+	Message  string `json:"message"`
+}
+
+// Location returns the finding's "file:line"
+func (f Finding) Location() string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.File, f.Line)
+	}
+	return f.File
+}
+
+// ReviewOptions holds the inputs of Review
+type ReviewOptions struct {
+	Diff string
+	// Language is the language of the finding messages
+	Language string
+	// Instructions are the project's review guidelines
+	Instructions string
+	// ExtraPrompt holds additional instructions from the user
+	ExtraPrompt string
+	// ChunkTokens splits diffs larger than this many tokens into several requests (0 = one request)
+	ChunkTokens int
+}
+
+// SeverityRank orders severities from 0 (info) up; unknown severities rank -1
+func SeverityRank(severity string) int {
+	for i, s := range config.Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// AtOrAbove returns the findings whose severity is at least threshold; none for "none"
+func AtOrAbove(findings []Finding, threshold string) []Finding {
+	rank := SeverityRank(threshold)
+	if rank < 0 {
+		return nil
+	}
+	var result []Finding
+	for _, f := range findings {
+		if SeverityRank(f.Severity) >= rank {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// NumberDiffLines prefixes the added and context lines of a diff with their line number in the
+// new file, so the model can point at lines
+func NumberDiffLines(diff string) string {
+	lines := strings.Split(diff, "\n")
+	line := 0
+	for i, l := range lines {
+		if m := hunkHeaderPattern.FindStringSubmatch(l); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if line == 0 || l == "" {
+			continue
+		}
+		switch l[0] {
+		case '+', ' ':
+			lines[i] = fmt.Sprintf("%5d %s", line, l)
+			line++
+		case '-':
+			lines[i] = "      " + l
+		case 'd':
+			// "diff --git" starts the next file
+			line = 0
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BuildReviewPrompt builds the prompt that asks for review findings as JSON
+func BuildReviewPrompt(opts ReviewOptions) string {
+	var sb strings.Builder
+	sb.WriteString("Review the staged changes below before they are committed and report the issues you find.\n\n")
+	sb.WriteString("Look for:\n")
+	for _, c := range reviewCategories {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", c.ID, c.Description))
+	}
+	sb.WriteString("\nRules:\n")
+	sb.WriteString("- Report only issues in the added lines; the number before a line is its line number in the new file\n")
+	sb.WriteString("- severity is \"error\" for issues that must be fixed (bugs, secrets), \"warning\" for issues that should be fixed and \"info\" for suggestions\n")
+	sb.WriteString("- Do not report style preferences or issues you are not confident about\n")
+	if opts.Language != "" {
+		sb.WriteString(fmt.Sprintf("- Write the messages in %s\n", opts.Language))
+	}
+	sb.WriteString("- Answer with only a JSON array, [] when there is nothing to report, of objects like:\n")
+	sb.WriteString(`  {"file": "path/to/file.go", "line": 42, "severity": "error", "category": "bug", "message": "..."}` + "\n")
+	if strings.TrimSpace(opts.Instructions) != "" {
+		sb.WriteString("\nProject guidelines:\n" + strings.TrimSpace(opts.Instructions) + "\n")
+	}
+	if strings.TrimSpace(opts.ExtraPrompt) != "" {
+		sb.WriteString(fmt.Sprintf("\nAdditional instructions from user:\n%s\n", opts.ExtraPrompt))
+	}
+
+	sb.WriteString("\nDiff:\n" + NumberDiffLines(opts.Diff) + "\n")
+	return sb.String()
+}
+
+// SplitDiff splits a diff into chunks of about maxTokens (0 = one chunk) so that large diffs
+// are reviewed whole in several requests. Chunks end at file boundaries; a file larger than a
+// chunk is split between hunks with its header repeated, and a single hunk larger than a chunk
+// becomes a chunk of its own.
+func SplitDiff(diff string, maxTokens int) []string {
+	if maxTokens <= 0 || EstimateTokens(diff) <= maxTokens {
+		return []string{diff}
+	}
+
+	// Split into pieces that are never split further: whole files, or hunks with their file header
+	var pieces []string
+	for _, file := range splitBefore(diff, func(line string) bool { return strings.HasPrefix(line, "diff --git ") }) {
+		if EstimateTokens(file) <= maxTokens {
+			pieces = append(pieces, file)
+			continue
+		}
+		hunks := splitBefore(file, func(line string) bool { return strings.HasPrefix(line, "@@ ") })
+		header := ""
+		if !strings.HasPrefix(hunks[0], "@@ ") {
+			header, hunks = hunks[0], hunks[1:]
+		}
+		for _, hunk := range hunks {
+			pieces = append(pieces, header+hunk)
+		}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	for _, piece := range pieces {
+		if current.Len() > 0 && EstimateTokens(current.String()+piece) > maxTokens {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(piece)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// splitBefore splits text into parts that start at the lines matching start; each part keeps
+// its trailing newline, so the parts add up to text
+func splitBefore(text string, start func(line string) bool) []string {
+	var parts []string
+	begin := 0
+	for offset := 0; offset < len(text); {
+		end := strings.IndexByte(text[offset:], '\n')
+		if end < 0 {
+			end = len(text) - offset - 1
+		}
+		if offset > begin && start(text[offset:offset+end+1]) {
+			parts = append(parts, text[begin:offset])
+			begin = offset
+		}
+		offset += end + 1
+	}
+	return append(parts, text[begin:])
+}
+
+// ParseFindings reads the JSON array of findings from the model's response. Unknown
+// severities become warnings and unknown categories "other"; findings are sorted by location.
+func ParseFindings(response string) ([]Finding, error) {
+	response = stripCodeFence(response)
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON array in response: %q", response)
+	}
+	var findings []Finding
+	if err := json.Unmarshal([]byte(response[start:end+1]), &findings); err != nil {
+		return nil, fmt.Errorf("invalid findings: %w", err)
+	}
+
+	for i := range findings {
+		f := &findings[i]
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		if SeverityRank(f.Severity) < 0 {
+			f.Severity = config.SeverityWarning
+		}
+		f.Category = strings.ToLower(strings.TrimSpace(f.Category))
+		known := false
+		for _, c := range reviewCategories {
+			known = known || c.ID == f.Category
+		}
+		if !known {
+			f.Category = CategoryOther
+		}
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// sortFindings sorts findings by location
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// Review asks the model to review a diff, in several requests if it is larger than
+// opts.ChunkTokens (see SplitDiff)
+func Review(aiClient client.AIClient, opts ReviewOptions) ([]Finding, error) {
+	if strings.TrimSpace(opts.Diff) == "" {
+		return nil, fmt.Errorf("no diff provided")
+	}
+	chunks := SplitDiff(opts.Diff, opts.ChunkTokens)
+	failed := func(i int, err error) error {
+		if len(chunks) > 1 {
+			return fmt.Errorf("failed to review changes (part %d of %d): %w", i+1, len(chunks), err)
+		}
+		return fmt.Errorf("failed to review changes: %w", err)
+	}
+
+	var findings []Finding
+	for i, chunk := range chunks {
+		chunkOpts := opts
+		chunkOpts.Diff = chunk
+		response, err := aiClient.Complete(BuildReviewPrompt(chunkOpts))
+		if err != nil {
+			return nil, failed(i, err)
+		}
+		chunkFindings, err := ParseFindings(response)
+		if err != nil {
+			return nil, failed(i, err)
+		}
+		findings = append(findings, chunkFindings...)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// FormatFindings renders findings as "file:line: severity [category] message" lines
+func FormatFindings(findings []Finding) string {
+	var sb strings.Builder
+	for _, f := range findings {
+		sb.WriteString(fmt.Sprintf("%s: %s [%s] %s\n", f.Location(), f.Severity, f.Category, f.Message))
+	}
+	return sb.String()
+}
+
+// sarifLevels maps review severities to SARIF result levels
+var sarifLevels = map[string]string{
+	config.SeverityInfo:    "note",
+	config.SeverityWarning: "warning",
+	config.SeverityError:   "error",
+}
+
+// SARIF renders findings as a SARIF 2.1.0 log for code scanning tools
+func SARIF(findings []Finding, toolVersion string) ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type region struct {
+		StartLine int `json:"startLine"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *region `json:"region,omitempty"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+
+	rules := make([]rule, len(reviewCategories))
+	for i, c := range reviewCategories {
+		rules[i] = rule{ID: c.ID, ShortDescription: message{Text: c.Description}}
+	}
+	results := make([]result, len(findings))
+	for i, f := range findings {
+		var loc location
+		loc.PhysicalLocation.ArtifactLocation.URI = f.File
+		if f.Line > 0 {
+			loc.PhysicalLocation.Region = &region{StartLine: f.Line}
+		}
+		results[i] = result{RuleID: f.Category, Level: sarifLevels[f.Severity], Message: message{Text: f.Message}, Locations: []location{loc}}
+	}
+
+	log := map[string]interface{}{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []interface{}{
+			map[string]interface{}{
+				"tool": map[string]interface{}{
+					"driver": map[string]interface{}{
+						"name":           "generate-auto-commit-message",
+						"version":        toolVersion,
+						"informationUri": "https://github.com/UNILORN/generative-commit-message-for-ai-tool",
+						"rules":          rules,
+					},
+				},
+				"results": results,
+			},
+		},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
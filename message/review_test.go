@@ -0,0 +1,182 @@
+package message
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestNumberDiffLines(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -10,3 +10,3 @@ func a() {\n ctx\n-old\n+new\n ctx\n"
+	want := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -10,3 +10,3 @@ func a() {\n   10  ctx\n      -old\n   11 +new\n   12  ctx\n"
+	if got := NumberDiffLines(diff); got != want {
+		t.Errorf("NumberDiffLines() = %q, want %q", got, want)
+	}
+}
+
+// reviewClient answers every review request with a finding in the first file of its diff
+type reviewClient struct {
+	prompts []string
+}
+
+func (c *reviewClient) GenerateCommitMessage(diff string, branch string) (string, error) {
+	return "", nil
+}
+
+func (c *reviewClient) Complete(prompt string) (string, error) {
+	c.prompts = append(c.prompts, prompt)
+	file := strings.TrimPrefix(strings.Fields(prompt[strings.Index(prompt, "+++ b/"):])[1], "b/")
+	return `[{"file": "` + file + `", "line": 1, "severity": "error", "category": "bug", "message": "bug"}]`, nil
+}
+
+func TestSplitDiff(t *testing.T) {
+	fileDiff := func(name string, hunks int) string {
+		var sb strings.Builder
+		sb.WriteString("diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n")
+		for i := 0; i < hunks; i++ {
+			sb.WriteString("@@ -1,1 +1,1 @@\n-" + strings.Repeat("x", 200) + "\n+" + strings.Repeat("y", 200) + "\n")
+		}
+		return sb.String()
+	}
+	diff := fileDiff("a.go", 1) + fileDiff("b.go", 1) + fileDiff("big.go", 3)
+
+	if chunks := SplitDiff(diff, 0); len(chunks) != 1 || chunks[0] != diff {
+		t.Errorf("expected one chunk without a budget, got %d", len(chunks))
+	}
+
+	chunks := SplitDiff(diff, 150)
+	if len(chunks) != 5 {
+		t.Fatalf("expected 5 chunks, got %d: %q", len(chunks), chunks)
+	}
+	if !strings.Contains(chunks[0], "a.go") || strings.Contains(chunks[0], "b.go") {
+		t.Errorf("expected the first chunk to hold only a.go:\n%s", chunks[0])
+	}
+	if merged := SplitDiff(diff, 250); !strings.Contains(merged[0], "a.go") || !strings.Contains(merged[0], "b.go") {
+		t.Errorf("expected small files to share a chunk:\n%s", merged[0])
+	}
+	// The hunks of a file larger than a chunk each get its header
+	for _, chunk := range chunks[2:] {
+		if !strings.HasPrefix(chunk, "diff --git a/big.go b/big.go\n--- a/big.go\n+++ b/big.go\n@@ ") {
+			t.Errorf("expected a hunk of big.go with its header:\n%s", chunk)
+		}
+	}
+	if got := strings.Count(strings.Join(chunks, ""), strings.Repeat("y", 200)); got != 5 {
+		t.Errorf("expected all 5 hunks in the chunks, got %d", got)
+	}
+}
+
+func TestReviewSplitsDiffOverBudget(t *testing.T) {
+	var sb strings.Builder
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		sb.WriteString("diff --git a/" + name + " b/" + name + "\n--- a/" + name + "\n+++ b/" + name + "\n@@ -0,0 +1,1 @@\n+" + strings.Repeat("z", 400) + "\n")
+	}
+	diff := sb.String()
+
+	aiClient := &reviewClient{}
+	findings, err := Review(aiClient, ReviewOptions{Diff: diff, ChunkTokens: 150})
+	if err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if len(aiClient.prompts) != 3 {
+		t.Fatalf("expected 3 review requests, got %d", len(aiClient.prompts))
+	}
+	// Nothing of the diff is left out, and every part is reviewed
+	for _, name := range []string{"a.go", "b.go", "c.go"} {
+		found := false
+		for _, f := range findings {
+			found = found || f.File == name
+		}
+		if !found {
+			t.Errorf("expected a finding in %s, got %+v", name, findings)
+		}
+	}
+}
+
+func TestParseFindings(t *testing.T) {
+	response := "Here are the issues:\n```json\n[\n" +
+		`{"file": "b.go", "line": 3, "severity": "ERROR", "category": "secret", "message": "API key"},` + "\n" +
+		`{"file": "a.go", "line": 7, "severity": "critical", "category": "style", "message": "odd"}` + "\n]\n```"
+	findings, err := ParseFindings(response)
+	if err != nil {
+		t.Fatalf("ParseFindings failed: %v", err)
+	}
+	want := []Finding{
+		{File: "a.go", Line: 7, Severity: "warning", Category: "other", Message: "odd"},
+		{File: "b.go", Line: 3, Severity: "error", Category: "secret", Message: "API key"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("got %d findings, want %d", len(findings), len(want))
+	}
+	for i := range want {
+		if findings[i] != want[i] {
+			t.Errorf("finding %d = %+v, want %+v", i, findings[i], want[i])
+		}
+	}
+
+	if findings, err := ParseFindings("[]"); err != nil || len(findings) != 0 {
+		t.Errorf("ParseFindings([]) = %v, %v", findings, err)
+	}
+	if _, err := ParseFindings("Looks good to me"); err == nil {
+		t.Error("expected an error without a JSON array")
+	}
+}
+
+func TestAtOrAbove(t *testing.T) {
+	findings := []Finding{{Severity: "info"}, {Severity: "warning"}, {Severity: "error"}}
+	for threshold, want := range map[string]int{"info": 3, "warning": 2, "error": 1, "none": 0} {
+		if got := len(AtOrAbove(findings, threshold)); got != want {
+			t.Errorf("AtOrAbove(%q) returned %d findings, want %d", threshold, got, want)
+		}
+	}
+}
+
+func TestFormatFindings(t *testing.T) {
+	got := FormatFindings([]Finding{
+		{File: "a.go", Line: 7, Severity: "error", Category: "bug", Message: "nil dereference"},
+		{File: "go.mod", Severity: "info", Category: "other", Message: "tidy"},
+	})
+	want := "a.go:7: error [bug] nil dereference\ngo.mod: info [other] tidy\n"
+	if got != want {
+		t.Errorf("FormatFindings() = %q, want %q", got, want)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	data, err := SARIF([]Finding{{File: "a.go", Line: 7, Severity: "info", Category: "debug", Message: "debug print"}}, "v1.0.0")
+	if err != nil {
+		t.Fatalf("SARIF failed: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 1 {
+		t.Fatalf("unexpected SARIF log: %s", data)
+	}
+	result := log.Runs[0].Results[0]
+	location := result.Locations[0].PhysicalLocation
+	if result.RuleID != "debug" || result.Level != "note" || location.ArtifactLocation.URI != "a.go" || location.Region.StartLine != 7 {
+		t.Errorf("unexpected result: %s", data)
+	}
+	if !strings.Contains(string(data), `"version": "v1.0.0"`) {
+		t.Errorf("tool version missing: %s", data)
+	}
+}
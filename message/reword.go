@@ -0,0 +1,73 @@
+package message
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// rewordSubjectWidth is the width of the subject columns of the reword table
+const rewordSubjectWidth = 50
+
+// Reword is a commit whose message is regenerated
+type Reword struct {
+	Hash   string
+	Before string
+	After  string
+}
+
+// Subject returns the summary line of a commit message
+func Subject(msg string) string {
+	subject, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+	return strings.TrimSpace(subject)
+}
+
+// IsProtectedBranch reports whether branch matches one of the protected patterns (* is a wildcard)
+func IsProtectedBranch(branch string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if config.MatchWildcard(pattern, branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// KeepTrailers copies the footer block of the original message (Signed-off-by, Refs, ...)
+// into the regenerated one, skipping lines it already has
+func KeepTrailers(msg, original string) string {
+	paragraphs := strings.Split(strings.TrimSpace(original), "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+	if len(paragraphs) < 2 || !isFooterBlock(last) {
+		return msg
+	}
+	for _, line := range strings.Split(last, "\n") {
+		msg = addFooter(msg, line, line)
+	}
+	return msg
+}
+
+// RewordTable renders the summary lines before and after rewording as a table
+func RewordTable(rewords []Reword) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%-7s  %-*s  %s\n", "Commit", rewordSubjectWidth, "Before", "After"))
+	for _, r := range rewords {
+		hash := r.Hash
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		before := truncateRunes(Subject(r.Before), rewordSubjectWidth)
+		padding := rewordSubjectWidth - utf8.RuneCountInString(before)
+		sb.WriteString(fmt.Sprintf("%-7s  %s%s  %s\n", hash, before, strings.Repeat(" ", padding), Subject(r.After)))
+	}
+	return sb.String()
+}
+
+// truncateRunes shortens s to at most n runes, ending with "…" when cut
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
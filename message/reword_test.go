@@ -0,0 +1,78 @@
+package message
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeepTrailers(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		original string
+		want     string
+	}{
+		{
+			name:     "no footer",
+			msg:      "feat: add login",
+			original: "wip\n\nsome notes",
+			want:     "feat: add login",
+		},
+		{
+			name:     "copies footer",
+			msg:      "feat: add login\n\nAdd the login form.",
+			original: "wip\n\nSigned-off-by: A <a@example.com>",
+			want:     "feat: add login\n\nAdd the login form.\n\nSigned-off-by: A <a@example.com>",
+		},
+		{
+			name:     "merges with generated footer",
+			msg:      "fix: crash\n\nRefs: ABC-1",
+			original: "fix\n\nRefs: ABC-1\nSigned-off-by: A <a@example.com>",
+			want:     "fix: crash\n\nRefs: ABC-1\nSigned-off-by: A <a@example.com>",
+		},
+		{
+			name:     "subject only",
+			msg:      "fix: crash",
+			original: "Refs: ABC-1",
+			want:     "fix: crash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KeepTrailers(tt.msg, tt.original); got != tt.want {
+				t.Errorf("KeepTrailers() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsProtectedBranch(t *testing.T) {
+	patterns := []string{"main", "release/*"}
+	for branch, want := range map[string]bool{
+		"main":          true,
+		"release/1.2":   true,
+		"feature/login": false,
+		"maintenance":   false,
+	} {
+		if got := IsProtectedBranch(branch, patterns); got != want {
+			t.Errorf("IsProtectedBranch(%q) = %v, want %v", branch, got, want)
+		}
+	}
+}
+
+func TestRewordTable(t *testing.T) {
+	table := RewordTable([]Reword{
+		{Hash: "0123456789abcdef", Before: "wip\n\nbody", After: "feat: add login\n\nbody"},
+		{Hash: "fedcba9876543210", Before: strings.Repeat("x", 60), After: "fix: crash"},
+	})
+	lines := strings.Split(strings.TrimRight(table, "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %q", table)
+	}
+	if !strings.HasPrefix(lines[1], "0123456  wip ") || !strings.HasSuffix(lines[1], "  feat: add login") {
+		t.Errorf("unexpected row %q", lines[1])
+	}
+	if !strings.Contains(lines[2], "…  fix: crash") {
+		t.Errorf("long summary not truncated: %q", lines[2])
+	}
+}
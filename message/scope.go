@@ -0,0 +1,170 @@
+package message
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// defaultMaxScopes is the number of scopes joined before the scope is omitted
+const defaultMaxScopes = 3
+
+// scopedPrefixPattern splits a summary line into type, scope, breaking marker and the rest
+var scopedPrefixPattern = regexp.MustCompile(`^([a-z][a-z0-9_-]*)(?:\(([^)]*)\))?(!?): `)
+
+// ScopeInference is the scope inferred for a set of changed files
+type ScopeInference struct {
+	// Scope is the scope to use; empty when the scope should be omitted
+	Scope string
+	// Candidates are all scopes the files map to, most files first
+	Candidates []string
+}
+
+// Inferred reports whether any file mapped to a scope
+func (s ScopeInference) Inferred() bool {
+	return len(s.Candidates) > 0
+}
+
+// PromptSection returns the instruction added to the prompt, or "" if no scope was inferred
+func (s ScopeInference) PromptSection() string {
+	switch {
+	case !s.Inferred():
+		return ""
+	case s.Scope != "":
+		return fmt.Sprintf("Use %q as the scope of the summary line, e.g. \"feat(%s): ...\"", s.Scope, s.Scope)
+	default:
+		return fmt.Sprintf("The change spans several scopes (%s); do not put a scope in the summary line", strings.Join(s.Candidates, ", "))
+	}
+}
+
+// InferScope maps the changed files (relative to root) to scopes with the configured rules,
+// falling back to nested module directories when monorepo detection is enabled
+func InferScope(sc config.ScopesConfig, root string, files []string) ScopeInference {
+	counts := map[string]int{}
+	for _, file := range files {
+		if scope := scopeForFile(sc, root, file); scope != "" {
+			counts[scope]++
+		}
+	}
+
+	var inference ScopeInference
+	for scope := range counts {
+		inference.Candidates = append(inference.Candidates, scope)
+	}
+	sort.Slice(inference.Candidates, func(i, j int) bool {
+		a, b := inference.Candidates[i], inference.Candidates[j]
+		if counts[a] != counts[b] {
+			return counts[a] > counts[b]
+		}
+		return a < b
+	})
+
+	switch {
+	case len(inference.Candidates) == 0:
+	case len(inference.Candidates) == 1:
+		inference.Scope = inference.Candidates[0]
+	case sc.Multiple == config.ScopesMajority:
+		inference.Scope = inference.Candidates[0]
+	case sc.Multiple == config.ScopesOmit:
+	default:
+		maxScopes := sc.MaxScopes
+		if maxScopes <= 0 {
+			maxScopes = defaultMaxScopes
+		}
+		if len(inference.Candidates) <= maxScopes {
+			joined := append([]string{}, inference.Candidates...)
+			sort.Strings(joined)
+			inference.Scope = strings.Join(joined, ",")
+		}
+	}
+	return inference
+}
+
+// scopeForFile returns the scope of a single file, or "" if it has none
+func scopeForFile(sc config.ScopesConfig, root string, file string) string {
+	for _, rule := range sc.Rules {
+		if MatchGlob(rule.Glob, file) {
+			return rule.Scope
+		}
+	}
+	if sc.Monorepo && root != "" {
+		// Files of the root module have no scope of their own
+		if module := FindModule(root, file); module != nil && module.Dir != "." {
+			return path.Base(module.Dir)
+		}
+	}
+	return ""
+}
+
+// ApplyScope rewrites the scope of a Conventional Commits summary line. An empty scope removes it.
+// Summary lines without a type prefix are left unchanged.
+func ApplyScope(msg string, scope string) string {
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	m := scopedPrefixPattern.FindStringSubmatchIndex(subject)
+	if m == nil {
+		return msg
+	}
+
+	prefix := subject[m[2]:m[3]]
+	if scope != "" {
+		prefix += "(" + scope + ")"
+	}
+	prefix += subject[m[6]:m[7]] + ": "
+	subject = prefix + subject[m[1]:]
+
+	if !hasBody {
+		return subject
+	}
+	return subject + "\n" + body
+}
+
+// MessageScope returns the scope of the summary line and whether it has a type prefix at all
+func MessageScope(msg string) (string, bool) {
+	subject, _, _ := strings.Cut(msg, "\n")
+	m := scopedPrefixPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return "", false
+	}
+	return m[2], true
+}
+
+// MatchGlob reports whether a slash-separated path matches a glob pattern.
+// "*" matches within a path segment, "**" across segments, "?" a single character
+// and "{a,b}" either alternative.
+func MatchGlob(pattern, name string) bool {
+	var sb strings.Builder
+	sb.WriteString("^")
+	inBraces := false
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			sb.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		case c == '{' && !inBraces:
+			sb.WriteString("(?:")
+			inBraces = true
+		case c == '}' && inBraces:
+			sb.WriteString(")")
+			inBraces = false
+		case c == ',' && inBraces:
+			sb.WriteString("|")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	return err == nil && re.MatchString(name)
+}
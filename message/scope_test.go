@@ -0,0 +1,170 @@
+package message
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"mcp/**", "mcp/server.go", true},
+		{"mcp/**", "mcp/tools/generate.go", true},
+		{"mcp/**", "mcpx/server.go", false},
+		{"*.go", "main.go", true},
+		{"*.go", "config/types.go", false},
+		{"**/*.go", "config/types.go", true},
+		{"**/*.go", "main.go", true},
+		{"config/?ypes.go", "config/types.go", true},
+		{"{bedrock,claude}/**", "claude/client.go", true},
+		{"{bedrock,claude}/**", "client/interface.go", false},
+		{"docs/*.md", "docs/a.b.md", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestInferScope(t *testing.T) {
+	rules := []config.ScopeRule{
+		{Glob: "mcp/**", Scope: "mcp"},
+		{Glob: "{bedrock,claude}/**", Scope: "provider"},
+	}
+
+	tests := []struct {
+		name  string
+		sc    config.ScopesConfig
+		files []string
+		want  ScopeInference
+	}{
+		{
+			name:  "single scope",
+			sc:    config.ScopesConfig{Rules: rules},
+			files: []string{"mcp/server.go", "mcp/tools.go"},
+			want:  ScopeInference{Scope: "mcp", Candidates: []string{"mcp"}},
+		},
+		{
+			name:  "unmatched files are ignored",
+			sc:    config.ScopesConfig{Rules: rules},
+			files: []string{"bedrock/client.go", "README.md"},
+			want:  ScopeInference{Scope: "provider", Candidates: []string{"provider"}},
+		},
+		{
+			name:  "no scope",
+			sc:    config.ScopesConfig{Rules: rules},
+			files: []string{"main.go"},
+			want:  ScopeInference{},
+		},
+		{
+			name:  "join",
+			sc:    config.ScopesConfig{Rules: rules, Multiple: config.ScopesJoin},
+			files: []string{"mcp/server.go", "claude/client.go", "bedrock/client.go"},
+			want:  ScopeInference{Scope: "mcp,provider", Candidates: []string{"provider", "mcp"}},
+		},
+		{
+			name:  "join beyond max_scopes",
+			sc:    config.ScopesConfig{Rules: rules, Multiple: config.ScopesJoin, MaxScopes: 1},
+			files: []string{"mcp/server.go", "claude/client.go"},
+			want:  ScopeInference{Candidates: []string{"mcp", "provider"}},
+		},
+		{
+			name:  "majority",
+			sc:    config.ScopesConfig{Rules: rules, Multiple: config.ScopesMajority},
+			files: []string{"mcp/server.go", "claude/client.go", "bedrock/client.go"},
+			want:  ScopeInference{Scope: "provider", Candidates: []string{"provider", "mcp"}},
+		},
+		{
+			name:  "omit",
+			sc:    config.ScopesConfig{Rules: rules, Multiple: config.ScopesOmit},
+			files: []string{"mcp/server.go", "claude/client.go"},
+			want:  ScopeInference{Candidates: []string{"mcp", "provider"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := InferScope(tt.sc, "", tt.files)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("InferScope() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInferScopeMonorepo(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":               "module example.com/app\n",
+		"main.go":              "package main\n",
+		"services/api/go.mod":  "module example.com/api\n",
+		"services/api/api.go":  "package api\n",
+		"web/package.json":     `{"name": "@example/web"}`,
+		"web/src/index.ts":     "export {}\n",
+		"docs/architecture.md": "# Architecture\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	sc := config.ScopesConfig{
+		Rules:    []config.ScopeRule{{Glob: "docs/**", Scope: "docs"}},
+		Monorepo: true,
+	}
+	got := InferScope(sc, root, []string{"services/api/api.go", "main.go"})
+	if got.Scope != "api" {
+		t.Errorf("InferScope() scope = %q, want %q", got.Scope, "api")
+	}
+
+	got = InferScope(sc, root, []string{"web/src/index.ts", "docs/architecture.md"})
+	if got.Scope != "docs,web" {
+		t.Errorf("InferScope() scope = %q, want %q", got.Scope, "docs,web")
+	}
+}
+
+func TestApplyScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		msg   string
+		scope string
+		want  string
+	}{
+		{"add", "feat: add scopes\n\nbody", "api", "feat(api): add scopes\n\nbody"},
+		{"replace", "fix(web): handle errors", "api", "fix(api): handle errors"},
+		{"keep breaking marker", "feat(web)!: drop v1", "api", "feat(api)!: drop v1"},
+		{"remove", "feat(web): add scopes", "", "feat: add scopes"},
+		{"no prefix", "Add scopes", "api", "Add scopes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ApplyScope(tt.msg, tt.scope); got != tt.want {
+				t.Errorf("ApplyScope() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageScope(t *testing.T) {
+	if scope, ok := MessageScope("feat(api): add scopes"); !ok || scope != "api" {
+		t.Errorf("MessageScope() = %q, %v, want %q, true", scope, ok, "api")
+	}
+	if scope, ok := MessageScope("feat: add scopes"); !ok || scope != "" {
+		t.Errorf("MessageScope() = %q, %v, want \"\", true", scope, ok)
+	}
+	if _, ok := MessageScope("Add scopes"); ok {
+		t.Error("MessageScope() reported a prefix for a plain summary line")
+	}
+}
@@ -0,0 +1,30 @@
+package message
+
+import (
+	"strings"
+)
+
+// CommitsPromptSection lists the messages of the commits being squashed for the prompt
+func CommitsPromptSection(commits []string) string {
+	var sections []string
+	for _, commit := range commits {
+		if commit = strings.TrimSpace(commit); commit != "" {
+			sections = append(sections, "---\n"+commit)
+		}
+	}
+	return strings.Join(sections, "\n")
+}
+
+// SquashTrailers carries the footers of the squashed commits (Refs, Closes, Co-authored-by, ...)
+// over to the combined message and credits the other authors as co-authors
+func SquashTrailers(msg string, commits []string, authors []string, self string) string {
+	for _, commit := range commits {
+		msg = KeepTrailers(msg, commit)
+	}
+	for _, author := range authors {
+		if !strings.EqualFold(author, self) {
+			msg = addFooter(msg, "Co-authored-by: "+author, author)
+		}
+	}
+	return msg
+}
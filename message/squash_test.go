@@ -0,0 +1,24 @@
+package message
+
+import "testing"
+
+func TestCommitsPromptSection(t *testing.T) {
+	got := CommitsPromptSection([]string{"wip\n", "", "fix: typo\n\nin the README"})
+	want := "---\nwip\n---\nfix: typo\n\nin the README"
+	if got != want {
+		t.Errorf("CommitsPromptSection() = %q, want %q", got, want)
+	}
+}
+
+func TestSquashTrailers(t *testing.T) {
+	commits := []string{
+		"wip\n\nRefs: ABC-1",
+		"fix\n\nRefs: ABC-1\nCo-authored-by: Carol <carol@example.com>",
+	}
+	authors := []string{"Alice <alice@example.com>", "Bob <bob@example.com>", "Carol <carol@example.com>"}
+	got := SquashTrailers("feat: add login", commits, authors, "alice <ALICE@example.com>")
+	want := "feat: add login\n\nRefs: ABC-1\nCo-authored-by: Carol <carol@example.com>\nCo-authored-by: Bob <bob@example.com>"
+	if got != want {
+		t.Errorf("SquashTrailers() = %q, want %q", got, want)
+	}
+}
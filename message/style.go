@@ -0,0 +1,275 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// inferSamples is the number of commits analyzed when inferring the style
+const inferSamples = 50
+
+// Emoji styles found in commit subjects
+const (
+	EmojiNone      = config.EmojiNone
+	EmojiShortcode = config.EmojiShortcode
+	EmojiUnicode   = config.EmojiUnicode
+)
+
+var (
+	// typePrefixPattern matches a semantic release prefix and captures its type
+	typePrefixPattern = regexp.MustCompile(`^([a-z][a-z0-9_-]*)(\([^)]*\))?!?: `)
+	shortcodePattern  = regexp.MustCompile(`:[a-z0-9_+\-]+:`)
+)
+
+// Style is the commit convention inferred from a repository's history
+type Style struct {
+	// Samples is the number of commit messages analyzed
+	Samples int
+	// Language is japanese or english
+	Language string
+	// PrefixShare is the share of subjects that start with a type prefix such as "feat: "
+	PrefixShare float64
+	// Types are the prefix types used, most frequent first
+	Types []string
+	// Emoji is one of EmojiNone, EmojiShortcode or EmojiUnicode
+	Emoji string
+	// SubjectLength is the length (in characters) that 90% of the subjects do not exceed
+	SubjectLength int
+}
+
+// Conventional reports whether most subjects use a type prefix
+func (s Style) Conventional() bool {
+	return s.PrefixShare >= 0.5
+}
+
+// Preset returns the init preset closest to the style
+func (s Style) Preset() string {
+	switch {
+	case s.Conventional() && s.Emoji == EmojiShortcode:
+		return "gitmoji"
+	case s.Conventional():
+		return "conventional"
+	default:
+		return "plain"
+	}
+}
+
+// InferStyle analyzes commit messages and returns the prevailing convention
+func InferStyle(messages []string) Style {
+	style := Style{Samples: len(messages), Language: "english", Emoji: EmojiNone}
+	if len(messages) == 0 {
+		return style
+	}
+
+	var japanese, prefixed, shortcodes, unicodeEmoji int
+	typeCounts := map[string]int{}
+	lengths := make([]int, 0, len(messages))
+	for _, msg := range messages {
+		subject, _, _ := strings.Cut(strings.TrimSpace(msg), "\n")
+		lengths = append(lengths, utf8.RuneCountInString(subject))
+
+		if containsJapanese(subject) {
+			japanese++
+		}
+		if m := typePrefixPattern.FindStringSubmatch(subject); m != nil {
+			prefixed++
+			typeCounts[m[1]]++
+		}
+		switch {
+		case shortcodePattern.MatchString(subject):
+			shortcodes++
+		case containsEmoji(subject):
+			unicodeEmoji++
+		}
+	}
+
+	total := len(messages)
+	if japanese*2 >= total {
+		style.Language = "japanese"
+	}
+	style.PrefixShare = float64(prefixed) / float64(total)
+	switch {
+	case shortcodes*3 >= total && shortcodes >= unicodeEmoji:
+		style.Emoji = EmojiShortcode
+	case unicodeEmoji*3 >= total:
+		style.Emoji = EmojiUnicode
+	}
+
+	for t := range typeCounts {
+		style.Types = append(style.Types, t)
+	}
+	sort.Slice(style.Types, func(i, j int) bool {
+		if typeCounts[style.Types[i]] != typeCounts[style.Types[j]] {
+			return typeCounts[style.Types[i]] > typeCounts[style.Types[j]]
+		}
+		return style.Types[i] < style.Types[j]
+	})
+
+	sort.Ints(lengths)
+	style.SubjectLength = lengths[(len(lengths)*9+9)/10-1]
+	return style
+}
+
+// Guidelines describes the style as prompt guidelines in the given language
+func (s Style) Guidelines(lang string) []string {
+	ja := config.NormalizeLangCode(lang) == "ja"
+	var guidelines []string
+
+	if s.Conventional() {
+		types := s.Types
+		if len(types) > 5 {
+			types = types[:5]
+		}
+		if ja {
+			guidelines = append(guidelines, fmt.Sprintf("このリポジトリでは 'feat: ' のような Prefix を使います（よく使われるもの: %s）", strings.Join(types, ", ")))
+		} else {
+			guidelines = append(guidelines, fmt.Sprintf("This repository uses type prefixes such as 'feat: ' (most used: %s)", strings.Join(types, ", ")))
+		}
+	} else if ja {
+		guidelines = append(guidelines, "このリポジトリでは 'feat: ' のような Prefix を使いません")
+	} else {
+		guidelines = append(guidelines, "This repository does not use type prefixes such as 'feat: '")
+	}
+
+	switch {
+	case s.Emoji == EmojiShortcode && ja:
+		guidelines = append(guidelines, "要約行に :sparkles: のような絵文字の shortcode を入れる")
+	case s.Emoji == EmojiShortcode:
+		guidelines = append(guidelines, "Include an emoji shortcode such as :sparkles: in the summary line")
+	case s.Emoji == EmojiUnicode && ja:
+		guidelines = append(guidelines, "要約行に ✨ のような絵文字を入れる")
+	case s.Emoji == EmojiUnicode:
+		guidelines = append(guidelines, "Include an emoji such as ✨ in the summary line")
+	case ja:
+		guidelines = append(guidelines, "絵文字は使わない")
+	default:
+		guidelines = append(guidelines, "Do not use emoji")
+	}
+
+	if s.SubjectLength > 0 {
+		if ja {
+			guidelines = append(guidelines, fmt.Sprintf("要約行は%d文字以内にする", s.SubjectLength))
+		} else {
+			guidelines = append(guidelines, fmt.Sprintf("Keep the summary line within %d characters", s.SubjectLength))
+		}
+	}
+	return guidelines
+}
+
+// ApplyStyle configures cfg for the inferred style. Values set explicitly (such as the
+// language) are kept; the style is added to the guidelines of every template.
+func ApplyStyle(cfg *config.Config, style Style) {
+	if cfg.Defaults.Language == "" {
+		cfg.Defaults.Language = style.Language
+	}
+	if cfg.Defaults.Emoji == "" && style.Conventional() {
+		cfg.Defaults.Emoji = style.Emoji
+	}
+
+	if !style.Conventional() {
+		cfg.SemanticReleasePrefixes = nil
+	}
+	if style.Emoji == EmojiNone {
+		for i := range cfg.SemanticReleasePrefixes {
+			cfg.SemanticReleasePrefixes[i].Emoji = ""
+		}
+	}
+
+	for name, template := range cfg.PromptTemplates {
+		lang := "english"
+		if strings.HasPrefix(strings.ToLower(name), "j") {
+			lang = "japanese"
+		}
+
+		var guidelines []string
+		for _, guideline := range template.Guidelines {
+			// The default guidelines ask for semantic release prefixes
+			if !style.Conventional() && strings.Contains(strings.ToLower(guideline), "semantic release") {
+				continue
+			}
+			guidelines = append(guidelines, guideline)
+		}
+		template.Guidelines = append(guidelines, style.Guidelines(lang)...)
+		cfg.PromptTemplates[name] = template
+	}
+}
+
+// LearnStyle samples recent commit messages as few-shot examples (style.examples) and,
+// when autoConfigure is set and style.infer is enabled, applies the inferred style to cfg.
+// It returns the inferred style, or nil if none was inferred.
+func LearnStyle(cfg *config.Config, autoConfigure bool) (*Style, error) {
+	sc := cfg.Style
+	infer := sc.Infer && autoConfigure
+	if sc.Examples <= 0 && !infer {
+		return nil, nil
+	}
+
+	opts := git.LogOptions{Limit: sc.Examples, Author: sc.Author, Paths: sc.Paths}
+	if infer && opts.Limit < inferSamples {
+		opts.Limit = inferSamples
+	}
+	if opts.Author == "me" {
+		email, err := git.GetConfigValue("user.email")
+		if err != nil {
+			return nil, err
+		}
+		opts.Author = email
+	}
+	if sc.StagedPaths {
+		files, err := git.GetStagedFiles()
+		if err != nil {
+			return nil, err
+		}
+		opts.Paths = append(opts.Paths, files...)
+	}
+
+	messages, err := git.GetRecentCommitMessages(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit history: %w", err)
+	}
+	// New files have no history; fall back to the whole repository
+	if len(messages) == 0 && sc.StagedPaths {
+		opts.Paths = sc.Paths
+		if messages, err = git.GetRecentCommitMessages(opts); err != nil {
+			return nil, fmt.Errorf("failed to read commit history: %w", err)
+		}
+	}
+
+	if sc.Examples > 0 {
+		cfg.Examples = messages[:min(len(messages), sc.Examples)]
+	}
+	if !infer || len(messages) == 0 {
+		return nil, nil
+	}
+
+	style := InferStyle(messages)
+	ApplyStyle(cfg, style)
+	return &style, nil
+}
+
+// containsJapanese reports whether s contains Hiragana, Katakana or Han characters
+func containsJapanese(s string) bool {
+	for _, r := range s {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana, unicode.Han) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsEmoji reports whether s contains a character from the common emoji blocks
+func containsEmoji(s string) bool {
+	for _, r := range s {
+		if (r >= 0x1F300 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package message
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestInferStyle(t *testing.T) {
+	conventional := InferStyle([]string{
+		"feat: add login form\n\n- details",
+		"fix(api): handle timeouts",
+		"feat: :sparkles: add logout",
+		"docs: update README",
+	})
+	if !conventional.Conventional() || conventional.Types[0] != "feat" {
+		t.Errorf("Expected a conventional style led by feat, got %+v", conventional)
+	}
+	if conventional.Language != "english" || conventional.Emoji != EmojiNone {
+		t.Errorf("Unexpected language or emoji: %+v", conventional)
+	}
+	if conventional.Preset() != "conventional" {
+		t.Errorf("Expected the conventional preset, got %s", conventional.Preset())
+	}
+
+	japanese := InferStyle([]string{
+		"ログイン画面を追加 ✨",
+		"タイムアウトを修正 🐛",
+		"Update README",
+	})
+	if japanese.Language != "japanese" || japanese.Conventional() || japanese.Emoji != EmojiUnicode {
+		t.Errorf("Unexpected style: %+v", japanese)
+	}
+	if japanese.SubjectLength != len("Update README") {
+		t.Errorf("Unexpected subject length %d", japanese.SubjectLength)
+	}
+}
+
+func TestApplyStyleKeepsExplicitLanguage(t *testing.T) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+	cfg.Defaults.Language = "english"
+
+	ApplyStyle(cfg, Style{Language: "japanese", PrefixShare: 0, Emoji: EmojiNone, SubjectLength: 50})
+
+	if cfg.Defaults.Language != "english" {
+		t.Errorf("Expected the explicit language to be kept, got %s", cfg.Defaults.Language)
+	}
+	if len(cfg.SemanticReleasePrefixes) != 0 {
+		t.Errorf("Expected prefixes to be dropped for a plain style")
+	}
+	guidelines := strings.Join(cfg.PromptTemplates["english"].Guidelines, "\n")
+	if strings.Contains(guidelines, "Semantic Release") || !strings.Contains(guidelines, "within 50 characters") {
+		t.Errorf("Unexpected guidelines:\n%s", guidelines)
+	}
+}
@@ -0,0 +1,168 @@
+package message
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+// defaultTicketFooter is the footer key used when neither the pattern nor the config sets one
+const defaultTicketFooter = "Refs"
+
+var (
+	// subjectPrefixPattern matches a semantic release prefix such as "feat(api)!: " with an optional :shortcode:
+	subjectPrefixPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*(\([^)]*\))?!?:\s*(:[a-z0-9_+\-]+:\s*)?`)
+	// footerLinePattern matches a git trailer or Conventional Commits footer line
+	footerLinePattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9-]*|BREAKING CHANGE)(: | #)`)
+)
+
+// Ticket is a ticket reference extracted from a branch name
+type Ticket struct {
+	// Name is the name of the pattern that matched (e.g. jira)
+	Name string
+	// ID is the raw ticket ID (e.g. ABC-123 or 42)
+	ID string
+	// Reference is the ID formatted for the message (e.g. ABC-123 or #42)
+	Reference string
+	// Footer is the footer key used with the footer placement (e.g. Refs or Closes)
+	Footer string
+}
+
+// ExtractTicket returns the first ticket reference found in branch, or nil if no pattern matches
+func ExtractTicket(branch string, tc config.TicketConfig) (*Ticket, error) {
+	for _, pattern := range tc.Patterns {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticket pattern %q: %w", pattern.Name, err)
+		}
+
+		m := re.FindStringSubmatch(branch)
+		if m == nil {
+			continue
+		}
+		id := m[0]
+		if len(m) > 1 && m[1] != "" {
+			id = m[1]
+		}
+		if pattern.Uppercase {
+			id = strings.ToUpper(id)
+		}
+
+		reference := pattern.Reference
+		if reference == "" {
+			reference = "{id}"
+		}
+		footer := pattern.Footer
+		if footer == "" {
+			footer = tc.Footer
+		}
+		if footer == "" {
+			footer = defaultTicketFooter
+		}
+
+		return &Ticket{
+			Name:      pattern.Name,
+			ID:        id,
+			Reference: strings.ReplaceAll(reference, "{id}", id),
+			Footer:    footer,
+		}, nil
+	}
+	return nil, nil
+}
+
+// ApplyTicket inserts the ticket reference into msg at the configured placement.
+// Nothing is changed when the reference is already present at that placement.
+func ApplyTicket(msg string, ticket *Ticket, placement string) string {
+	if ticket == nil {
+		return msg
+	}
+
+	subject, body, hasBody := strings.Cut(msg, "\n")
+	switch placement {
+	case config.TicketPlacementNone:
+		return msg
+	case config.TicketPlacementSubjectPrefix:
+		if containsReference(subject, ticket.Reference) {
+			return msg
+		}
+		prefix := subjectPrefixPattern.FindString(subject)
+		subject = prefix + ticket.Reference + " " + strings.TrimPrefix(subject, prefix)
+	case config.TicketPlacementFooter:
+		return addFooter(msg, footerLine(ticket.Footer, ticket.Reference), ticket.Reference)
+	default:
+		if containsReference(subject, ticket.Reference) {
+			return msg
+		}
+		subject = strings.TrimRight(subject, " ") + " " + ticket.Reference
+	}
+
+	if !hasBody {
+		return subject
+	}
+	return subject + "\n" + body
+}
+
+// ContainsTicket reports whether msg mentions the ticket reference
+func ContainsTicket(msg string, ticket *Ticket) bool {
+	return ticket == nil || containsReference(msg, ticket.Reference)
+}
+
+// containsReference reports whether text mentions reference as a whole word, so that #4 is not
+// found in #42 nor ABC-1 in ABC-12
+func containsReference(text, reference string) bool {
+	re := regexp.MustCompile(`(?:^|[^A-Za-z0-9_])` + regexp.QuoteMeta(reference) + `(?:$|[^A-Za-z0-9_])`)
+	return re.MatchString(text)
+}
+
+// ReferenceTicket extracts the ticket from branch, inserts it into msg according to the config
+// and checks that the final message contains it. The returned ticket is nil if none was found.
+func ReferenceTicket(msg string, branch string, tc config.TicketConfig) (string, *Ticket, error) {
+	ticket, err := ExtractTicket(branch, tc)
+	if err != nil || ticket == nil {
+		return msg, nil, err
+	}
+
+	msg = ApplyTicket(msg, ticket, tc.Placement)
+	if tc.Required && !ContainsTicket(msg, ticket) {
+		return msg, ticket, fmt.Errorf("commit message does not reference ticket %s", ticket.Reference)
+	}
+	return msg, ticket, nil
+}
+
+// footerLine formats a footer the Conventional Commits way: "Closes #42" for issue numbers, "Refs: ABC-123" otherwise
+func footerLine(key, reference string) string {
+	if strings.HasPrefix(reference, "#") {
+		return key + " " + reference
+	}
+	return key + ": " + reference
+}
+
+// addFooter appends line to the footer block of msg, starting a new block if the last
+// paragraph is not one. Nothing is changed if a footer line already contains reference.
+func addFooter(msg string, line string, reference string) string {
+	msg = strings.TrimRight(msg, "\n")
+	paragraphs := strings.Split(msg, "\n\n")
+	last := paragraphs[len(paragraphs)-1]
+
+	if len(paragraphs) > 1 && isFooterBlock(last) {
+		for _, footer := range strings.Split(last, "\n") {
+			if containsReference(footer, reference) {
+				return msg
+			}
+		}
+		return msg + "\n" + line
+	}
+	return msg + "\n\n" + line
+}
+
+// isFooterBlock reports whether every line of a paragraph is a footer line
+func isFooterBlock(paragraph string) bool {
+	for _, line := range strings.Split(paragraph, "\n") {
+		if !footerLinePattern.MatchString(line) {
+			return false
+		}
+	}
+	return paragraph != ""
+}
@@ -0,0 +1,113 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestExtractTicketWithDefaultPatterns(t *testing.T) {
+	cfg, err := config.LoadDefault()
+	if err != nil {
+		t.Fatalf("Failed to load default config: %v", err)
+	}
+
+	tests := []struct {
+		branch    string
+		reference string
+	}{
+		{"feature/ABC-123-login", "ABC-123"},
+		{"fix/gh-42", "#42"},
+		{"fix/gh-42-crash", "#42"},
+		{"feature/issue-1234-add-button", "#1234"},
+		{"fix/#42", "#42"},
+		{"issue-7", "#7"},
+		{"feature/1234-add-button", ""},
+		{"hotfix/2024-10-18", ""},
+		{"release/1.2", ""},
+		{"deps/3-bump-yaml", ""},
+		{"main", ""},
+		{"feature/v2-login", ""},
+	}
+
+	for _, tt := range tests {
+		ticket, err := ExtractTicket(tt.branch, cfg.Ticket)
+		if err != nil {
+			t.Fatalf("ExtractTicket(%q) failed: %v", tt.branch, err)
+		}
+		got := ""
+		if ticket != nil {
+			got = ticket.Reference
+		}
+		if got != tt.reference {
+			t.Errorf("ExtractTicket(%q) = %q, want %q", tt.branch, got, tt.reference)
+		}
+	}
+}
+
+func TestExtractTicketUppercase(t *testing.T) {
+	tc := config.TicketConfig{Patterns: []config.TicketPattern{
+		{Name: "linear", Regex: `(?:^|/)([a-z]{2,5}-[0-9]+)(?:-|$)`, Uppercase: true},
+	}}
+	ticket, err := ExtractTicket("alice/eng-123-fix-login", tc)
+	if err != nil || ticket == nil {
+		t.Fatalf("Expected a ticket, got %v, %v", ticket, err)
+	}
+	if ticket.Reference != "ENG-123" || ticket.Footer != "Refs" {
+		t.Errorf("Unexpected ticket: %+v", ticket)
+	}
+}
+
+func TestApplyTicket(t *testing.T) {
+	jira := &Ticket{Reference: "ABC-123", Footer: "Refs"}
+	issue := &Ticket{Reference: "#42", Footer: "Closes"}
+
+	tests := []struct {
+		name      string
+		msg       string
+		ticket    *Ticket
+		placement string
+		want      string
+	}{
+		{"subject", "feat: add login", jira, config.TicketPlacementSubject, "feat: add login ABC-123"},
+		{"subject keeps body", "feat: add login\n\n- detail", issue, "", "feat: add login #42\n\n- detail"},
+		{"subject already present", "feat: add login #42", issue, config.TicketPlacementSubject, "feat: add login #42"},
+		{"subject with a longer number", "feat: add login #421", issue, config.TicketPlacementSubject, "feat: add login #421 #42"},
+		{"subject with a longer key", "feat: add login ABC-1234", jira, config.TicketPlacementSubject, "feat: add login ABC-1234 ABC-123"},
+		{"subject prefix", "feat(api): :sparkles: add login", jira, config.TicketPlacementSubjectPrefix, "feat(api): :sparkles: ABC-123 add login"},
+		{"subject prefix without type", "Add login", jira, config.TicketPlacementSubjectPrefix, "ABC-123 Add login"},
+		{"footer", "feat: add login\n\n- detail\n", jira, config.TicketPlacementFooter, "feat: add login\n\n- detail\n\nRefs: ABC-123"},
+		{"footer issue", "fix: crash", issue, config.TicketPlacementFooter, "fix: crash\n\nCloses #42"},
+		{"footer block", "fix: crash\n\nSigned-off-by: A <a@example.com>", issue, config.TicketPlacementFooter, "fix: crash\n\nSigned-off-by: A <a@example.com>\nCloses #42"},
+		{"footer already present", "fix: crash\n\nRefs #42", issue, config.TicketPlacementFooter, "fix: crash\n\nRefs #42"},
+		{"footer with a longer number", "fix: crash\n\nRefs #420", issue, config.TicketPlacementFooter, "fix: crash\n\nRefs #420\nCloses #42"},
+		{"none", "fix: crash", issue, config.TicketPlacementNone, "fix: crash"},
+	}
+
+	for _, tt := range tests {
+		if got := ApplyTicket(tt.msg, tt.ticket, tt.placement); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReferenceTicketRequired(t *testing.T) {
+	tc := config.TicketConfig{
+		Placement: config.TicketPlacementNone,
+		Required:  true,
+		Patterns:  []config.TicketPattern{{Name: "jira", Regex: `[A-Z]+-[0-9]+`}},
+	}
+
+	if _, _, err := ReferenceTicket("feat: add login", "feature/ABC-1-login", tc); err == nil {
+		t.Errorf("Expected an error when the required ticket is missing")
+	}
+	if _, _, err := ReferenceTicket("feat: ABC-12 add login", "feature/ABC-1-login", tc); err == nil {
+		t.Errorf("Expected ABC-12 not to count as ABC-1")
+	}
+	if _, _, err := ReferenceTicket("feat: ABC-1 add login", "feature/ABC-1-login", tc); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if msg, ticket, err := ReferenceTicket("feat: add login", "main", tc); err != nil || ticket != nil || msg != "feat: add login" {
+		t.Errorf("Expected no ticket for main, got %q, %v, %v", msg, ticket, err)
+	}
+}
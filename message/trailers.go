@@ -0,0 +1,185 @@
+package message
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// defaultTeamFile is the co-author file looked up at the repository root when none is configured
+const defaultTeamFile = ".mailmap"
+
+// identityPattern matches "Name <email>" as used in .mailmap files and trailers
+var identityPattern = regexp.MustCompile(`([^<>]*?)\s*<([^<>]+)>`)
+
+// TeamMember is a person listed in the team file
+type TeamMember struct {
+	Name  string
+	Email string
+	// Aliases are the other names and emails of the line (the "commit" identities of a .mailmap entry)
+	Aliases []string
+}
+
+// Identity returns the member as "Name <email>"
+func (m TeamMember) Identity() string {
+	return fmt.Sprintf("%s <%s>", m.Name, m.Email)
+}
+
+// matches reports whether alias refers to the member: the full name, a word of the name,
+// the email, the local part of the email, or one of the aliases (case-insensitive)
+func (m TeamMember) matches(alias string) bool {
+	candidates := append([]string{m.Name, m.Email, strings.Split(m.Email, "@")[0]}, strings.Fields(m.Name)...)
+	candidates = append(candidates, m.Aliases...)
+	for _, candidate := range candidates {
+		if strings.EqualFold(candidate, alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// TrailerOptions holds the trailer inputs that come from the command line rather than the config
+type TrailerOptions struct {
+	// Pairs are co-authors for this commit: team file aliases or "Name <email>"
+	Pairs []string
+	// Provider and Model fill the {provider} and {model} placeholders of generated_by
+	Provider string
+	Model    string
+}
+
+// LoadTeam parses a .mailmap-style team file. Each line holds "Name <email>",
+// optionally followed by further names and emails of the same person.
+func LoadTeam(path string) ([]TeamMember, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var team []TeamMember
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		matches := identityPattern.FindAllStringSubmatch(line, -1)
+		if len(matches) == 0 || strings.TrimSpace(matches[0][1]) == "" {
+			continue
+		}
+		member := TeamMember{Name: strings.TrimSpace(matches[0][1]), Email: matches[0][2]}
+		for _, m := range matches[1:] {
+			if name := strings.TrimSpace(m[1]); name != "" {
+				member.Aliases = append(member.Aliases, name)
+			}
+			member.Aliases = append(member.Aliases, m[2])
+		}
+		team = append(team, member)
+	}
+	return team, scanner.Err()
+}
+
+// ResolveCoAuthor turns a --pair entry into "Name <email>", looking up aliases in the team
+func ResolveCoAuthor(alias string, team []TeamMember) (string, error) {
+	alias = strings.TrimSpace(alias)
+	if identityPattern.MatchString(alias) {
+		return alias, nil
+	}
+
+	var found []TeamMember
+	for _, member := range team {
+		if member.matches(alias) {
+			found = append(found, member)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("unknown co-author %q (add them to the team file or use \"Name <email>\")", alias)
+	case 1:
+		return found[0].Identity(), nil
+	default:
+		return "", fmt.Errorf("co-author %q is ambiguous (%s, %s, ...)", alias, found[0].Identity(), found[1].Identity())
+	}
+}
+
+// Trailers returns the trailers to append, in order: Co-authored-by, Signed-off-by, Generated-by
+func Trailers(tc config.TrailersConfig, opts TrailerOptions) ([]string, error) {
+	var trailers []string
+
+	coAuthors := append(append([]string{}, tc.CoAuthors...), opts.Pairs...)
+	if len(coAuthors) > 0 {
+		team, err := loadTeamFile(tc.TeamFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range coAuthors {
+			if strings.TrimSpace(alias) == "" {
+				continue
+			}
+			identity, err := ResolveCoAuthor(alias, team)
+			if err != nil {
+				return nil, err
+			}
+			trailers = append(trailers, "Co-authored-by: "+identity)
+		}
+	}
+
+	if tc.Signoff {
+		identity, err := git.GetUserIdentity()
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign off: %w", err)
+		}
+		trailers = append(trailers, "Signed-off-by: "+identity)
+	}
+
+	if tc.GeneratedBy != "" {
+		value := strings.NewReplacer("{provider}", opts.Provider, "{model}", opts.Model).Replace(tc.GeneratedBy)
+		trailers = append(trailers, "Generated-by: "+value)
+	}
+
+	return trailers, nil
+}
+
+// AddTrailers appends the configured trailers to msg with git interpret-trailers semantics,
+// so trailers already present in the message are merged rather than duplicated
+func AddTrailers(msg string, tc config.TrailersConfig, opts TrailerOptions) (string, error) {
+	trailers, err := Trailers(tc, opts)
+	if err != nil || len(trailers) == 0 {
+		return msg, err
+	}
+
+	ifExists := tc.IfExists
+	if ifExists == "" {
+		ifExists = "addIfDifferent"
+	}
+	return git.InterpretTrailers(msg, trailers, ifExists)
+}
+
+// loadTeamFile loads the team file; a missing default .mailmap is not an error
+func loadTeamFile(path string) ([]TeamMember, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultTeamFile
+	}
+	if !filepath.IsAbs(path) {
+		if root, err := git.GetRepoRoot(); err == nil {
+			path = filepath.Join(root, path)
+		}
+	}
+
+	team, err := LoadTeam(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read team file: %w", err)
+	}
+	return team, nil
+}
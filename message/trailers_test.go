@@ -0,0 +1,55 @@
+package message
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveCoAuthorFromTeamFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".mailmap")
+	content := `# team
+Alice Smith <alice@example.com> <alice@old.example.com>
+Bob Jones <bob@example.com> Bobby <bobby@example.com>
+Bob Brown <brown@example.com>
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write team file: %v", err)
+	}
+
+	team, err := LoadTeam(path)
+	if err != nil {
+		t.Fatalf("LoadTeam failed: %v", err)
+	}
+	if len(team) != 3 {
+		t.Fatalf("Expected 3 members, got %d", len(team))
+	}
+
+	tests := []struct {
+		alias string
+		want  string
+	}{
+		{"alice", "Alice Smith <alice@example.com>"},
+		{"alice@old.example.com", "Alice Smith <alice@example.com>"},
+		{"Bobby", "Bob Jones <bob@example.com>"},
+		{"brown", "Bob Brown <brown@example.com>"},
+		{"Carol <carol@example.com>", "Carol <carol@example.com>"},
+	}
+	for _, tt := range tests {
+		got, err := ResolveCoAuthor(tt.alias, team)
+		if err != nil {
+			t.Errorf("ResolveCoAuthor(%q) failed: %v", tt.alias, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ResolveCoAuthor(%q) = %q, want %q", tt.alias, got, tt.want)
+		}
+	}
+
+	if _, err := ResolveCoAuthor("bob", team); err == nil {
+		t.Errorf("Expected an error for an ambiguous alias")
+	}
+	if _, err := ResolveCoAuthor("dave", team); err == nil {
+		t.Errorf("Expected an error for an unknown alias")
+	}
+}
@@ -0,0 +1,257 @@
+package metrics
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// Kinds of events in the metrics log
+const (
+	KindGeneration = "generation"
+	KindOutcome    = "outcome"
+)
+
+// Results of a generated message
+const (
+	// Accepted means the message was committed as generated
+	Accepted = "accepted"
+	// Edited means the committed message was changed but is still similar to the generated one
+	Edited = "edited"
+	// Discarded means the message was not committed: the commit has a message of its own, the
+	// message was generated again, or nothing was committed within MatchWindow
+	Discarded = "discarded"
+	// Pending means the message may still be committed
+	Pending = "pending"
+)
+
+// MatchWindow is how long after its generation a commit is matched with a message
+const MatchWindow = time.Hour
+
+// MinSimilarity is the similarity (1 - edit distance / length) a committed message needs to
+// count as an edit of the generated one rather than a message of its own
+const MinSimilarity = 0.5
+
+// Event is one line of the metrics log: a generated message, or what became of it
+type Event struct {
+	Kind string    `json:"kind"`
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Repo string    `json:"repo,omitempty"`
+
+	// Generations record where the message came from and how long it took
+	Branch   string `json:"branch,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+	// Prompt identifies the prompt template (see PromptID); ExtraPrompt is set when --prompt was given
+	Prompt      string `json:"prompt,omitempty"`
+	ExtraPrompt bool   `json:"extra_prompt,omitempty"`
+	LatencyMS   int64  `json:"latency_ms,omitempty"`
+	Message     string `json:"message,omitempty"`
+
+	// Outcomes refer to their generation by ID and record the commit
+	Result   string `json:"result,omitempty"`
+	Distance int    `json:"distance,omitempty"`
+	Commit   string `json:"commit,omitempty"`
+}
+
+// Path returns the configured metrics log; a leading "~/" is the home directory
+func Path(mc config.MetricsConfig) (string, error) {
+	path, err := config.StatePath(mc.Path, "metrics.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the metrics log: %w", err)
+	}
+	return path, nil
+}
+
+// PromptID identifies the prompt template used for a language by the language and a short hash
+// of the template and its guidelines, so that changed templates are told apart in the stats
+func PromptID(cfg *config.Config, lang string) string {
+	h := sha256.New()
+	template := cfg.PromptTemplate(lang)
+	h.Write([]byte(template.Template))
+	for _, guideline := range template.Guidelines {
+		h.Write([]byte("\x00" + guideline))
+	}
+	return fmt.Sprintf("%s@%s", lang, hex.EncodeToString(h.Sum(nil))[:8])
+}
+
+// RecordGeneration appends a generated message to the metrics log if metrics are enabled. A
+// log that cannot be written only prints a warning.
+func RecordGeneration(e Event) {
+	cfg := config.Get()
+	if !cfg.Metrics.Enabled {
+		return
+	}
+	e.Kind = KindGeneration
+	e.ID = newID()
+	e.Time = time.Now().UTC()
+	e.Repo, _ = git.GetRepoRoot()
+
+	path, err := Path(cfg.Metrics)
+	if err == nil {
+		err = Append(path, e)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the metrics log: %v\n", err)
+	}
+}
+
+// RecordCommit matches a new commit with the latest pending message generated in the same
+// repository and branch and appends the outcome to the log at path. It returns nil when no
+// message is pending, for commits written without the tool.
+func RecordCommit(path, repo, branch, commit, msg string, now time.Time) (*Event, error) {
+	events, err := Read(path)
+	if err != nil {
+		return nil, err
+	}
+	generation := latestPending(events, repo, branch, now)
+	if generation == nil {
+		return nil, nil
+	}
+
+	result, distance := Classify(generation.Message, msg)
+	outcome := Event{
+		Kind:     KindOutcome,
+		ID:       generation.ID,
+		Time:     now.UTC(),
+		Repo:     repo,
+		Result:   result,
+		Distance: distance,
+		Commit:   commit,
+	}
+	if err := Append(path, outcome); err != nil {
+		return nil, err
+	}
+	return &outcome, nil
+}
+
+// latestPending returns the latest generation in repo and branch that is still pending (see
+// Results)
+func latestPending(events []Event, repo, branch string, now time.Time) *Event {
+	results := Results(events, now)
+	var latest *Event
+	for i, e := range events {
+		if e.Kind != KindGeneration || e.Repo != repo || results[e.ID].Result != Pending {
+			continue
+		}
+		if branch != "" && e.Branch != "" && e.Branch != branch {
+			continue
+		}
+		if latest == nil || !e.Time.Before(latest.Time) {
+			latest = &events[i]
+		}
+	}
+	return latest
+}
+
+// Classify compares a generated message with the committed one
+func Classify(generated, committed string) (result string, distance int) {
+	generated, committed = Normalize(generated), Normalize(committed)
+	if generated == committed {
+		return Accepted, 0
+	}
+	distance = EditDistance(generated, committed)
+	length := max(len([]rune(generated)), len([]rune(committed)))
+	if 1-float64(distance)/float64(length) >= MinSimilarity {
+		return Edited, distance
+	}
+	return Discarded, distance
+}
+
+// Normalize removes what git removes from a commit message: comment lines, trailing
+// whitespace and surrounding blank lines
+func Normalize(msg string) string {
+	var lines []string
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// EditDistance returns the Levenshtein distance between a and b in characters
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// newID returns a random ID for a generation
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Append adds an event to the log at path as one line of JSON
+func Append(path string, e Event) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	// A single write of a whole line keeps lines of concurrent processes apart
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the events of the log at path; a missing log has no events and lines that
+// cannot be parsed are skipped
+func Read(path string) ([]Event, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			events = append(events, e)
+		}
+	}
+	return events, scanner.Err()
+}
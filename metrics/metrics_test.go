@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"feat: ログイン", "feat: ログアウト", 3},
+	}
+	for _, tt := range tests {
+		if got := EditDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("EditDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	generated := "feat: add login form\n\nAdds a form for email and password.\n"
+	tests := []struct {
+		committed string
+		want      string
+	}{
+		{"feat: add login form  \n\nAdds a form for email and password.\n# Please enter the commit message\n", Accepted},
+		{"feat(ui): add a login form\n\nAdds a form for email and password.", Edited},
+		{"wip", Discarded},
+	}
+	for _, tt := range tests {
+		result, distance := Classify(generated, tt.committed)
+		if result != tt.want {
+			t.Errorf("Classify(%q) = %s, want %s", tt.committed, result, tt.want)
+		}
+		if (result == Accepted) != (distance == 0) {
+			t.Errorf("Classify(%q) distance = %d", tt.committed, distance)
+		}
+	}
+}
+
+func TestPromptID(t *testing.T) {
+	cfg := &config.Config{PromptTemplates: map[string]config.PromptTemplate{
+		"english": {Template: "Write a commit message for {diff}", Guidelines: []string{"Be brief"}},
+	}}
+	id := PromptID(cfg, "english")
+	if !strings.HasPrefix(id, "english@") || len(id) != len("english@")+8 {
+		t.Errorf("unexpected prompt ID %q", id)
+	}
+	cfg.PromptTemplates["english"] = config.PromptTemplate{Template: "Write a commit message for {diff}", Guidelines: []string{"Be very brief"}}
+	if changed := PromptID(cfg, "english"); changed == id {
+		t.Errorf("expected a changed guideline to change the prompt ID %q", id)
+	}
+}
+
+func TestRecordCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "metrics.jsonl")
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	generations := []Event{
+		{Kind: KindGeneration, ID: "old", Time: now.Add(-2 * time.Hour), Repo: "/src/a", Branch: "main", Message: "fix: old"},
+		{Kind: KindGeneration, ID: "first", Time: now.Add(-10 * time.Minute), Repo: "/src/a", Branch: "main", Message: "feat: add login form"},
+		{Kind: KindGeneration, ID: "second", Time: now.Add(-5 * time.Minute), Repo: "/src/a", Branch: "main", Message: "feat: add a login form"},
+		{Kind: KindGeneration, ID: "other", Time: now.Add(-time.Minute), Repo: "/src/b", Branch: "main", Message: "docs: readme"},
+	}
+	for _, e := range generations {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	outcome, err := RecordCommit(path, "/src/a", "main", "abc123", "feat: add a login form\n", now)
+	if err != nil {
+		t.Fatalf("RecordCommit failed: %v", err)
+	}
+	if outcome == nil || outcome.ID != "second" || outcome.Result != Accepted || outcome.Commit != "abc123" {
+		t.Fatalf("unexpected outcome %+v", outcome)
+	}
+
+	// The first generation was superseded and the old one is outside the window
+	if outcome, err := RecordCommit(path, "/src/a", "main", "def456", "fix: typo", now); err != nil || outcome != nil {
+		t.Errorf("expected no pending generation, got %+v, %v", outcome, err)
+	}
+
+	events, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	results := Results(events, now)
+	for id, want := range map[string]string{"old": Discarded, "first": Discarded, "second": Accepted, "other": Pending} {
+		if got := results[id].Result; got != want {
+			t.Errorf("result of %s = %s, want %s", id, got, want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	events := []Event{
+		{Kind: KindGeneration, ID: "1", Time: now.Add(-3 * time.Hour), Repo: "/a", Provider: "claude", Prompt: "english@1", LatencyMS: 1000},
+		{Kind: KindOutcome, ID: "1", Result: Accepted},
+		{Kind: KindGeneration, ID: "2", Time: now.Add(-2 * time.Hour), Repo: "/a", Provider: "claude", Prompt: "english@1", LatencyMS: 3000},
+		{Kind: KindOutcome, ID: "2", Result: Edited, Distance: 12},
+		{Kind: KindGeneration, ID: "3", Time: now.Add(-time.Hour), Repo: "/b", Provider: "geminicli", Prompt: "english@2", ExtraPrompt: true, LatencyMS: 8000},
+		{Kind: KindOutcome, ID: "3", Result: Discarded, Distance: 40},
+	}
+
+	rows, err := Summarize(events, []string{"provider", "prompt"}, time.Time{}, now)
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	claude := rows[0]
+	if claude.Keys[0] != "claude" || claude.Generations != 2 || claude.Accepted != 1 || claude.Edited != 1 || claude.DistanceSum != 12 {
+		t.Errorf("unexpected claude row %+v", claude)
+	}
+	if rate, ok := claude.UsedRate(); !ok || rate != 1 {
+		t.Errorf("UsedRate = %v, %v; want 1", rate, ok)
+	}
+	if claude.MeanLatency() != 2*time.Second {
+		t.Errorf("MeanLatency = %s, want 2s", claude.MeanLatency())
+	}
+	if rows[1].Keys[1] != "english@2 +prompt" || rows[1].Discarded != 1 {
+		t.Errorf("unexpected geminicli row %+v", rows[1])
+	}
+
+	if recent, _ := Summarize(events, nil, now.Add(-90*time.Minute), now); len(recent) != 1 || recent[0].Generations != 1 {
+		t.Errorf("expected --since to keep one generation, got %+v", recent)
+	}
+	if _, err := Summarize(events, []string{"color"}, time.Time{}, now); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+
+	table := FormatTable(rows, []string{"provider", "prompt"})
+	if !strings.Contains(table, "total") || !strings.Contains(table, "67%") {
+		t.Errorf("unexpected table:\n%s", table)
+	}
+}
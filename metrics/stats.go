@@ -0,0 +1,208 @@
+package metrics
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Keys lists the values generations can be grouped by
+var Keys = []string{"provider", "model", "prompt", "repo", "day"}
+
+// Row sums the generations of one group
+type Row struct {
+	// Keys are the values of the grouping keys, in the order requested
+	Keys        []string `json:"keys,omitempty"`
+	Generations int      `json:"generations"`
+	Accepted    int      `json:"accepted"`
+	Edited      int      `json:"edited"`
+	Discarded   int      `json:"discarded"`
+	Pending     int      `json:"pending"`
+	// DistanceSum sums the edit distances of edited messages
+	DistanceSum int `json:"distance_sum"`
+	// LatencySum sums the latencies of all generations
+	LatencySum int64 `json:"latency_ms_sum"`
+}
+
+// add adds a generation with its result to the row
+func (r *Row) add(e Event, result string, distance int) {
+	r.Generations++
+	r.LatencySum += e.LatencyMS
+	switch result {
+	case Accepted:
+		r.Accepted++
+	case Edited:
+		r.Edited++
+		r.DistanceSum += distance
+	case Discarded:
+		r.Discarded++
+	default:
+		r.Pending++
+	}
+}
+
+// merge adds the sums of another row
+func (r *Row) merge(other Row) {
+	r.Generations += other.Generations
+	r.Accepted += other.Accepted
+	r.Edited += other.Edited
+	r.Discarded += other.Discarded
+	r.Pending += other.Pending
+	r.DistanceSum += other.DistanceSum
+	r.LatencySum += other.LatencySum
+}
+
+// UsedRate returns the share of decided generations that were committed, as is or edited
+func (r Row) UsedRate() (float64, bool) {
+	decided := r.Accepted + r.Edited + r.Discarded
+	if decided == 0 {
+		return 0, false
+	}
+	return float64(r.Accepted+r.Edited) / float64(decided), true
+}
+
+// MeanDistance returns the mean edit distance of the edited messages
+func (r Row) MeanDistance() (float64, bool) {
+	if r.Edited == 0 {
+		return 0, false
+	}
+	return float64(r.DistanceSum) / float64(r.Edited), true
+}
+
+// MeanLatency returns the mean time a generation took
+func (r Row) MeanLatency() time.Duration {
+	if r.Generations == 0 {
+		return 0
+	}
+	return time.Duration(r.LatencySum/int64(r.Generations)) * time.Millisecond
+}
+
+// key returns the value of a grouping key for a generation
+func key(e Event, name string) string {
+	var value string
+	switch name {
+	case "provider":
+		value = e.Provider
+	case "model":
+		value = e.Model
+	case "prompt":
+		value = e.Prompt
+		if e.ExtraPrompt {
+			value += " +prompt"
+		}
+	case "repo":
+		value = e.Repo
+	case "day":
+		value = e.Time.Local().Format(time.DateOnly)
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// Results returns the result of every generation by ID. Generations without an outcome are
+// discarded when a later message was generated in the same repository or MatchWindow has
+// passed, and pending otherwise.
+func Results(events []Event, now time.Time) map[string]Event {
+	results := map[string]Event{}
+	latest := map[string]time.Time{}
+	for _, e := range events {
+		switch e.Kind {
+		case KindOutcome:
+			results[e.ID] = e
+		case KindGeneration:
+			if e.Time.After(latest[e.Repo]) {
+				latest[e.Repo] = e.Time
+			}
+		}
+	}
+	for _, e := range events {
+		if e.Kind != KindGeneration {
+			continue
+		}
+		if _, ok := results[e.ID]; ok {
+			continue
+		}
+		result := Pending
+		if e.Time.Before(latest[e.Repo]) || now.Sub(e.Time) > MatchWindow {
+			result = Discarded
+		}
+		results[e.ID] = Event{Kind: KindOutcome, ID: e.ID, Result: result}
+	}
+	return results
+}
+
+// Summarize groups the generations made at or after since by the named keys (see Keys) and
+// sums the results of every group; rows are sorted by their keys
+func Summarize(events []Event, by []string, since, now time.Time) ([]Row, error) {
+	for _, name := range by {
+		if !slices.Contains(Keys, name) {
+			return nil, fmt.Errorf("unknown grouping '%s' (available: %s)", name, strings.Join(Keys, ", "))
+		}
+	}
+
+	results := Results(events, now)
+	groups := map[string]*Row{}
+	for _, e := range events {
+		if e.Kind != KindGeneration || e.Time.Before(since) {
+			continue
+		}
+		keys := make([]string, len(by))
+		for i, name := range by {
+			keys[i] = key(e, name)
+		}
+		id := strings.Join(keys, "\x00")
+		if groups[id] == nil {
+			groups[id] = &Row{Keys: keys}
+		}
+		outcome := results[e.ID]
+		groups[id].add(e, outcome.Result, outcome.Distance)
+	}
+
+	rows := make([]Row, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return strings.Join(rows[i].Keys, "\x00") < strings.Join(rows[j].Keys, "\x00")
+	})
+	return rows, nil
+}
+
+// FormatTable renders the rows under a header of the grouping keys, followed by their total
+func FormatTable(rows []Row, by []string) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	header := append(append([]string(nil), by...), "Generated", "Accepted", "Edited", "Discarded", "Pending", "Used", "Avg distance", "Avg latency")
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	total := Row{Keys: make([]string, len(by))}
+	for _, row := range rows {
+		total.merge(row)
+	}
+	if len(by) > 0 && len(rows) > 1 {
+		total.Keys[0] = "total"
+		rows = append(rows[:len(rows):len(rows)], total)
+	}
+	for _, row := range rows {
+		used, distance := "-", "-"
+		if rate, ok := row.UsedRate(); ok {
+			used = fmt.Sprintf("%.0f%%", rate*100)
+		}
+		if mean, ok := row.MeanDistance(); ok {
+			distance = fmt.Sprintf("%.1f", mean)
+		}
+		cells := append(append([]string(nil), row.Keys...),
+			strconv.Itoa(row.Generations), strconv.Itoa(row.Accepted), strconv.Itoa(row.Edited),
+			strconv.Itoa(row.Discarded), strconv.Itoa(row.Pending), used, distance,
+			row.MeanLatency().Round(100*time.Millisecond).String())
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return sb.String()
+}
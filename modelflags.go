@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/provider"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
+)
+
+// modelFlags are the flags shared by the subcommands that call a model
+type modelFlags struct {
+	set        *flag.FlagSet
+	configPath *string
+	profile    *string
+	model      *string
+
+	// Provider and Model are the resolved provider and model after load
+	Provider string
+	Model    string
+}
+
+// addModelFlags defines the provider, model and config flags on fs
+func addModelFlags(fs *flag.FlagSet) *modelFlags {
+	f := &modelFlags{set: fs}
+	f.model = fs.String("model", "", "Model ID (default depends on provider)")
+	fs.String("region", "", "AWS region (for bedrock provider, default \"us-east-1\")")
+	fs.String("provider", "", "AI provider: "+strings.Join(provider.Names, ", ")+" (auto-detected if not specified)")
+	f.configPath = fs.String("config", "", "Path to config file (merged over discovered config files)")
+	f.profile = fs.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	fs.Bool("verbose", false, "Enable verbose output")
+	fs.String("timeout", "", "Timeout for the AI request (e.g. 90s, 2m)")
+	fs.String("language", "", "Prompt language (e.g. japanese, english)")
+	fs.Bool("no-cache", false, "Call the model even if a cached response exists")
+	return f
+}
+
+// load initializes the config with the flags given explicitly and creates the AI client.
+// It exits with an error message when the config or the provider cannot be set up.
+func (f *modelFlags) load() (*config.Config, client.AIClient) {
+	cfg := f.loadConfig()
+	return cfg, f.newClient(cfg)
+}
+
+// loadConfig initializes the config with the flags given explicitly
+func (f *modelFlags) loadConfig() *config.Config {
+	overrides := map[string]interface{}{}
+	overrideOrigins := map[string]string{}
+	f.set.Visit(func(fl *flag.Flag) {
+		if path, ok := flagConfigPaths[fl.Name]; ok {
+			overrides[path] = fl.Value.(flag.Getter).Get()
+			overrideOrigins[path] = "--" + fl.Name
+		}
+		if path, ok := negatedFlagConfigPaths[fl.Name]; ok {
+			overrides[path] = !fl.Value.(flag.Getter).Get().(bool)
+			overrideOrigins[path] = "--" + fl.Name
+		}
+	})
+
+	if err := config.InitGlobalWithOptions(config.LoadOptions{
+		ConfigPath:      *f.configPath,
+		Overrides:       overrides,
+		OverrideOrigins: overrideOrigins,
+		Profile:         *f.profile,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		os.Exit(1)
+	}
+	cfg := config.Get()
+	if _, err := config.ParseTimeout(cfg.Defaults.Timeout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// newClient resolves the provider and model and creates the AI client
+func (f *modelFlags) newClient(cfg *config.Config) client.AIClient {
+	providerName := provider.ResolveName(cfg, "")
+	if !provider.IsValid(providerName) {
+		fmt.Fprintf(os.Stderr, "Error: Invalid provider '%s'. Must be one of: %s\n", providerName, strings.Join(provider.Names, ", "))
+		os.Exit(1)
+	}
+	explicitModel := ""
+	f.set.Visit(func(fl *flag.Flag) {
+		if fl.Name == "model" {
+			explicitModel = *f.model
+		}
+	})
+	f.Provider = providerName
+	f.Model = provider.ResolveModel(cfg, providerName, explicitModel)
+
+	aiClient, err := provider.New(f.Provider, f.Model, provider.ResolveRegion(cfg, ""))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing %s client: %v\n", providerName, err)
+		os.Exit(1)
+	}
+	return aiClient
+}
+
+// printUsage prints the tokens and cost of the model requests of this run to stderr in verbose mode
+func printUsage(cfg *config.Config) {
+	if run := usage.Run(); cfg.Defaults.Verbose && len(run) > 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s\n", usage.Total(run))
+	}
+}
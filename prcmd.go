@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
+)
+
+// defaultPRBase is the branch a pull request merges into when none is configured
+const defaultPRBase = "origin/main"
+
+// runPR generates a pull request title and body from the commits and the diff of the
+// current branch against its merge base
+func runPR(args []string) {
+	prFlags := flag.NewFlagSet("pr", flag.ExitOnError)
+	mf := addModelFlags(prFlags)
+	base := prFlags.String("base", "", "Branch the pull request merges into (default \""+defaultPRBase+"\")")
+	template := prFlags.String("template", "", "Pull request template (default: .github/pull_request_template.md, ...)")
+	format := prFlags.String("format", "text", "Output format: text or json")
+	output := prFlags.String("output", "", "Write the body to a file (for gh pr create --body-file) and print only the title")
+	prFlags.StringVar(output, "o", "", "Shorthand for --output")
+	prompt := prFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	prFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	prFlags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (available: text, json)\n", *format)
+		os.Exit(1)
+	}
+
+	cfg, aiClient := mf.load()
+	if *base == "" {
+		*base = cfg.PR.Base
+	}
+	if *base == "" {
+		*base = defaultPRBase
+	}
+	if *template == "" {
+		*template = cfg.PR.Template
+	}
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting git branch: %v\n", err)
+		os.Exit(1)
+	}
+	mergeBase, err := git.GetMergeBase(*base)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v (set the base branch with --base)\n", err)
+		os.Exit(1)
+	}
+
+	commits, err := git.GetRecentCommitMessages(git.LogOptions{Range: mergeBase + "..HEAD", Reverse: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	stat, err := git.GetRangeStat(mergeBase, "HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting diff stat: %v\n", err)
+		os.Exit(1)
+	}
+	diff, err := git.GetRangeDiff(mergeBase, "HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting diff: %v\n", err)
+		os.Exit(1)
+	}
+
+	root, _ := git.GetRepoRoot()
+	prTemplate, err := message.FindPRTemplate(root, *template)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.Defaults.Verbose {
+		fmt.Fprintln(os.Stderr, "=== Debug Information ===")
+		fmt.Fprintf(os.Stderr, "Provider: %s\n", mf.Provider)
+		fmt.Fprintf(os.Stderr, "Model ID: %s\n", mf.Model)
+		fmt.Fprintf(os.Stderr, "Base: %s (merge base %s)\n", *base, mergeBase)
+		fmt.Fprintf(os.Stderr, "Commits: %d\n", len(commits))
+		fmt.Fprintf(os.Stderr, "Diff size: %d bytes\n", len(diff))
+		if sections := message.TemplateSections(prTemplate); len(sections) > 0 {
+			fmt.Fprintf(os.Stderr, "Template sections: %s\n", strings.Join(sections, ", "))
+		}
+		fmt.Fprintln(os.Stderr, "========================")
+	}
+
+	pr, err := message.GeneratePullRequest(aiClient, message.PROptions{
+		Branch:      branch,
+		Base:        *base,
+		Commits:     commits,
+		Stat:        stat,
+		Diff:        diff,
+		Template:    prTemplate,
+		Language:    cfg.PromptLanguage("english"),
+		ExtraPrompt: *prompt,
+		TokenBudget: cfg.Context.TokenBudget,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	printUsage(cfg)
+
+	if *output != "" {
+		if err := os.WriteFile(*output, []byte(pr.Body+"\n"), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", *output, err)
+			os.Exit(1)
+		}
+	}
+
+	switch {
+	case *format == "json":
+		// The tokens and cost of the run go along with the pull request
+		output := struct {
+			*message.PullRequest
+			Usage *usage.Row `json:"usage,omitempty"`
+		}{PullRequest: pr}
+		if run := usage.Run(); len(run) > 0 {
+			total := usage.Total(run)
+			output.Usage = &total
+		}
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	case *output != "":
+		fmt.Println(pr.Title)
+	default:
+		fmt.Printf("%s\n\n%s\n", pr.Title, pr.Body)
+	}
+}
@@ -0,0 +1,200 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
+)
+
+// Built-in retry settings, used when retry.initial_delay or retry.max_delay are not configured
+const (
+	DefaultInitialDelay = time.Second
+	DefaultMaxDelay     = 30 * time.Second
+)
+
+// RetryPolicy controls how often and how long a provider is retried after a retryable error
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// NewRetryPolicy returns the policy configured by rc, filling in the built-in defaults
+func NewRetryPolicy(rc config.RetryConfig) RetryPolicy {
+	p := RetryPolicy{MaxAttempts: max(rc.MaxAttempts, 1), InitialDelay: DefaultInitialDelay, MaxDelay: DefaultMaxDelay}
+	if d, err := config.ParseTimeout(rc.InitialDelay); err == nil && d > 0 {
+		p.InitialDelay = d
+	}
+	if d, err := config.ParseTimeout(rc.MaxDelay); err == nil && d > 0 {
+		p.MaxDelay = d
+	}
+	return p
+}
+
+// Backoff returns the wait before retry number attempt (1 for the first retry). Without a
+// retry-after the wait doubles on every retry, capped at MaxDelay, with equal jitter so that
+// concurrent processes do not retry in lockstep. A retry-after is honored as is; ok is false
+// when it exceeds MaxDelay and the provider is better skipped than waited for.
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) (delay time.Duration, ok bool) {
+	if retryAfter > 0 {
+		return retryAfter, retryAfter <= p.MaxDelay
+	}
+	delay = p.InitialDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1))
+	}
+	return delay, true
+}
+
+// link is one provider of a chain; its client is created when it is first needed
+type link struct {
+	name   string
+	model  string
+	once   sync.Once
+	client client.AIClient
+	err    error
+	create func() (client.AIClient, error)
+}
+
+// get returns the client of the link, creating it on first use
+func (l *link) get() (client.AIClient, error) {
+	l.once.Do(func() {
+		l.client, l.err = l.create()
+	})
+	return l.client, l.err
+}
+
+// Chain sends requests to its providers in order. A provider is retried while it fails with
+// retryable errors; when it keeps failing, or fails with a fatal error, the next one is tried.
+type Chain struct {
+	links  []*link
+	policy RetryPolicy
+	// ctx cancels the requests and the waits between retries; nil means never
+	ctx context.Context
+	// sleep waits between retries and reports false when ctx is cancelled first; tests replace it
+	sleep func(ctx context.Context, d time.Duration) bool
+	// answered is the provider that answered the last request
+	answered *link
+}
+
+// Ensure Chain implements the AIClient and Cancelable interfaces
+var (
+	_ client.AIClient   = (*Chain)(nil)
+	_ client.Cancelable = (*Chain)(nil)
+)
+
+// sleep waits for d unless ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// WithContext returns a copy of the chain whose requests are cancelled with ctx
+func (c *Chain) WithContext(ctx context.Context) client.AIClient {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// Answered returns the provider and model that answered the last request, if any
+func (c *Chain) Answered() (name, model string, ok bool) {
+	if c.answered == nil {
+		return "", "", false
+	}
+	return c.answered.name, c.answered.model, true
+}
+
+// do sends a request with call, retrying and falling back as configured
+func (c *Chain) do(call func(client.AIClient) (string, error)) (string, error) {
+	ctx := client.OrBackground(c.ctx)
+	var failures []string
+	var lastErr error
+	for i, l := range c.links {
+		aiClient, err := l.get()
+		for attempt := 1; aiClient != nil; attempt++ {
+			var response string
+			requester := client.WithContext(ctx, aiClient)
+			response, err = call(requester)
+			record(l, requester)
+			if err == nil {
+				c.answered = l
+				return response, nil
+			}
+			if ctx.Err() != nil {
+				return "", err
+			}
+			if !client.IsRetryable(err) || attempt >= c.policy.MaxAttempts {
+				break
+			}
+			delay, ok := c.policy.Backoff(attempt, client.RetryAfter(err))
+			if !ok {
+				err = fmt.Errorf("%w (asked to retry after %s)", err, delay.Round(time.Second))
+				break
+			}
+			fmt.Fprintf(os.Stderr, "Warning: %s failed (%s); retrying in %s (attempt %d of %d)\n", l.name, firstLine(err), delay.Round(100*time.Millisecond), attempt+1, c.policy.MaxAttempts)
+			if !c.sleep(ctx, delay) {
+				return "", err
+			}
+		}
+
+		lastErr = err
+		failures = append(failures, fmt.Sprintf("%s: %s", l.name, firstLine(err)))
+		if i+1 < len(c.links) {
+			fmt.Fprintf(os.Stderr, "Warning: %s failed (%s); falling back to %s\n", l.name, firstLine(err), c.links[i+1].name)
+		}
+	}
+
+	if len(c.links) == 1 {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("all providers failed: %s", strings.Join(failures, "; "))
+}
+
+// GenerateCommitMessage generates a commit message with the first provider that succeeds
+func (c *Chain) GenerateCommitMessage(diff string, branch string) (string, error) {
+	return c.do(func(aiClient client.AIClient) (string, error) {
+		return aiClient.GenerateCommitMessage(diff, branch)
+	})
+}
+
+// Complete sends a prompt to the first provider that succeeds
+func (c *Chain) Complete(prompt string) (string, error) {
+	return c.do(func(aiClient client.AIClient) (string, error) {
+		return aiClient.Complete(prompt)
+	})
+}
+
+// record adds the tokens of the last request of aiClient to the usage of the run, if its
+// provider reports them. Requests that failed after a response (e.g. an empty one) are paid for
+// too, so they are recorded as well.
+func record(l *link, aiClient client.AIClient) {
+	if reporter, ok := aiClient.(usage.Reporter); ok {
+		if t, ok := reporter.LastUsage(); ok {
+			usage.Record(l.name, l.model, t)
+		}
+	}
+}
+
+// firstLine returns the first line of an error message; CLI errors carry their whole stderr
+func firstLine(err error) string {
+	line, _, _ := strings.Cut(err.Error(), "\n")
+	return line
+}
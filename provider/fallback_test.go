@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+)
+
+// fakeClient answers with the queued errors, then with its response
+type fakeClient struct {
+	errs     []error
+	response string
+	calls    int
+}
+
+func (f *fakeClient) GenerateCommitMessage(diff string, branch string) (string, error) {
+	return f.Complete(diff)
+}
+
+func (f *fakeClient) Complete(prompt string) (string, error) {
+	f.calls++
+	if f.calls <= len(f.errs) {
+		return "", f.errs[f.calls-1]
+	}
+	return f.response, nil
+}
+
+// newTestChain returns a chain of the clients that records its waits instead of sleeping
+func newTestChain(policy RetryPolicy, clients map[string]*fakeClient, order ...string) (*Chain, *[]time.Duration) {
+	var waits []time.Duration
+	chain := &Chain{policy: policy, sleep: func(ctx context.Context, d time.Duration) bool {
+		waits = append(waits, d)
+		return true
+	}}
+	for _, name := range order {
+		fake := clients[name]
+		chain.links = append(chain.links, &link{name: name, model: name + "-model", create: func() (client.AIClient, error) {
+			if fake == nil {
+				return nil, errors.New("not installed")
+			}
+			return fake, nil
+		}})
+	}
+	return chain, &waits
+}
+
+func TestChainRetriesRetryableErrors(t *testing.T) {
+	claude := &fakeClient{errs: []error{
+		client.RetryableError("claude", errors.New("status 529"), 0),
+		client.RetryableError("claude", errors.New("status 429"), 2*time.Second),
+	}, response: "feat: retry"}
+	chain, waits := newTestChain(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second}, map[string]*fakeClient{"claude": claude}, "claude")
+
+	response, err := chain.Complete("diff")
+	if err != nil || response != "feat: retry" {
+		t.Fatalf("Complete = %q, %v", response, err)
+	}
+	if claude.calls != 3 || len(*waits) != 2 {
+		t.Fatalf("expected 3 calls and 2 waits, got %d and %v", claude.calls, *waits)
+	}
+	if w := (*waits)[0]; w < 500*time.Millisecond || w > time.Second {
+		t.Errorf("first wait = %v, want between 0.5s and 1s", w)
+	}
+	if (*waits)[1] != 2*time.Second {
+		t.Errorf("second wait = %v, want the retry-after of 2s", (*waits)[1])
+	}
+}
+
+func TestChainFallsBack(t *testing.T) {
+	claude := &fakeClient{errs: []error{client.FatalError("claude", errors.New("invalid x-api-key"))}}
+	bedrock := &fakeClient{errs: []error{
+		client.RetryableError("bedrock", errors.New("ThrottlingException"), time.Minute),
+	}}
+	gemini := &fakeClient{response: "fix: fallback"}
+	chain, waits := newTestChain(RetryPolicy{MaxAttempts: 3, InitialDelay: time.Second, MaxDelay: 30 * time.Second},
+		map[string]*fakeClient{"claude": claude, "bedrock": bedrock, "geminicli": gemini}, "claude", "codexcli", "bedrock", "geminicli")
+
+	response, err := chain.Complete("diff")
+	if err != nil || response != "fix: fallback" {
+		t.Fatalf("Complete = %q, %v", response, err)
+	}
+	if claude.calls != 1 {
+		t.Errorf("expected a fatal error not to be retried, got %d calls", claude.calls)
+	}
+	if bedrock.calls != 1 || len(*waits) != 0 {
+		t.Errorf("expected a retry-after above max_delay to skip the provider, got %d calls and waits %v", bedrock.calls, *waits)
+	}
+	if name, model, ok := chain.Answered(); !ok || name != "geminicli" || model != "geminicli-model" {
+		t.Errorf("Answered = %q, %q, %v", name, model, ok)
+	}
+}
+
+func TestChainReportsAllFailures(t *testing.T) {
+	claude := &fakeClient{errs: []error{client.FatalError("claude", errors.New("status 400"))}}
+	bedrock := &fakeClient{errs: []error{
+		client.RetryableError("bedrock", errors.New("ThrottlingException"), 0),
+		client.RetryableError("bedrock", errors.New("ThrottlingException"), 0),
+	}}
+	chain, _ := newTestChain(RetryPolicy{MaxAttempts: 2, InitialDelay: time.Second, MaxDelay: 30 * time.Second},
+		map[string]*fakeClient{"claude": claude, "bedrock": bedrock}, "claude", "bedrock")
+
+	_, err := chain.Complete("diff")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"claude: status 400", "bedrock: ThrottlingException"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %q", want, err)
+		}
+	}
+	if bedrock.calls != 2 {
+		t.Errorf("expected max_attempts requests to bedrock, got %d", bedrock.calls)
+	}
+	if _, _, ok := chain.Answered(); ok {
+		t.Error("expected no provider to have answered")
+	}
+}
+
+func TestChainOfOneReturnsItsError(t *testing.T) {
+	fatal := client.FatalError("claude", errors.New("API request failed with status 401"))
+	chain, _ := newTestChain(RetryPolicy{MaxAttempts: 3}, map[string]*fakeClient{"claude": {errs: []error{fatal}}}, "claude")
+
+	if _, err := chain.Complete("diff"); err != fatal {
+		t.Errorf("expected the provider's error unchanged, got %v", err)
+	}
+}
+
+func TestBackoffDoublesUpToMaxDelay(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, ceiling := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 9: 5 * time.Second} {
+		delay, ok := policy.Backoff(attempt, 0)
+		if !ok || delay < ceiling/2 || delay > ceiling {
+			t.Errorf("Backoff(%d) = %v, %v; want between %v and %v", attempt, delay, ok, ceiling/2, ceiling)
+		}
+	}
+	if _, ok := policy.Backoff(1, 6*time.Second); ok {
+		t.Error("expected a retry-after above max_delay to be refused")
+	}
+}
@@ -0,0 +1,244 @@
+package provider
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/bedrock"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/cache"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/claude"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/claudecode"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/codexcli"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/copilotcli"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/copilotsdk"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/geminicli"
+)
+
+// DefaultRegion is the AWS region used when none is configured
+const DefaultRegion = "us-east-1"
+
+// Names lists all supported providers
+var Names = []string{"bedrock", "claude", "geminicli", "copilotcli", "copilotsdk", "claudecode", "codexcli"}
+
+// IsValid reports whether name is a supported provider
+func IsValid(name string) bool {
+	for _, n := range Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect returns the best available provider based on environment variables and installed tools
+func Detect() string {
+	if os.Getenv("ANTHROPIC_API_KEY") != "" {
+		return "claude"
+	} else if _, err := exec.LookPath("claude"); err == nil {
+		return "claudecode"
+	} else if _, err := exec.LookPath("copilot"); err == nil {
+		return "copilotcli"
+	} else if _, err := exec.LookPath("gemini"); err == nil {
+		return "geminicli"
+	} else if _, err := exec.LookPath("codex"); err == nil {
+		return "codexcli"
+	}
+	return "bedrock"
+}
+
+// DefaultModel returns the built-in default model ID for a provider
+func DefaultModel(name string) string {
+	switch name {
+	case "bedrock":
+		return "anthropic.claude-sonnet-4-5-20250929-v1:0"
+	case "claude":
+		return "claude-sonnet-4-6"
+	case "geminicli":
+		return "gemini-2.5-pro"
+	case "copilotcli":
+		return "claude-sonnet-4.5"
+	case "copilotsdk":
+		return "gpt-4o"
+	case "claudecode":
+		return "claude-sonnet-4.5"
+	case "codexcli":
+		return "o4-mini"
+	default:
+		return ""
+	}
+}
+
+// DefaultLanguage returns the prompt language a provider uses when defaults.language is not set;
+// the providers of defaults.fallback use that of the provider they stand in for
+func DefaultLanguage(name string) string {
+	switch name {
+	case "bedrock", "claude", "geminicli":
+		return "japanese"
+	default:
+		return "english"
+	}
+}
+
+// ResolveName returns the provider to use: the given name, the configured default, or an auto-detected one
+func ResolveName(cfg *config.Config, name string) string {
+	if name == "" {
+		name = cfg.Defaults.Provider
+	}
+	if name == "" {
+		name = Detect()
+	}
+	return strings.ToLower(name)
+}
+
+// ResolveModel returns the model to use for a provider.
+// An explicit model wins, followed by the configured one (the provider block's model or
+// defaults.model, whichever a later config layer set; see config.Config.Model) and the
+// built-in default.
+func ResolveModel(cfg *config.Config, name, model string) string {
+	if model != "" {
+		return model
+	}
+	if m := cfg.Model(name); m != "" {
+		return m
+	}
+	return DefaultModel(name)
+}
+
+// ResolveRegion returns the AWS region to use for the bedrock provider
+func ResolveRegion(cfg *config.Config, region string) string {
+	if region != "" {
+		return region
+	}
+	if cfg.Defaults.Region != "" {
+		return cfg.Defaults.Region
+	}
+	return DefaultRegion
+}
+
+// New creates an AI client for the named provider. Requests are retried and fall back to the
+// providers of defaults.fallback as configured, and identical requests are answered from the
+// response cache.
+func New(name, model, region string) (client.AIClient, error) {
+	cfg := config.Get()
+	chain := &Chain{links: links(cfg, name, model, region), policy: NewRetryPolicy(cfg.Retry), sleep: sleep}
+	if _, err := chain.links[0].get(); err != nil && len(chain.links) == 1 {
+		return nil, err
+	}
+	return withCache(cfg, chain, name, model, "")
+}
+
+// NewRace creates a client that races the named provider against the providers of
+// defaults.fallback (see Race); identical requests are answered from the response cache
+func NewRace(name, model, region string, bestOf bool, evaluate Evaluator) (client.AIClient, error) {
+	cfg := config.Get()
+	race := &Race{evaluate: evaluate, bestOf: bestOf}
+	for _, l := range links(cfg, name, model, region) {
+		race.entrants = append(race.entrants, &Chain{links: []*link{l}, policy: NewRetryPolicy(cfg.Retry), sleep: sleep})
+	}
+	if len(race.entrants) < 2 {
+		return nil, fmt.Errorf("racing needs at least two providers; list the others in defaults.fallback")
+	}
+	mode := "race"
+	if bestOf {
+		mode = "best-of"
+	}
+	return withCache(cfg, race, name, model, mode)
+}
+
+// links returns the named provider followed by the providers of defaults.fallback; their
+// clients are created when they are first needed. All of them write in the prompt language of
+// the named provider, so that the message does not change language when a fallback answers.
+func links(cfg *config.Config, name, model, region string) []*link {
+	language := DefaultLanguage(name)
+	newLink := func(name, model string) *link {
+		return &link{name: name, model: model, create: func() (client.AIClient, error) {
+			aiClient, err := newClient(name, model, region)
+			if err != nil {
+				return nil, err
+			}
+			return client.WithLanguage(language, aiClient), nil
+		}}
+	}
+
+	result := []*link{newLink(name, model)}
+	seen := map[string]bool{name: true}
+	for _, fallback := range cfg.Defaults.Fallback {
+		fallback = strings.ToLower(fallback)
+		if seen[fallback] {
+			continue
+		}
+		seen[fallback] = true
+		// defaults.model names a model of the primary provider, so it is not used here
+		result = append(result, newLink(fallback, cmp.Or(cfg.Provider(fallback).Model, DefaultModel(fallback))))
+	}
+	return result
+}
+
+// withCache wraps aiClient with the response cache if it is enabled. Requests are keyed by the
+// provider and model asked for and by mode; entries record the provider that answered.
+func withCache(cfg *config.Config, aiClient client.AIClient, name, model, mode string) (client.AIClient, error) {
+	if !cfg.Cache.Enabled {
+		return aiClient, nil
+	}
+	store, err := cache.Open(cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewClient(aiClient, store, name, model, mode), nil
+}
+
+// Unwrap returns the client created by New or NewRace without the response cache
+func Unwrap(aiClient client.AIClient) client.AIClient {
+	if cached, ok := aiClient.(*cache.Client); ok {
+		return cached.Unwrap()
+	}
+	return aiClient
+}
+
+// Answered returns the provider and model that answered the last request of a client created
+// by New or NewRace, or that answered it originally when the response came from the cache; ok
+// is false when no request was answered
+func Answered(aiClient client.AIClient) (name, model string, ok bool) {
+	if answerer, ok := aiClient.(interface {
+		Answered() (string, string, bool)
+	}); ok {
+		return answerer.Answered()
+	}
+	return "", "", false
+}
+
+// newClient creates the client of a single provider
+func newClient(name, model, region string) (client.AIClient, error) {
+	var (
+		aiClient client.AIClient
+		err      error
+	)
+
+	switch name {
+	case "bedrock":
+		aiClient, err = bedrock.NewClient(region, model)
+	case "claude":
+		aiClient, err = claude.NewClient(model)
+	case "geminicli":
+		aiClient, err = geminicli.NewClient(model)
+	case "copilotcli":
+		aiClient, err = copilotcli.NewClient(model)
+	case "copilotsdk":
+		aiClient, err = copilotsdk.NewClient(model)
+	case "claudecode":
+		aiClient, err = claudecode.NewClient(model)
+	case "codexcli":
+		aiClient, err = codexcli.NewClient(model)
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return aiClient, nil
+}
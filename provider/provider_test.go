@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestResolveModel(t *testing.T) {
+	withBlock := &config.Config{
+		Defaults:  config.Defaults{Model: "defaults-model"},
+		Providers: map[string]config.ProviderConfig{"claude": {Model: "block-model"}},
+	}
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		provider string
+		model    string
+		want     string
+	}{
+		{"explicit flag", withBlock, "claude", "flag-model", "flag-model"},
+		{"provider block", withBlock, "claude", "", "block-model"},
+		{"defaults", withBlock, "bedrock", "", "defaults-model"},
+		{"built-in default", &config.Config{}, "claude", "", DefaultModel("claude")},
+	}
+	for _, tt := range tests {
+		if got := ResolveModel(tt.cfg, tt.provider, tt.model); got != tt.want {
+			t.Errorf("%s: ResolveModel = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
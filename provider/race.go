@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+)
+
+// Evaluator scores a commit message and reports whether it is valid (see message.Evaluate)
+type Evaluator func(msg string) (score int, valid bool)
+
+// Candidate is the answer of one provider of a race
+type Candidate struct {
+	Provider string
+	Model    string
+	Response string
+	Score    int
+	Valid    bool
+	Err      error
+}
+
+// Race sends every request to all of its providers at once. In the default mode the first
+// valid commit message wins and the other requests are cancelled; in best-of mode all
+// providers are waited for and the highest-scored message wins. Prompts other than commit messages
+// cannot be scored, so Complete always takes the first answer.
+type Race struct {
+	entrants []*Chain
+	evaluate Evaluator
+	bestOf   bool
+	// ctx cancels the requests; nil means never
+	ctx context.Context
+	// candidates are the answers to the last request, in the order they arrived
+	candidates []Candidate
+	answered   *Candidate
+}
+
+// Ensure Race implements the AIClient and Cancelable interfaces
+var (
+	_ client.AIClient   = (*Race)(nil)
+	_ client.Cancelable = (*Race)(nil)
+)
+
+// WithContext returns a copy of the race whose requests are cancelled with ctx
+func (r *Race) WithContext(ctx context.Context) client.AIClient {
+	copied := *r
+	copied.ctx = ctx
+	return &copied
+}
+
+// Candidates returns the answers to the last request in the order they arrived; providers
+// cancelled after another one won are missing
+func (r *Race) Candidates() []Candidate {
+	return r.candidates
+}
+
+// Answered returns the provider and model whose answer was taken, if any
+func (r *Race) Answered() (name, model string, ok bool) {
+	if r.answered == nil {
+		return "", "", false
+	}
+	return r.answered.Provider, r.answered.Model, true
+}
+
+// run sends a request with call to all providers and picks the answer
+func (r *Race) run(call func(client.AIClient) (string, error), score bool) (string, error) {
+	ctx, cancel := context.WithCancel(client.OrBackground(r.ctx))
+	defer cancel()
+
+	type finished struct {
+		index     int
+		candidate Candidate
+	}
+	results := make(chan finished, len(r.entrants))
+	for i, entrant := range r.entrants {
+		go func() {
+			response, err := call(entrant.WithContext(ctx))
+			l := entrant.links[0]
+			results <- finished{i, Candidate{Provider: l.name, Model: l.model, Response: response, Err: err}}
+		}()
+	}
+
+	r.candidates, r.answered = nil, nil
+	best, bestIndex := -1, 0
+	var failures []string
+	for range r.entrants {
+		result := <-results
+		c := result.candidate
+		if c.Err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %s", c.Provider, firstLine(c.Err)))
+			r.candidates = append(r.candidates, c)
+			continue
+		}
+		c.Valid = true
+		if score {
+			c.Score, c.Valid = r.evaluate(c.Response)
+		}
+		r.candidates = append(r.candidates, c)
+
+		// Without scoring every answer is as good as the first
+		if c.Valid && (!r.bestOf || !score) {
+			r.answered = &r.candidates[len(r.candidates)-1]
+			return c.Response, nil
+		}
+		// Ties go to the provider listed first
+		if best < 0 || c.Score > r.candidates[best].Score || c.Score == r.candidates[best].Score && result.index < bestIndex {
+			best, bestIndex = len(r.candidates)-1, result.index
+		}
+	}
+
+	if best < 0 {
+		return "", fmt.Errorf("all providers failed: %s", strings.Join(failures, "; "))
+	}
+	r.answered = &r.candidates[best]
+	if !r.answered.Valid && !r.bestOf {
+		fmt.Fprintf(os.Stderr, "Warning: no provider gave a valid commit message; using the best-scored one from %s\n", r.answered.Provider)
+	}
+	return r.answered.Response, nil
+}
+
+// GenerateCommitMessage generates a commit message with all providers and picks one
+func (r *Race) GenerateCommitMessage(diff string, branch string) (string, error) {
+	return r.run(func(aiClient client.AIClient) (string, error) {
+		return aiClient.GenerateCommitMessage(diff, branch)
+	}, true)
+}
+
+// Complete sends a prompt to all providers and returns the first answer
+func (r *Race) Complete(prompt string) (string, error) {
+	return r.run(func(aiClient client.AIClient) (string, error) {
+		return aiClient.Complete(prompt)
+	}, false)
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/client"
+)
+
+// slowClient answers after a delay unless its context is cancelled first
+type slowClient struct {
+	delay    time.Duration
+	response string
+	ctx      context.Context
+	canceled chan struct{}
+}
+
+func (s *slowClient) WithContext(ctx context.Context) client.AIClient {
+	copied := *s
+	copied.ctx = ctx
+	return &copied
+}
+
+func (s *slowClient) GenerateCommitMessage(diff string, branch string) (string, error) {
+	return s.Complete(diff)
+}
+
+func (s *slowClient) Complete(prompt string) (string, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.response, nil
+	case <-client.OrBackground(s.ctx).Done():
+		close(s.canceled)
+		return "", client.FatalError("slow", s.ctx.Err())
+	}
+}
+
+// newTestRace returns a race between the clients in order
+func newTestRace(bestOf bool, clients ...client.AIClient) *Race {
+	race := &Race{bestOf: bestOf, evaluate: func(msg string) (int, bool) {
+		if !strings.HasPrefix(msg, "feat: ") {
+			return 10, false
+		}
+		return 100 - len(msg), true
+	}}
+	for i, c := range clients {
+		name := string(rune('a' + i))
+		race.entrants = append(race.entrants, &Chain{
+			links:  []*link{{name: name, model: name + "-model", create: func() (client.AIClient, error) { return c, nil }}},
+			policy: RetryPolicy{MaxAttempts: 1},
+			sleep:  sleep,
+		})
+	}
+	return race
+}
+
+func TestRaceTakesFirstValidAnswerAndCancelsTheRest(t *testing.T) {
+	slow := &slowClient{delay: time.Minute, response: "feat: slow", canceled: make(chan struct{})}
+	race := newTestRace(false,
+		&fakeClient{response: "Added search"},
+		slow,
+		&slowClient{delay: 10 * time.Millisecond, response: "feat: add search"},
+	)
+
+	response, err := race.GenerateCommitMessage("diff", "main")
+	if err != nil || response != "feat: add search" {
+		t.Fatalf("GenerateCommitMessage = %q, %v", response, err)
+	}
+	if name, _, _ := race.Answered(); name != "c" {
+		t.Errorf("Answered = %q, want c", name)
+	}
+	select {
+	case <-slow.canceled:
+	case <-time.After(time.Second):
+		t.Error("expected the slow request to be cancelled")
+	}
+}
+
+func TestRaceFallsBackToBestInvalidAnswer(t *testing.T) {
+	race := newTestRace(false,
+		&fakeClient{errs: []error{errors.New("boom")}},
+		&fakeClient{response: "Added search"},
+	)
+
+	response, err := race.GenerateCommitMessage("diff", "main")
+	if err != nil || response != "Added search" {
+		t.Fatalf("GenerateCommitMessage = %q, %v", response, err)
+	}
+	if len(race.Candidates()) != 2 {
+		t.Errorf("expected 2 candidates, got %v", race.Candidates())
+	}
+}
+
+func TestBestOfPicksHighestScore(t *testing.T) {
+	race := newTestRace(true,
+		&fakeClient{response: "feat: add a search box to the header"},
+		&slowClient{delay: 10 * time.Millisecond, response: "feat: add search"},
+		&fakeClient{response: "Added search"},
+	)
+
+	response, err := race.GenerateCommitMessage("diff", "main")
+	if err != nil || response != "feat: add search" {
+		t.Fatalf("GenerateCommitMessage = %q, %v", response, err)
+	}
+	if len(race.Candidates()) != 3 {
+		t.Errorf("expected every candidate to finish, got %v", race.Candidates())
+	}
+}
+
+func TestRaceReportsAllFailures(t *testing.T) {
+	race := newTestRace(false,
+		&fakeClient{errs: []error{errors.New("boom")}},
+		&fakeClient{errs: []error{errors.New("bang")}},
+	)
+
+	_, err := race.Complete("prompt")
+	if err == nil || !strings.Contains(err.Error(), "a: boom") || !strings.Contains(err.Error(), "b: bang") {
+		t.Errorf("expected both failures, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// runReview asks the model to review the staged changes and exits with status 1 when it
+// finds issues at or above the configured severity, so it can gate commits in a hook
+func runReview(args []string) {
+	reviewFlags := flag.NewFlagSet("review", flag.ExitOnError)
+	mf := addModelFlags(reviewFlags)
+	format := reviewFlags.String("format", "text", "Output format: text, json or sarif")
+	failOn := reviewFlags.String("fail-on", "", "Lowest severity that fails the review: "+strings.Join(config.Severities, ", ")+" or none (default \"error\")")
+	output := reviewFlags.String("output", "", "Write the findings to a file instead of stdout")
+	reviewFlags.StringVar(output, "o", "", "Shorthand for --output")
+	prompt := reviewFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	reviewFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	reviewFlags.Parse(args)
+
+	if *format != "text" && *format != "json" && *format != "sarif" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (available: text, json, sarif)\n", *format)
+		os.Exit(1)
+	}
+
+	cfg, aiClient := mf.load()
+	if *failOn == "" {
+		*failOn = cfg.Review.FailOn
+	}
+	if *failOn == "" {
+		*failOn = config.SeverityError
+	}
+	if *failOn != config.ReviewFailOnNone && message.SeverityRank(*failOn) < 0 {
+		fmt.Fprintf(os.Stderr, "Error: unknown severity '%s' (available: %s, none)\n", *failOn, strings.Join(config.Severities, ", "))
+		os.Exit(1)
+	}
+
+	diff, err := git.GetStagedDiff()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting staged diff: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.TrimSpace(diff) == "" {
+		fmt.Fprintln(os.Stderr, "No staged changes to review")
+		os.Exit(0)
+	}
+
+	if cfg.Defaults.Verbose {
+		fmt.Fprintln(os.Stderr, "=== Debug Information ===")
+		fmt.Fprintf(os.Stderr, "Provider: %s\n", mf.Provider)
+		fmt.Fprintf(os.Stderr, "Model ID: %s\n", mf.Model)
+		fmt.Fprintf(os.Stderr, "Diff size: %d bytes\n", len(diff))
+		fmt.Fprintf(os.Stderr, "Review requests: %d\n", len(message.SplitDiff(diff, cfg.Review.ChunkTokens)))
+		fmt.Fprintf(os.Stderr, "Fail on: %s\n", *failOn)
+		fmt.Fprintln(os.Stderr, "========================")
+	}
+
+	findings, err := message.Review(aiClient, message.ReviewOptions{
+		Diff:         diff,
+		Language:     cfg.PromptLanguage("english"),
+		Instructions: cfg.Review.Instructions,
+		ExtraPrompt:  *prompt,
+		ChunkTokens:  cfg.Review.ChunkTokens,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	printUsage(cfg)
+
+	var out []byte
+	switch *format {
+	case "json":
+		if findings == nil {
+			findings = []message.Finding{}
+		}
+		out, err = json.MarshalIndent(findings, "", "  ")
+		out = append(out, '\n')
+	case "sarif":
+		out, err = message.SARIF(findings, getVersion())
+		out = append(out, '\n')
+	default:
+		out = []byte(message.FormatFindings(findings))
+		if len(findings) == 0 {
+			out = []byte("✓ No issues found\n")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *output != "" {
+		err = os.WriteFile(*output, out, 0644)
+	} else {
+		_, err = os.Stdout.Write(out)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing findings: %v\n", err)
+		os.Exit(1)
+	}
+
+	if failing := message.AtOrAbove(findings, *failOn); len(failing) > 0 {
+		fmt.Fprintf(os.Stderr, "Review failed: %d of %d findings at or above %s\n", len(failing), len(findings), *failOn)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// backupRefPrefix is where reword saves the branch before rewriting it
+const backupRefPrefix = "refs/gcm-backup/"
+
+// runReword regenerates the messages of the commits in a range from their own diffs and,
+// after confirmation, rewrites the current branch with them
+func runReword(args []string) {
+	rewordFlags := flag.NewFlagSet("reword", flag.ExitOnError)
+	mf := addModelFlags(rewordFlags)
+	force := rewordFlags.Bool("force", false, "Rewrite protected branches and commits already pushed")
+	yes := rewordFlags.Bool("yes", false, "Rewrite without asking for confirmation")
+	rewordFlags.BoolVar(yes, "y", false, "Shorthand for --yes")
+	dryRun := rewordFlags.Bool("dry-run", false, "Show the new messages without rewriting history")
+	prompt := rewordFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	rewordFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	rewordFlags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: generate-auto-commit-message reword [options] <range>")
+		fmt.Fprintln(os.Stderr, "  <range> is <from>..<to>, or <from> for <from>..HEAD (e.g. HEAD~5, origin/main..)")
+		rewordFlags.PrintDefaults()
+	}
+	rewordFlags.Parse(args)
+
+	if rewordFlags.NArg() != 1 {
+		rewordFlags.Usage()
+		os.Exit(2)
+	}
+	from, to, found := strings.Cut(rewordFlags.Arg(0), "..")
+	if !found || to == "" {
+		to = "HEAD"
+	}
+
+	cfg := mf.loadConfig()
+
+	branch, err := git.GetCurrentBranch()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: reword needs a branch to rewrite (HEAD is detached): %v\n", err)
+		os.Exit(1)
+	}
+
+	base, err := git.ResolveCommit(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown revision %q\n", from)
+		os.Exit(1)
+	}
+	commits, err := git.RevList(base + ".." + to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(commits) == 0 {
+		fmt.Fprintf(os.Stderr, "No commits in %s..%s\n", from, to)
+		os.Exit(0)
+	}
+
+	// Every commit after the start of the range is recreated, so the whole stretch up to HEAD
+	// must be linear and must contain the selected commits
+	onBranch, err := git.RevList(base + "..HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	branchCommits := map[string]bool{}
+	for _, hash := range onBranch {
+		branchCommits[hash] = true
+	}
+	for _, hash := range commits {
+		if !branchCommits[hash] {
+			fmt.Fprintf(os.Stderr, "Error: commit %s is not on the current branch after %s\n", hash[:7], from)
+			os.Exit(1)
+		}
+	}
+	merges, err := git.RevList("--min-parents=2", base+"..HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(merges) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: cannot reword across merge commit %s\n", merges[0][:7])
+		os.Exit(1)
+	}
+
+	if !*force {
+		checkRewritable(branch, cfg.Reword.Protected, onBranch[0])
+	}
+
+	aiClient := mf.newClient(cfg)
+	if cfg.Defaults.Verbose {
+		fmt.Fprintf(os.Stderr, "Rewording %d commits of %s with %s (%s)\n", len(commits), branch, mf.Provider, mf.Model)
+	}
+
+	// The diff of each commit stands on its own: context and breaking change detection
+	// describe the index, so they are left out
+	var rewords []message.Reword
+	messages := map[string]string{}
+	for i, hash := range commits {
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", i+1, len(commits), hash[:7])
+		original, diff, files, filesWithStatus, err := readCommit(hash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading commit %s: %v\n", hash[:7], err)
+			os.Exit(1)
+		}
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintf(os.Stderr, "Skipping %s: the commit is empty\n", hash[:7])
+			continue
+		}
+
+		result, err := message.GenerateWithOptions(aiClient, diff, branch, message.Options{
+			ExtraPrompt:     *prompt,
+			Scopes:          cfg.Scopes,
+			Emoji:           cfg.Defaults.Emoji,
+			Files:           files,
+			FilesWithStatus: filesWithStatus,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating message for %s: %v\n", hash[:7], err)
+			os.Exit(1)
+		}
+		msg, _, err := message.ReferenceTicket(result.Message, branch, cfg.Ticket)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", hash[:7], err)
+		}
+		msg = message.KeepTrailers(msg, original)
+
+		rewords = append(rewords, message.Reword{Hash: hash, Before: original, After: msg})
+		messages[hash] = msg
+	}
+	if len(rewords) == 0 {
+		fmt.Fprintln(os.Stderr, "Nothing to reword")
+		os.Exit(0)
+	}
+	printUsage(cfg)
+
+	fmt.Println()
+	fmt.Print(message.RewordTable(rewords))
+	if cfg.Defaults.Verbose || *dryRun {
+		for _, r := range rewords {
+			fmt.Printf("\n--- %s\n%s\n", r.Hash[:7], r.After)
+		}
+	}
+	if *dryRun {
+		return
+	}
+
+	if !*yes {
+		ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Rewrite %d commits on %s?", len(rewords), branch))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Println("Aborted; history was not changed")
+			return
+		}
+	}
+
+	backup, _ := backupBranch(branch)
+	newHead, err := git.RewriteMessages(base, messages)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rewriting history: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Reworded %d commits on %s (HEAD is now %s)\n", len(rewords), branch, newHead[:7])
+	fmt.Printf("  Backup: %s\n", backup)
+	fmt.Printf("  Undo with: git reset --keep %s\n", backup)
+}
+
+// checkRewritable exits with an error when the history of branch from the commit oldest on
+// must not be rewritten: the branch is protected or a remote branch already contains the commit
+func checkRewritable(branch string, protected []string, oldest string) {
+	if message.IsProtectedBranch(branch, protected) {
+		fmt.Fprintf(os.Stderr, "Error: branch %s is protected (reword.protected); use --force to rewrite it anyway\n", branch)
+		os.Exit(1)
+	}
+	remotes, err := git.RemoteBranchesContaining(oldest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error checking remote branches: %v\n", err)
+		os.Exit(1)
+	}
+	if len(remotes) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: the commits have already been pushed to %s; use --force to rewrite them anyway\n", strings.Join(remotes, ", "))
+		os.Exit(1)
+	}
+}
+
+// backupBranch saves the current HEAD of branch under backupRefPrefix and returns the backup
+// ref and the saved commit
+func backupBranch(branch string) (string, string) {
+	head, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	backup := backupRefPrefix + branch + "/" + time.Now().Format("20060102-150405")
+	if err := git.UpdateRef(backup, head, "", "backup of "+branch); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating backup ref: %v\n", err)
+		os.Exit(1)
+	}
+	return backup, head
+}
+
+// readCommit returns the message, the diff and the changed files of a commit
+func readCommit(hash string) (original, diff string, files []string, filesWithStatus string, err error) {
+	if original, err = git.GetCommitMessage(hash); err != nil {
+		return
+	}
+	if diff, err = git.GetCommitDiff(hash); err != nil {
+		return
+	}
+	if files, err = git.GetCommitFiles(hash); err != nil {
+		return
+	}
+	filesWithStatus, err = git.GetCommitFilesWithStatus(hash)
+	return
+}
+
+// confirm asks a yes/no question; anything but y or yes is a no
+func confirm(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/message"
+)
+
+// runSquash writes one message for the commits of a range from their combined diff and their
+// individual messages, and optionally squashes them into a single commit
+func runSquash(args []string) {
+	squashFlags := flag.NewFlagSet("squash", flag.ExitOnError)
+	mf := addModelFlags(squashFlags)
+	base := squashFlags.String("base", "", "Branch whose merge base starts the range when none is given (default \""+defaultPRBase+"\")")
+	apply := squashFlags.Bool("apply", false, "Squash the commits into one with git reset --soft and git commit")
+	yes := squashFlags.Bool("yes", false, "Squash without asking for confirmation")
+	squashFlags.BoolVar(yes, "y", false, "Shorthand for --yes")
+	force := squashFlags.Bool("force", false, "Squash on protected branches, across merge commits and commits already pushed")
+	prompt := squashFlags.String("prompt", "", "Additional instructions to inject into the prompt")
+	squashFlags.StringVar(prompt, "p", "", "Shorthand for --prompt")
+	squashFlags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: generate-auto-commit-message squash [options] [range]")
+		fmt.Fprintln(os.Stderr, "  [range] is <from>..<to>, or <from> for <from>..HEAD (default: the merge base with --base)")
+		squashFlags.PrintDefaults()
+	}
+	squashFlags.Parse(args)
+
+	if squashFlags.NArg() > 1 {
+		squashFlags.Usage()
+		os.Exit(2)
+	}
+
+	cfg := mf.loadConfig()
+	if *base == "" {
+		*base = cfg.PR.Base
+	}
+	if *base == "" {
+		*base = defaultPRBase
+	}
+
+	var from, to string
+	if squashFlags.NArg() == 1 {
+		var found bool
+		from, to, found = strings.Cut(squashFlags.Arg(0), "..")
+		if !found || to == "" {
+			to = "HEAD"
+		}
+	} else {
+		var err error
+		if from, err = git.GetMergeBase(*base); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v (set the base branch with --base or give a range)\n", err)
+			os.Exit(1)
+		}
+		to = "HEAD"
+	}
+	start, err := git.ResolveCommit(from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: unknown revision %q\n", from)
+		os.Exit(1)
+	}
+	revRange := start + ".." + to
+
+	// A detached HEAD can still get a message, but only a branch can be squashed
+	branch, err := git.GetCurrentBranch()
+	if err != nil && *apply {
+		fmt.Fprintf(os.Stderr, "Error: squash --apply needs a branch (HEAD is detached): %v\n", err)
+		os.Exit(1)
+	}
+
+	hashes, err := git.RevList(revRange)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(hashes) == 0 {
+		fmt.Fprintf(os.Stderr, "No commits in %s..%s\n", from, to)
+		os.Exit(0)
+	}
+	if *apply {
+		checkSquashable(start, to, hashes[0], branch, cfg.Reword.Protected, *force)
+	}
+
+	commits, err := git.GetRecentCommitMessages(git.LogOptions{Range: revRange, Reverse: true})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	diff, err := git.GetRangeDiff(start, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting diff: %v\n", err)
+		os.Exit(1)
+	}
+	if strings.TrimSpace(diff) == "" {
+		fmt.Fprintf(os.Stderr, "The commits in %s..%s cancel each other out; there is nothing to squash\n", from, to)
+		os.Exit(1)
+	}
+	files, err := git.GetRangeFiles(start, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting changed files: %v\n", err)
+		os.Exit(1)
+	}
+	filesWithStatus, err := git.GetRangeFilesWithStatus(start, to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting changed files: %v\n", err)
+		os.Exit(1)
+	}
+
+	aiClient := mf.newClient(cfg)
+	if cfg.Defaults.Verbose {
+		fmt.Fprintln(os.Stderr, "=== Debug Information ===")
+		fmt.Fprintf(os.Stderr, "Provider: %s\n", mf.Provider)
+		fmt.Fprintf(os.Stderr, "Model ID: %s\n", mf.Model)
+		fmt.Fprintf(os.Stderr, "Range: %s..%s (%d commits)\n", from, to, len(hashes))
+		fmt.Fprintf(os.Stderr, "Diff size: %d bytes\n", len(diff))
+		fmt.Fprintln(os.Stderr, "========================")
+	}
+
+	// Breaking change detection and context describe the index, so they are left out
+	result, err := message.GenerateWithOptions(aiClient, diff, branch, message.Options{
+		ExtraPrompt:     *prompt,
+		Scopes:          cfg.Scopes,
+		Emoji:           cfg.Defaults.Emoji,
+		Files:           files,
+		FilesWithStatus: filesWithStatus,
+		Commits:         commits,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating commit message: %v\n", err)
+		os.Exit(1)
+	}
+	printUsage(cfg)
+	commitMsg, _, err := message.ReferenceTicket(result.Message, branch, cfg.Ticket)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// Keep the footers of the squashed commits and credit their other authors
+	authors, err := git.GetRangeAuthors(revRange)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commit authors: %v\n", err)
+		os.Exit(1)
+	}
+	self, _ := git.GetUserIdentity()
+	commitMsg = message.SquashTrailers(commitMsg, commits, authors, self)
+	commitMsg, err = message.AddTrailers(commitMsg, cfg.Trailers, message.TrailerOptions{
+		Provider: mf.Provider,
+		Model:    mf.Model,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error adding trailers: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(commitMsg)
+	if !*apply {
+		return
+	}
+
+	if !*yes {
+		fmt.Println()
+		ok, err := confirm(os.Stdin, os.Stdout, fmt.Sprintf("Squash %d commits on %s into one?", len(hashes), branch))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			fmt.Println("Aborted; history was not changed")
+			return
+		}
+	}
+
+	backup, head := backupBranch(branch)
+	if err := git.ResetSoft(start); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := git.CommitIndex(commitMsg); err != nil {
+		// Put the branch back so a failing hook does not leave the commits undone
+		if resetErr := git.ResetSoft(head); resetErr != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring %s: %v (the commits are saved as %s)\n", branch, resetErr, backup)
+		}
+		fmt.Fprintf(os.Stderr, "Error committing: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ Squashed %d commits on %s\n", len(hashes), branch)
+	fmt.Printf("  Backup: %s\n", backup)
+	fmt.Printf("  Undo with: git reset --keep %s\n", backup)
+}
+
+// checkSquashable exits with an error when the commits after start cannot be squashed in place:
+// the range must end at HEAD, nothing may be staged and the history must be safe to rewrite.
+// Squashing a merge commit flattens the merged history, so it also needs force.
+func checkSquashable(start, to, oldest, branch string, protected []string, force bool) {
+	head, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if end, err := git.ResolveCommit(to); err != nil || end != head {
+		fmt.Fprintf(os.Stderr, "Error: squash --apply needs a range that ends at HEAD\n")
+		os.Exit(1)
+	}
+	staged, err := git.GetStagedFiles()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(staged) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: there are staged changes; commit or unstage them before squashing\n")
+		os.Exit(1)
+	}
+	if force {
+		return
+	}
+	merges, err := git.RevList("--min-parents=2", start+"..HEAD")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading commits: %v\n", err)
+		os.Exit(1)
+	}
+	if len(merges) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: cannot squash across merge commit %s; use --force to flatten it anyway\n", merges[0][:7])
+		os.Exit(1)
+	}
+	checkRewritable(branch, protected, oldest)
+}
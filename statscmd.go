@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/metrics"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
+)
+
+// runStats compares how often the generated messages of each provider and prompt are committed
+func runStats(args []string) {
+	if len(args) > 0 && args[0] == "post-commit" {
+		runStatsPostCommit(args[1:])
+		return
+	}
+
+	statsFlags := flag.NewFlagSet("stats", flag.ExitOnError)
+	configPath := statsFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := statsFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	by := statsFlags.String("by", "provider", "Comma-separated grouping: "+strings.Join(metrics.Keys, ", "))
+	since := statsFlags.String("since", "", "Only count messages generated since a number of days, a duration or a date (e.g. 7d, 12h, 2026-01-31)")
+	format := statsFlags.String("format", "text", "Output format: text or json")
+	statsFlags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (available: text, json)\n", *format)
+		os.Exit(1)
+	}
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: *configPath, Profile: *profile}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		os.Exit(1)
+	}
+
+	path, err := metrics.Path(config.Get().Metrics)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	events, err := metrics.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading metrics log: %v\n", err)
+		os.Exit(1)
+	}
+	now := time.Now()
+	var start time.Time
+	if *since != "" {
+		if start, err = usage.ParseSince(*since, now); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	var keys []string
+	for _, key := range strings.Split(*by, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	rows, err := metrics.Summarize(events, keys, start, now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if len(rows) == 0 {
+		if !config.Get().Metrics.Enabled {
+			fmt.Println("Metrics are disabled; set metrics.enabled: true to record generated messages")
+			return
+		}
+		fmt.Printf("No generated messages recorded in %s\n", path)
+		return
+	}
+	fmt.Print(metrics.FormatTable(rows, keys))
+}
+
+// runStatsPostCommit records what became of the latest generated message; it is run by the
+// post-commit hook and never fails the commit
+func runStatsPostCommit(args []string) {
+	postCommitFlags := flag.NewFlagSet("stats post-commit", flag.ExitOnError)
+	configPath := postCommitFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := postCommitFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	verbose := postCommitFlags.Bool("verbose", false, "Print the recorded result")
+	postCommitFlags.Parse(args)
+
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: *configPath, Profile: *profile}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: metrics not recorded: %v\n", err)
+		return
+	}
+	cfg := config.Get()
+	if !cfg.Metrics.Enabled {
+		return
+	}
+
+	if err := recordCommit(cfg.Metrics, *verbose); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: metrics not recorded: %v\n", err)
+	}
+}
+
+// recordCommit matches HEAD with the latest generated message of the repository
+func recordCommit(mc config.MetricsConfig, verbose bool) error {
+	path, err := metrics.Path(mc)
+	if err != nil {
+		return err
+	}
+	repo, err := git.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	commit, err := git.ResolveCommit("HEAD")
+	if err != nil {
+		return err
+	}
+	msg, err := git.GetCommitMessage(commit)
+	if err != nil {
+		return err
+	}
+	branch, _ := git.GetCurrentBranch()
+
+	outcome, err := metrics.RecordCommit(path, repo, branch, commit, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	if outcome != nil && verbose {
+		fmt.Printf("Generated message %s (edit distance %d)\n", outcome.Result, outcome.Distance)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package usage
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Keys lists the values usage can be grouped by
+var Keys = []string{"day", "repo", "provider", "model"}
+
+// Row sums the entries of one group
+type Row struct {
+	// Keys are the values of the grouping keys, in the order requested
+	Keys     []string `json:"keys,omitempty"`
+	Requests int      `json:"requests"`
+	Tokens
+	// Cost sums the priced requests; Unpriced counts the requests of models without a price
+	Cost     float64 `json:"cost_usd"`
+	Unpriced int     `json:"unpriced,omitempty"`
+}
+
+// add adds an entry to the row
+func (r *Row) add(e Entry) {
+	r.Requests++
+	r.Tokens.Add(e.Tokens)
+	if e.Cost != nil {
+		r.Cost += *e.Cost
+	} else {
+		r.Unpriced++
+	}
+}
+
+// FormatCost renders the cost of the row, noting requests without a price
+func (r Row) FormatCost() string {
+	switch {
+	case r.Unpriced == r.Requests:
+		return "-"
+	case r.Unpriced > 0:
+		return fmt.Sprintf("$%.4f (+%d unpriced)", r.Cost, r.Unpriced)
+	}
+	return fmt.Sprintf("$%.4f", r.Cost)
+}
+
+// String renders the tokens and cost of the row on one line; see CacheString for the prompt cache
+func (r Row) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d input + %d output tokens", r.Input, r.Output)
+	if r.Requests > 1 {
+		fmt.Fprintf(&sb, " in %d requests", r.Requests)
+	}
+	if cost := r.FormatCost(); cost != "-" {
+		sb.WriteString(", " + cost)
+	} else {
+		sb.WriteString(", no price configured")
+	}
+	return sb.String()
+}
+
+// CacheString renders the prompt tokens the row read from and wrote to the prompt cache
+func (r Row) CacheString() string {
+	if r.CacheRead == 0 && r.CacheCreation == 0 {
+		return "not used (the instructions are shorter than the model's minimum cacheable prompt)"
+	}
+	return fmt.Sprintf("%d tokens read, %d written", r.CacheRead, r.CacheCreation)
+}
+
+// Total sums all entries
+func Total(entries []Entry) Row {
+	var total Row
+	for _, e := range entries {
+		total.add(e)
+	}
+	return total
+}
+
+// key returns the value of a grouping key for an entry
+func key(e Entry, name string) string {
+	switch name {
+	case "day":
+		return e.Time.Local().Format(time.DateOnly)
+	case "repo":
+		if e.Repo == "" {
+			return "-"
+		}
+		return e.Repo
+	case "provider":
+		return e.Provider
+	case "model":
+		return e.Model
+	}
+	return ""
+}
+
+// Summarize groups the entries by the named keys (see Keys) and sums every group; rows are
+// sorted by their keys
+func Summarize(entries []Entry, by []string) ([]Row, error) {
+	for _, name := range by {
+		if !slices.Contains(Keys, name) {
+			return nil, fmt.Errorf("unknown grouping '%s' (available: %s)", name, strings.Join(Keys, ", "))
+		}
+	}
+
+	groups := map[string]*Row{}
+	for _, e := range entries {
+		keys := make([]string, len(by))
+		for i, name := range by {
+			keys[i] = key(e, name)
+		}
+		id := strings.Join(keys, "\x00")
+		if groups[id] == nil {
+			groups[id] = &Row{Keys: keys}
+		}
+		groups[id].add(e)
+	}
+
+	rows := make([]Row, 0, len(groups))
+	for _, row := range groups {
+		rows = append(rows, *row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return strings.Join(rows[i].Keys, "\x00") < strings.Join(rows[j].Keys, "\x00")
+	})
+	return rows, nil
+}
+
+// Since returns the entries recorded at or after t
+func Since(entries []Entry, t time.Time) []Entry {
+	var result []Entry
+	for _, e := range entries {
+		if !e.Time.Before(t) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// ParseSince parses the start of a period: a number of days such as "7d", a duration such as
+// "12h", or a date such as "2026-01-31"
+func ParseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid period %q (e.g. 7d, 12h or 2026-01-31)", value)
+}
+
+// FormatTable renders the rows under a header of the grouping keys, followed by their total
+func FormatTable(rows []Row, by []string) string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	header := append(append([]string(nil), by...), "Requests", "Input", "Output", "Cache write", "Cache read", "Cost")
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+
+	total := Row{Keys: make([]string, len(by))}
+	for _, row := range rows {
+		total.Requests += row.Requests
+		total.Tokens.Add(row.Tokens)
+		total.Cost += row.Cost
+		total.Unpriced += row.Unpriced
+	}
+	if len(by) > 0 && len(rows) > 1 {
+		total.Keys[0] = "total"
+		rows = append(rows[:len(rows):len(rows)], total)
+	}
+	for _, row := range rows {
+		cells := append(append([]string(nil), row.Keys...),
+			strconv.Itoa(row.Requests), strconv.Itoa(row.Input), strconv.Itoa(row.Output),
+			strconv.Itoa(row.CacheCreation), strconv.Itoa(row.CacheRead), row.FormatCost())
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+	return sb.String()
+}
@@ -0,0 +1,155 @@
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/git"
+)
+
+// Tokens counts the tokens of model requests
+type Tokens struct {
+	Input  int `json:"input_tokens"`
+	Output int `json:"output_tokens"`
+	// CacheCreation and CacheRead are prompt tokens written to and read from the prompt cache
+	CacheCreation int `json:"cache_creation_input_tokens,omitempty"`
+	CacheRead     int `json:"cache_read_input_tokens,omitempty"`
+}
+
+// Add adds the tokens of another request
+func (t *Tokens) Add(other Tokens) {
+	t.Input += other.Input
+	t.Output += other.Output
+	t.CacheCreation += other.CacheCreation
+	t.CacheRead += other.CacheRead
+}
+
+// Entry is one model request of the usage log
+type Entry struct {
+	Time     time.Time `json:"time"`
+	Repo     string    `json:"repo,omitempty"`
+	Provider string    `json:"provider"`
+	Model    string    `json:"model"`
+	Tokens
+	// Cost is in USD; nil when the model has no price in the pricing table
+	Cost *float64 `json:"cost_usd,omitempty"`
+}
+
+// Reporter is implemented by clients that report the tokens of their last request; the
+// provider chain records them, so that every request is recorded once
+type Reporter interface {
+	// LastUsage returns the tokens of the last request; ok is false when they are unknown
+	LastUsage() (t Tokens, ok bool)
+}
+
+var (
+	mu sync.Mutex
+	// run holds the entries recorded by this process
+	run []Entry
+	// repoRoot is the repository the entries of this process are recorded for
+	repoRoot = sync.OnceValue(func() string {
+		root, _ := git.GetRepoRoot()
+		return root
+	})
+)
+
+// Cost returns the cost of tokens in USD at price
+func Cost(price config.Price, t Tokens) float64 {
+	return (float64(t.Input)*price.Input +
+		float64(t.Output)*price.Output +
+		float64(t.CacheCreation)*price.CacheWrite +
+		float64(t.CacheRead)*price.CacheRead) / 1e6
+}
+
+// Record notes the tokens of a model request, prices them with the pricing table and appends
+// them to the usage log if it is enabled. A log that cannot be written only prints a warning.
+func Record(provider, model string, t Tokens) {
+	cfg := config.Get()
+	e := Entry{Time: time.Now().UTC(), Repo: repoRoot(), Provider: provider, Model: model, Tokens: t}
+	if price, ok := cfg.PriceOf(model); ok {
+		cost := Cost(price, t)
+		e.Cost = &cost
+	}
+
+	mu.Lock()
+	run = append(run, e)
+	mu.Unlock()
+
+	if !cfg.Usage.Log {
+		return
+	}
+	path, err := LogPath(cfg.Usage)
+	if err == nil {
+		err = Append(path, e)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write the usage log: %v\n", err)
+	}
+}
+
+// Run returns the entries recorded by this process
+func Run() []Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]Entry(nil), run...)
+}
+
+// LogPath returns the configured usage log; a leading "~/" is the home directory
+func LogPath(uc config.UsageConfig) (string, error) {
+	path, err := config.StatePath(uc.Path, "usage.jsonl")
+	if err != nil {
+		return "", fmt.Errorf("failed to find the usage log: %w", err)
+	}
+	return path, nil
+}
+
+// Append adds an entry to the log at path as one line of JSON
+func Append(path string, e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	// A single write of a whole line keeps lines of concurrent processes apart
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read returns the entries of the log at path; a missing log has no entries and lines that
+// cannot be parsed are skipped
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,118 @@
+package usage
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+)
+
+func TestCost(t *testing.T) {
+	price := config.Price{Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.3}
+	got := Cost(price, Tokens{Input: 1000, Output: 200, CacheCreation: 2000, CacheRead: 10000})
+	if want := 0.003 + 0.003 + 0.0075 + 0.003; got < want-1e-9 || got > want+1e-9 {
+		t.Errorf("Cost = %v, want %v", got, want)
+	}
+}
+
+func TestLogPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", dir)
+	t.Setenv("HOME", "/home/me")
+
+	tests := map[string]string{
+		"":                 filepath.Join(dir, "gcm", "usage.jsonl"),
+		"~/usage.jsonl":    "/home/me/usage.jsonl",
+		"/var/log/gcm.log": "/var/log/gcm.log",
+	}
+	for path, want := range tests {
+		if got, err := LogPath(config.UsageConfig{Path: path}); err != nil || got != want {
+			t.Errorf("LogPath(%q) = %q, %v; want %q", path, got, err, want)
+		}
+	}
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "usage.jsonl")
+	cost := 0.01
+	entries := []Entry{
+		{Time: time.Date(2026, 1, 2, 3, 0, 0, 0, time.UTC), Repo: "/src/a", Provider: "claude", Model: "claude-sonnet-4-6", Tokens: Tokens{Input: 100, Output: 20, CacheRead: 50}, Cost: &cost},
+		{Time: time.Date(2026, 1, 3, 3, 0, 0, 0, time.UTC), Provider: "bedrock", Model: "unknown", Tokens: Tokens{Input: 10, Output: 2}},
+	}
+	for _, e := range entries {
+		if err := Append(path, e); err != nil {
+			t.Fatalf("Append failed: %v", err)
+		}
+	}
+
+	got, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(got) != 2 || got[0].CacheRead != 50 || *got[0].Cost != cost || got[1].Cost != nil {
+		t.Errorf("Read = %+v", got)
+	}
+	if missing, err := Read(filepath.Join(t.TempDir(), "missing.jsonl")); err != nil || missing != nil {
+		t.Errorf("expected a missing log to have no entries, got %v, %v", missing, err)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	cost := 0.5
+	day := time.Date(2026, 1, 2, 12, 0, 0, 0, time.Local)
+	entries := []Entry{
+		{Time: day, Provider: "claude", Model: "sonnet", Tokens: Tokens{Input: 100, Output: 10}, Cost: &cost},
+		{Time: day, Provider: "claude", Model: "sonnet", Tokens: Tokens{Input: 50, Output: 5}, Cost: &cost},
+		{Time: day.AddDate(0, 0, 1), Provider: "geminicli", Model: "gemini", Tokens: Tokens{Input: 7}},
+	}
+
+	rows, err := Summarize(entries, []string{"provider", "day"})
+	if err != nil {
+		t.Fatalf("Summarize failed: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", rows)
+	}
+	if rows[0].Keys[0] != "claude" || rows[0].Keys[1] != "2026-01-02" || rows[0].Requests != 2 || rows[0].Input != 150 || rows[0].Cost != 1 {
+		t.Errorf("unexpected first row %+v", rows[0])
+	}
+	if rows[1].FormatCost() != "-" {
+		t.Errorf("expected an unpriced row to have no cost, got %q", rows[1].FormatCost())
+	}
+
+	table := FormatTable(rows, []string{"provider", "day"})
+	if !strings.Contains(table, "total") || !strings.Contains(table, "$1.0000 (+1 unpriced)") {
+		t.Errorf("unexpected table:\n%s", table)
+	}
+	if _, err := Summarize(entries, []string{"week"}); err == nil {
+		t.Error("expected an error for an unknown grouping")
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	tests := map[string]time.Time{
+		"7d":         time.Date(2026, 3, 3, 12, 0, 0, 0, time.Local),
+		"12h":        time.Date(2026, 3, 10, 0, 0, 0, 0, time.Local),
+		"2026-01-31": time.Date(2026, 1, 31, 0, 0, 0, 0, time.Local),
+	}
+	for value, want := range tests {
+		if got, err := ParseSince(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("ParseSince(%q) = %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if _, err := ParseSince("last week", now); err == nil {
+		t.Error("expected an error for an invalid period")
+	}
+}
+
+func TestCacheString(t *testing.T) {
+	if got := (Row{Tokens: Tokens{CacheRead: 1500, CacheCreation: 20}}).CacheString(); got != "1500 tokens read, 20 written" {
+		t.Errorf("CacheString = %q", got)
+	}
+	if got := (Row{Tokens: Tokens{Input: 100}}).CacheString(); !strings.HasPrefix(got, "not used") {
+		t.Errorf("CacheString = %q, want not used", got)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/config"
+	"github.com/UNILORN/generative-commit-message-for-ai-tool/usage"
+)
+
+// runUsage summarizes the tokens and cost of the usage log
+func runUsage(args []string) {
+	usageFlags := flag.NewFlagSet("usage", flag.ExitOnError)
+	configPath := usageFlags.String("config", "", "Path to config file (merged over discovered config files)")
+	profile := usageFlags.String("profile", "", "Config profile to apply (auto-selected by remote URL if not specified)")
+	by := usageFlags.String("by", "day", "Comma-separated grouping: "+strings.Join(usage.Keys, ", "))
+	since := usageFlags.String("since", "", "Only count requests since a number of days, a duration or a date (e.g. 7d, 12h, 2026-01-31)")
+	format := usageFlags.String("format", "text", "Output format: text or json")
+	usageFlags.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format '%s' (available: text, json)\n", *format)
+		os.Exit(1)
+	}
+	if err := config.InitGlobalWithOptions(config.LoadOptions{ConfigPath: *configPath, Profile: *profile}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing config: %v\n", err)
+		os.Exit(1)
+	}
+
+	path, err := usage.LogPath(config.Get().Usage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	entries, err := usage.Read(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading usage log: %v\n", err)
+		os.Exit(1)
+	}
+	if *since != "" {
+		start, err := usage.ParseSince(*since, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		entries = usage.Since(entries, start)
+	}
+
+	var keys []string
+	for _, key := range strings.Split(*by, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	rows, err := usage.Summarize(entries, keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
+	if len(rows) == 0 {
+		fmt.Printf("No usage recorded in %s\n", path)
+		return
+	}
+	fmt.Print(usage.FormatTable(rows, keys))
+}